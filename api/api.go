@@ -0,0 +1,43 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package api contains the OpenAPI specifications of the sidecar and tracker APIs.
+//
+// The specs are the contract for clients in other languages.
+// Servers and the Go clients in internal/fetch are checked against them in tests.
+package api
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sidecar is the OpenAPI 3 specification of the sidecar API.
+//
+//go:embed sidecar.json
+var Sidecar []byte
+
+// Tracker is the OpenAPI 3 specification of the public tracker API.
+//
+//go:embed tracker.json
+var Tracker []byte
+
+// Handler serves an OpenAPI specification.
+func Handler(spec []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", spec)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/internal/openapi"
+)
+
+func TestSpecs(t *testing.T) {
+	for name, spec := range map[string][]byte{
+		"sidecar": Sidecar,
+		"tracker": Tracker,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := openapi.Load(spec)
+			require.NoError(t, err)
+		})
+	}
+}
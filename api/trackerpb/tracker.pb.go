@@ -0,0 +1,1206 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: tracker.proto
+
+package trackerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Filters of snapshot queries. Unset fields match all snapshots.
+type SnapshotQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	MinSlot       uint64                 `protobuf:"varint,3,opt,name=min_slot,json=minSlot,proto3" json:"min_slot,omitempty"`
+	MaxSlot       uint64                 `protobuf:"varint,4,opt,name=max_slot,json=maxSlot,proto3" json:"max_slot,omitempty"`
+	Hash          string                 `protobuf:"bytes,5,opt,name=hash,proto3" json:"hash,omitempty"` // base58
+	Kind          string                 `protobuf:"bytes,6,opt,name=kind,proto3" json:"kind,omitempty"` // "full" or "incremental"
+	BaseSlot      *uint64                `protobuf:"varint,7,opt,name=base_slot,json=baseSlot,proto3,oneof" json:"base_slot,omitempty"`
+	MinSize       uint64                 `protobuf:"varint,8,opt,name=min_size,json=minSize,proto3" json:"min_size,omitempty"`
+	MaxAge        *durationpb.Duration   `protobuf:"bytes,9,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"` // time since the snapshot was created
+	Verified      bool                   `protobuf:"varint,10,opt,name=verified,proto3" json:"verified,omitempty"`
+	LocalOnly     bool                   `protobuf:"varint,11,opt,name=local_only,json=localOnly,proto3" json:"local_only,omitempty"` // exclude sources learned from federated trackers
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotQuery) Reset() {
+	*x = SnapshotQuery{}
+	mi := &file_tracker_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotQuery) ProtoMessage() {}
+
+func (x *SnapshotQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotQuery.ProtoReflect.Descriptor instead.
+func (*SnapshotQuery) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SnapshotQuery) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *SnapshotQuery) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *SnapshotQuery) GetMinSlot() uint64 {
+	if x != nil {
+		return x.MinSlot
+	}
+	return 0
+}
+
+func (x *SnapshotQuery) GetMaxSlot() uint64 {
+	if x != nil {
+		return x.MaxSlot
+	}
+	return 0
+}
+
+func (x *SnapshotQuery) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *SnapshotQuery) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *SnapshotQuery) GetBaseSlot() uint64 {
+	if x != nil && x.BaseSlot != nil {
+		return *x.BaseSlot
+	}
+	return 0
+}
+
+func (x *SnapshotQuery) GetMinSize() uint64 {
+	if x != nil {
+		return x.MinSize
+	}
+	return 0
+}
+
+func (x *SnapshotQuery) GetMaxAge() *durationpb.Duration {
+	if x != nil {
+		return x.MaxAge
+	}
+	return nil
+}
+
+func (x *SnapshotQuery) GetVerified() bool {
+	if x != nil {
+		return x.Verified
+	}
+	return false
+}
+
+func (x *SnapshotQuery) GetLocalOnly() bool {
+	if x != nil {
+		return x.LocalOnly
+	}
+	return false
+}
+
+type ListSnapshotsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         *SnapshotQuery         `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Sort          string                 `protobuf:"bytes,2,opt,name=sort,proto3" json:"sort,omitempty"`
+	Cursor        string                 `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"` // returned by the previous page
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`  // page size, all snapshots if zero
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSnapshotsRequest) Reset() {
+	*x = ListSnapshotsRequest{}
+	mi := &file_tracker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSnapshotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSnapshotsRequest) ProtoMessage() {}
+
+func (x *ListSnapshotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSnapshotsRequest.ProtoReflect.Descriptor instead.
+func (*ListSnapshotsRequest) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListSnapshotsRequest) GetQuery() *SnapshotQuery {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *ListSnapshotsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListSnapshotsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListSnapshotsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListSnapshotsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshots     []*SnapshotEntry       `protobuf:"bytes,1,rep,name=snapshots,proto3" json:"snapshots,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"` // empty on the last page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSnapshotsResponse) Reset() {
+	*x = ListSnapshotsResponse{}
+	mi := &file_tracker_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSnapshotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSnapshotsResponse) ProtoMessage() {}
+
+func (x *ListSnapshotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSnapshotsResponse.ProtoReflect.Descriptor instead.
+func (*ListSnapshotsResponse) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListSnapshotsResponse) GetSnapshots() []*SnapshotEntry {
+	if x != nil {
+		return x.Snapshots
+	}
+	return nil
+}
+
+func (x *ListSnapshotsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type GetBestSnapshotsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         *SnapshotQuery         `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"` // sort and pagination are not supported
+	Max           int32                  `protobuf:"varint,2,opt,name=max,proto3" json:"max,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBestSnapshotsRequest) Reset() {
+	*x = GetBestSnapshotsRequest{}
+	mi := &file_tracker_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBestSnapshotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBestSnapshotsRequest) ProtoMessage() {}
+
+func (x *GetBestSnapshotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBestSnapshotsRequest.ProtoReflect.Descriptor instead.
+func (*GetBestSnapshotsRequest) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetBestSnapshotsRequest) GetQuery() *SnapshotQuery {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *GetBestSnapshotsRequest) GetMax() int32 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+type GetBestSnapshotsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshots     []*SnapshotSource      `protobuf:"bytes,1,rep,name=snapshots,proto3" json:"snapshots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBestSnapshotsResponse) Reset() {
+	*x = GetBestSnapshotsResponse{}
+	mi := &file_tracker_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBestSnapshotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBestSnapshotsResponse) ProtoMessage() {}
+
+func (x *GetBestSnapshotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBestSnapshotsResponse.ProtoReflect.Descriptor instead.
+func (*GetBestSnapshotsResponse) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetBestSnapshotsResponse) GetSnapshots() []*SnapshotSource {
+	if x != nil {
+		return x.Snapshots
+	}
+	return nil
+}
+
+type BestSnapshotsEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshots     []*SnapshotSource      `protobuf:"bytes,1,rep,name=snapshots,proto3" json:"snapshots,omitempty"` // current ranking
+	Added         []*SnapshotID          `protobuf:"bytes,2,rep,name=added,proto3" json:"added,omitempty"`         // snapshots that gained their first source
+	Removed       []*SnapshotID          `protobuf:"bytes,3,rep,name=removed,proto3" json:"removed,omitempty"`     // snapshots that lost all sources
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BestSnapshotsEvent) Reset() {
+	*x = BestSnapshotsEvent{}
+	mi := &file_tracker_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BestSnapshotsEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BestSnapshotsEvent) ProtoMessage() {}
+
+func (x *BestSnapshotsEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BestSnapshotsEvent.ProtoReflect.Descriptor instead.
+func (*BestSnapshotsEvent) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BestSnapshotsEvent) GetSnapshots() []*SnapshotSource {
+	if x != nil {
+		return x.Snapshots
+	}
+	return nil
+}
+
+func (x *BestSnapshotsEvent) GetAdded() []*SnapshotID {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *BestSnapshotsEvent) GetRemoved() []*SnapshotID {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+// A snapshot served by a target, as stored in the index.
+type SnapshotEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        string                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	Group         string                 `protobuf:"bytes,2,opt,name=group,proto3" json:"group,omitempty"`
+	Info          *SnapshotInfo          `protobuf:"bytes,3,opt,name=info,proto3" json:"info,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Unverified    bool                   `protobuf:"varint,5,opt,name=unverified,proto3" json:"unverified,omitempty"` // restored from disk, not yet scraped again
+	Origin        string                 `protobuf:"bytes,6,opt,name=origin,proto3" json:"origin,omitempty"`          // federated tracker the entry was learned from
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotEntry) Reset() {
+	*x = SnapshotEntry{}
+	mi := &file_tracker_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotEntry) ProtoMessage() {}
+
+func (x *SnapshotEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotEntry.ProtoReflect.Descriptor instead.
+func (*SnapshotEntry) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SnapshotEntry) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *SnapshotEntry) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *SnapshotEntry) GetInfo() *SnapshotInfo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *SnapshotEntry) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *SnapshotEntry) GetUnverified() bool {
+	if x != nil {
+		return x.Unverified
+	}
+	return false
+}
+
+func (x *SnapshotEntry) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+// A snapshot and where to get it from.
+type SnapshotSource struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Info          *SnapshotInfo          `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Unverified    bool                   `protobuf:"varint,4,opt,name=unverified,proto3" json:"unverified,omitempty"`
+	Score         *SnapshotScore         `protobuf:"bytes,5,opt,name=score,proto3" json:"score,omitempty"`   // set if the tracker ranks by score
+	Origin        string                 `protobuf:"bytes,6,opt,name=origin,proto3" json:"origin,omitempty"` // federated tracker the source was learned from
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotSource) Reset() {
+	*x = SnapshotSource{}
+	mi := &file_tracker_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotSource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotSource) ProtoMessage() {}
+
+func (x *SnapshotSource) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotSource.ProtoReflect.Descriptor instead.
+func (*SnapshotSource) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SnapshotSource) GetInfo() *SnapshotInfo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *SnapshotSource) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *SnapshotSource) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *SnapshotSource) GetUnverified() bool {
+	if x != nil {
+		return x.Unverified
+	}
+	return false
+}
+
+func (x *SnapshotSource) GetScore() *SnapshotScore {
+	if x != nil {
+		return x.Score
+	}
+	return nil
+}
+
+func (x *SnapshotSource) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+type SnapshotInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Slot          uint64                 `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"` // base58
+	Files         []*SnapshotFile        `protobuf:"bytes,3,rep,name=files,proto3" json:"files,omitempty"`
+	Size          uint64                 `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	Draining      bool                   `protobuf:"varint,5,opt,name=draining,proto3" json:"draining,omitempty"` // source is shutting down
+	Metadata      *SnapshotMetadata      `protobuf:"bytes,6,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotInfo) Reset() {
+	*x = SnapshotInfo{}
+	mi := &file_tracker_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotInfo) ProtoMessage() {}
+
+func (x *SnapshotInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotInfo.ProtoReflect.Descriptor instead.
+func (*SnapshotInfo) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SnapshotInfo) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *SnapshotInfo) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *SnapshotInfo) GetFiles() []*SnapshotFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *SnapshotInfo) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *SnapshotInfo) GetDraining() bool {
+	if x != nil {
+		return x.Draining
+	}
+	return false
+}
+
+func (x *SnapshotInfo) GetMetadata() *SnapshotMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type SnapshotFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileName      string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Slot          uint64                 `protobuf:"varint,2,opt,name=slot,proto3" json:"slot,omitempty"`
+	BaseSlot      uint64                 `protobuf:"varint,3,opt,name=base_slot,json=baseSlot,proto3" json:"base_slot,omitempty"`
+	Hash          string                 `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"` // base58
+	Ext           string                 `protobuf:"bytes,5,opt,name=ext,proto3" json:"ext,omitempty"`
+	ModTime       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=mod_time,json=modTime,proto3" json:"mod_time,omitempty"`
+	Size          uint64                 `protobuf:"varint,7,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotFile) Reset() {
+	*x = SnapshotFile{}
+	mi := &file_tracker_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotFile) ProtoMessage() {}
+
+func (x *SnapshotFile) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotFile.ProtoReflect.Descriptor instead.
+func (*SnapshotFile) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SnapshotFile) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *SnapshotFile) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *SnapshotFile) GetBaseSlot() uint64 {
+	if x != nil {
+		return x.BaseSlot
+	}
+	return 0
+}
+
+func (x *SnapshotFile) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *SnapshotFile) GetExt() string {
+	if x != nil {
+		return x.Ext
+	}
+	return ""
+}
+
+func (x *SnapshotFile) GetModTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ModTime
+	}
+	return nil
+}
+
+func (x *SnapshotFile) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type SnapshotMetadata struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Version        string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Epoch          uint64                 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	ParentSlot     uint64                 `protobuf:"varint,3,opt,name=parent_slot,json=parentSlot,proto3" json:"parent_slot,omitempty"`
+	Capitalization uint64                 `protobuf:"varint,4,opt,name=capitalization,proto3" json:"capitalization,omitempty"`
+	AccountsHash   string                 `protobuf:"bytes,5,opt,name=accounts_hash,json=accountsHash,proto3" json:"accounts_hash,omitempty"` // base58
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SnapshotMetadata) Reset() {
+	*x = SnapshotMetadata{}
+	mi := &file_tracker_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotMetadata) ProtoMessage() {}
+
+func (x *SnapshotMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotMetadata.ProtoReflect.Descriptor instead.
+func (*SnapshotMetadata) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SnapshotMetadata) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *SnapshotMetadata) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *SnapshotMetadata) GetParentSlot() uint64 {
+	if x != nil {
+		return x.ParentSlot
+	}
+	return 0
+}
+
+func (x *SnapshotMetadata) GetCapitalization() uint64 {
+	if x != nil {
+		return x.Capitalization
+	}
+	return 0
+}
+
+func (x *SnapshotMetadata) GetAccountsHash() string {
+	if x != nil {
+		return x.AccountsHash
+	}
+	return ""
+}
+
+type SnapshotScore struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         float64                `protobuf:"fixed64,1,opt,name=total,proto3" json:"total,omitempty"`
+	Slot          float64                `protobuf:"fixed64,2,opt,name=slot,proto3" json:"slot,omitempty"`
+	Freshness     float64                `protobuf:"fixed64,3,opt,name=freshness,proto3" json:"freshness,omitempty"`
+	Latency       float64                `protobuf:"fixed64,4,opt,name=latency,proto3" json:"latency,omitempty"`
+	Health        float64                `protobuf:"fixed64,5,opt,name=health,proto3" json:"health,omitempty"`
+	Agreement     float64                `protobuf:"fixed64,6,opt,name=agreement,proto3" json:"agreement,omitempty"`
+	Labels        float64                `protobuf:"fixed64,7,opt,name=labels,proto3" json:"labels,omitempty"`
+	Origin        float64                `protobuf:"fixed64,8,opt,name=origin,proto3" json:"origin,omitempty"`                  // federation penalty
+	SlotLag       float64                `protobuf:"fixed64,9,opt,name=slot_lag,json=slotLag,proto3" json:"slot_lag,omitempty"` // processed slot lag of the node
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotScore) Reset() {
+	*x = SnapshotScore{}
+	mi := &file_tracker_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotScore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotScore) ProtoMessage() {}
+
+func (x *SnapshotScore) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotScore.ProtoReflect.Descriptor instead.
+func (*SnapshotScore) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SnapshotScore) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *SnapshotScore) GetSlot() float64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *SnapshotScore) GetFreshness() float64 {
+	if x != nil {
+		return x.Freshness
+	}
+	return 0
+}
+
+func (x *SnapshotScore) GetLatency() float64 {
+	if x != nil {
+		return x.Latency
+	}
+	return 0
+}
+
+func (x *SnapshotScore) GetHealth() float64 {
+	if x != nil {
+		return x.Health
+	}
+	return 0
+}
+
+func (x *SnapshotScore) GetAgreement() float64 {
+	if x != nil {
+		return x.Agreement
+	}
+	return 0
+}
+
+func (x *SnapshotScore) GetLabels() float64 {
+	if x != nil {
+		return x.Labels
+	}
+	return 0
+}
+
+func (x *SnapshotScore) GetOrigin() float64 {
+	if x != nil {
+		return x.Origin
+	}
+	return 0
+}
+
+func (x *SnapshotScore) GetSlotLag() float64 {
+	if x != nil {
+		return x.SlotLag
+	}
+	return 0
+}
+
+type SnapshotID struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Slot          uint64                 `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"` // base58
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotID) Reset() {
+	*x = SnapshotID{}
+	mi := &file_tracker_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotID) ProtoMessage() {}
+
+func (x *SnapshotID) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotID.ProtoReflect.Descriptor instead.
+func (*SnapshotID) Descriptor() ([]byte, []int) {
+	return file_tracker_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SnapshotID) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *SnapshotID) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+var File_tracker_proto protoreflect.FileDescriptor
+
+const file_tracker_proto_rawDesc = "" +
+	"\n" +
+	"\rtracker.proto\x12!solana.cluster_manager.tracker.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd5\x02\n" +
+	"\rSnapshotQuery\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x12\x19\n" +
+	"\bmin_slot\x18\x03 \x01(\x04R\aminSlot\x12\x19\n" +
+	"\bmax_slot\x18\x04 \x01(\x04R\amaxSlot\x12\x12\n" +
+	"\x04hash\x18\x05 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04kind\x18\x06 \x01(\tR\x04kind\x12 \n" +
+	"\tbase_slot\x18\a \x01(\x04H\x00R\bbaseSlot\x88\x01\x01\x12\x19\n" +
+	"\bmin_size\x18\b \x01(\x04R\aminSize\x122\n" +
+	"\amax_age\x18\t \x01(\v2\x19.google.protobuf.DurationR\x06maxAge\x12\x1a\n" +
+	"\bverified\x18\n" +
+	" \x01(\bR\bverified\x12\x1d\n" +
+	"\n" +
+	"local_only\x18\v \x01(\bR\tlocalOnlyB\f\n" +
+	"\n" +
+	"_base_slot\"\xa0\x01\n" +
+	"\x14ListSnapshotsRequest\x12F\n" +
+	"\x05query\x18\x01 \x01(\v20.solana.cluster_manager.tracker.v1.SnapshotQueryR\x05query\x12\x12\n" +
+	"\x04sort\x18\x02 \x01(\tR\x04sort\x12\x16\n" +
+	"\x06cursor\x18\x03 \x01(\tR\x06cursor\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"\x88\x01\n" +
+	"\x15ListSnapshotsResponse\x12N\n" +
+	"\tsnapshots\x18\x01 \x03(\v20.solana.cluster_manager.tracker.v1.SnapshotEntryR\tsnapshots\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"s\n" +
+	"\x17GetBestSnapshotsRequest\x12F\n" +
+	"\x05query\x18\x01 \x01(\v20.solana.cluster_manager.tracker.v1.SnapshotQueryR\x05query\x12\x10\n" +
+	"\x03max\x18\x02 \x01(\x05R\x03max\"k\n" +
+	"\x18GetBestSnapshotsResponse\x12O\n" +
+	"\tsnapshots\x18\x01 \x03(\v21.solana.cluster_manager.tracker.v1.SnapshotSourceR\tsnapshots\"\xf3\x01\n" +
+	"\x12BestSnapshotsEvent\x12O\n" +
+	"\tsnapshots\x18\x01 \x03(\v21.solana.cluster_manager.tracker.v1.SnapshotSourceR\tsnapshots\x12C\n" +
+	"\x05added\x18\x02 \x03(\v2-.solana.cluster_manager.tracker.v1.SnapshotIDR\x05added\x12G\n" +
+	"\aremoved\x18\x03 \x03(\v2-.solana.cluster_manager.tracker.v1.SnapshotIDR\aremoved\"\xf5\x01\n" +
+	"\rSnapshotEntry\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\x12\x14\n" +
+	"\x05group\x18\x02 \x01(\tR\x05group\x12C\n" +
+	"\x04info\x18\x03 \x01(\v2/.solana.cluster_manager.tracker.v1.SnapshotInfoR\x04info\x129\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1e\n" +
+	"\n" +
+	"unverified\x18\x05 \x01(\bR\n" +
+	"unverified\x12\x16\n" +
+	"\x06origin\x18\x06 \x01(\tR\x06origin\"\xa8\x02\n" +
+	"\x0eSnapshotSource\x12C\n" +
+	"\x04info\x18\x01 \x01(\v2/.solana.cluster_manager.tracker.v1.SnapshotInfoR\x04info\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x129\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1e\n" +
+	"\n" +
+	"unverified\x18\x04 \x01(\bR\n" +
+	"unverified\x12F\n" +
+	"\x05score\x18\x05 \x01(\v20.solana.cluster_manager.tracker.v1.SnapshotScoreR\x05score\x12\x16\n" +
+	"\x06origin\x18\x06 \x01(\tR\x06origin\"\xfe\x01\n" +
+	"\fSnapshotInfo\x12\x12\n" +
+	"\x04slot\x18\x01 \x01(\x04R\x04slot\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\x12E\n" +
+	"\x05files\x18\x03 \x03(\v2/.solana.cluster_manager.tracker.v1.SnapshotFileR\x05files\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x04R\x04size\x12\x1a\n" +
+	"\bdraining\x18\x05 \x01(\bR\bdraining\x12O\n" +
+	"\bmetadata\x18\x06 \x01(\v23.solana.cluster_manager.tracker.v1.SnapshotMetadataR\bmetadata\"\xcd\x01\n" +
+	"\fSnapshotFile\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\x12\x12\n" +
+	"\x04slot\x18\x02 \x01(\x04R\x04slot\x12\x1b\n" +
+	"\tbase_slot\x18\x03 \x01(\x04R\bbaseSlot\x12\x12\n" +
+	"\x04hash\x18\x04 \x01(\tR\x04hash\x12\x10\n" +
+	"\x03ext\x18\x05 \x01(\tR\x03ext\x125\n" +
+	"\bmod_time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\amodTime\x12\x12\n" +
+	"\x04size\x18\a \x01(\x04R\x04size\"\xb0\x01\n" +
+	"\x10SnapshotMetadata\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x04R\x05epoch\x12\x1f\n" +
+	"\vparent_slot\x18\x03 \x01(\x04R\n" +
+	"parentSlot\x12&\n" +
+	"\x0ecapitalization\x18\x04 \x01(\x04R\x0ecapitalization\x12#\n" +
+	"\raccounts_hash\x18\x05 \x01(\tR\faccountsHash\"\xf2\x01\n" +
+	"\rSnapshotScore\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x01R\x05total\x12\x12\n" +
+	"\x04slot\x18\x02 \x01(\x01R\x04slot\x12\x1c\n" +
+	"\tfreshness\x18\x03 \x01(\x01R\tfreshness\x12\x18\n" +
+	"\alatency\x18\x04 \x01(\x01R\alatency\x12\x16\n" +
+	"\x06health\x18\x05 \x01(\x01R\x06health\x12\x1c\n" +
+	"\tagreement\x18\x06 \x01(\x01R\tagreement\x12\x16\n" +
+	"\x06labels\x18\a \x01(\x01R\x06labels\x12\x16\n" +
+	"\x06origin\x18\b \x01(\x01R\x06origin\x12\x19\n" +
+	"\bslot_lag\x18\t \x01(\x01R\aslotLag\"4\n" +
+	"\n" +
+	"SnapshotID\x12\x12\n" +
+	"\x04slot\x18\x01 \x01(\x04R\x04slot\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash2\xa8\x03\n" +
+	"\aTracker\x12\x82\x01\n" +
+	"\rListSnapshots\x127.solana.cluster_manager.tracker.v1.ListSnapshotsRequest\x1a8.solana.cluster_manager.tracker.v1.ListSnapshotsResponse\x12\x8b\x01\n" +
+	"\x10GetBestSnapshots\x12:.solana.cluster_manager.tracker.v1.GetBestSnapshotsRequest\x1a;.solana.cluster_manager.tracker.v1.GetBestSnapshotsResponse\x12\x89\x01\n" +
+	"\x12WatchBestSnapshots\x12:.solana.cluster_manager.tracker.v1.GetBestSnapshotsRequest\x1a5.solana.cluster_manager.tracker.v1.BestSnapshotsEvent0\x01B9Z7go.blockdaemon.com/solana/cluster-manager/api/trackerpbb\x06proto3"
+
+var (
+	file_tracker_proto_rawDescOnce sync.Once
+	file_tracker_proto_rawDescData []byte
+)
+
+func file_tracker_proto_rawDescGZIP() []byte {
+	file_tracker_proto_rawDescOnce.Do(func() {
+		file_tracker_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_tracker_proto_rawDesc), len(file_tracker_proto_rawDesc)))
+	})
+	return file_tracker_proto_rawDescData
+}
+
+var file_tracker_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_tracker_proto_goTypes = []any{
+	(*SnapshotQuery)(nil),            // 0: solana.cluster_manager.tracker.v1.SnapshotQuery
+	(*ListSnapshotsRequest)(nil),     // 1: solana.cluster_manager.tracker.v1.ListSnapshotsRequest
+	(*ListSnapshotsResponse)(nil),    // 2: solana.cluster_manager.tracker.v1.ListSnapshotsResponse
+	(*GetBestSnapshotsRequest)(nil),  // 3: solana.cluster_manager.tracker.v1.GetBestSnapshotsRequest
+	(*GetBestSnapshotsResponse)(nil), // 4: solana.cluster_manager.tracker.v1.GetBestSnapshotsResponse
+	(*BestSnapshotsEvent)(nil),       // 5: solana.cluster_manager.tracker.v1.BestSnapshotsEvent
+	(*SnapshotEntry)(nil),            // 6: solana.cluster_manager.tracker.v1.SnapshotEntry
+	(*SnapshotSource)(nil),           // 7: solana.cluster_manager.tracker.v1.SnapshotSource
+	(*SnapshotInfo)(nil),             // 8: solana.cluster_manager.tracker.v1.SnapshotInfo
+	(*SnapshotFile)(nil),             // 9: solana.cluster_manager.tracker.v1.SnapshotFile
+	(*SnapshotMetadata)(nil),         // 10: solana.cluster_manager.tracker.v1.SnapshotMetadata
+	(*SnapshotScore)(nil),            // 11: solana.cluster_manager.tracker.v1.SnapshotScore
+	(*SnapshotID)(nil),               // 12: solana.cluster_manager.tracker.v1.SnapshotID
+	(*durationpb.Duration)(nil),      // 13: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil),    // 14: google.protobuf.Timestamp
+}
+var file_tracker_proto_depIdxs = []int32{
+	13, // 0: solana.cluster_manager.tracker.v1.SnapshotQuery.max_age:type_name -> google.protobuf.Duration
+	0,  // 1: solana.cluster_manager.tracker.v1.ListSnapshotsRequest.query:type_name -> solana.cluster_manager.tracker.v1.SnapshotQuery
+	6,  // 2: solana.cluster_manager.tracker.v1.ListSnapshotsResponse.snapshots:type_name -> solana.cluster_manager.tracker.v1.SnapshotEntry
+	0,  // 3: solana.cluster_manager.tracker.v1.GetBestSnapshotsRequest.query:type_name -> solana.cluster_manager.tracker.v1.SnapshotQuery
+	7,  // 4: solana.cluster_manager.tracker.v1.GetBestSnapshotsResponse.snapshots:type_name -> solana.cluster_manager.tracker.v1.SnapshotSource
+	7,  // 5: solana.cluster_manager.tracker.v1.BestSnapshotsEvent.snapshots:type_name -> solana.cluster_manager.tracker.v1.SnapshotSource
+	12, // 6: solana.cluster_manager.tracker.v1.BestSnapshotsEvent.added:type_name -> solana.cluster_manager.tracker.v1.SnapshotID
+	12, // 7: solana.cluster_manager.tracker.v1.BestSnapshotsEvent.removed:type_name -> solana.cluster_manager.tracker.v1.SnapshotID
+	8,  // 8: solana.cluster_manager.tracker.v1.SnapshotEntry.info:type_name -> solana.cluster_manager.tracker.v1.SnapshotInfo
+	14, // 9: solana.cluster_manager.tracker.v1.SnapshotEntry.updated_at:type_name -> google.protobuf.Timestamp
+	8,  // 10: solana.cluster_manager.tracker.v1.SnapshotSource.info:type_name -> solana.cluster_manager.tracker.v1.SnapshotInfo
+	14, // 11: solana.cluster_manager.tracker.v1.SnapshotSource.updated_at:type_name -> google.protobuf.Timestamp
+	11, // 12: solana.cluster_manager.tracker.v1.SnapshotSource.score:type_name -> solana.cluster_manager.tracker.v1.SnapshotScore
+	9,  // 13: solana.cluster_manager.tracker.v1.SnapshotInfo.files:type_name -> solana.cluster_manager.tracker.v1.SnapshotFile
+	10, // 14: solana.cluster_manager.tracker.v1.SnapshotInfo.metadata:type_name -> solana.cluster_manager.tracker.v1.SnapshotMetadata
+	14, // 15: solana.cluster_manager.tracker.v1.SnapshotFile.mod_time:type_name -> google.protobuf.Timestamp
+	1,  // 16: solana.cluster_manager.tracker.v1.Tracker.ListSnapshots:input_type -> solana.cluster_manager.tracker.v1.ListSnapshotsRequest
+	3,  // 17: solana.cluster_manager.tracker.v1.Tracker.GetBestSnapshots:input_type -> solana.cluster_manager.tracker.v1.GetBestSnapshotsRequest
+	3,  // 18: solana.cluster_manager.tracker.v1.Tracker.WatchBestSnapshots:input_type -> solana.cluster_manager.tracker.v1.GetBestSnapshotsRequest
+	2,  // 19: solana.cluster_manager.tracker.v1.Tracker.ListSnapshots:output_type -> solana.cluster_manager.tracker.v1.ListSnapshotsResponse
+	4,  // 20: solana.cluster_manager.tracker.v1.Tracker.GetBestSnapshots:output_type -> solana.cluster_manager.tracker.v1.GetBestSnapshotsResponse
+	5,  // 21: solana.cluster_manager.tracker.v1.Tracker.WatchBestSnapshots:output_type -> solana.cluster_manager.tracker.v1.BestSnapshotsEvent
+	19, // [19:22] is the sub-list for method output_type
+	16, // [16:19] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_tracker_proto_init() }
+func file_tracker_proto_init() {
+	if File_tracker_proto != nil {
+		return
+	}
+	file_tracker_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tracker_proto_rawDesc), len(file_tracker_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tracker_proto_goTypes,
+		DependencyIndexes: file_tracker_proto_depIdxs,
+		MessageInfos:      file_tracker_proto_msgTypes,
+	}.Build()
+	File_tracker_proto = out.File
+	file_tracker_proto_goTypes = nil
+	file_tracker_proto_depIdxs = nil
+}
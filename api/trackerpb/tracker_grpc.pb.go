@@ -0,0 +1,231 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: tracker.proto
+
+package trackerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Tracker_ListSnapshots_FullMethodName      = "/solana.cluster_manager.tracker.v1.Tracker/ListSnapshots"
+	Tracker_GetBestSnapshots_FullMethodName   = "/solana.cluster_manager.tracker.v1.Tracker/GetBestSnapshots"
+	Tracker_WatchBestSnapshots_FullMethodName = "/solana.cluster_manager.tracker.v1.Tracker/WatchBestSnapshots"
+)
+
+// TrackerClient is the client API for Tracker service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Tracker serves the snapshot index, mirroring the HTTP API in api/tracker.json.
+//
+// Calls require the read role if the tracker is configured with API clients.
+// Tokens are passed in the "authorization" metadata as "Bearer <token>".
+type TrackerClient interface {
+	// Lists known snapshots matching the query, one page at a time.
+	ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error)
+	// Finds the best snapshots.
+	GetBestSnapshots(ctx context.Context, in *GetBestSnapshotsRequest, opts ...grpc.CallOption) (*GetBestSnapshotsResponse, error)
+	// Streams the best snapshots initially and whenever the ranking changes.
+	WatchBestSnapshots(ctx context.Context, in *GetBestSnapshotsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BestSnapshotsEvent], error)
+}
+
+type trackerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTrackerClient(cc grpc.ClientConnInterface) TrackerClient {
+	return &trackerClient{cc}
+}
+
+func (c *trackerClient) ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSnapshotsResponse)
+	err := c.cc.Invoke(ctx, Tracker_ListSnapshots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerClient) GetBestSnapshots(ctx context.Context, in *GetBestSnapshotsRequest, opts ...grpc.CallOption) (*GetBestSnapshotsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBestSnapshotsResponse)
+	err := c.cc.Invoke(ctx, Tracker_GetBestSnapshots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerClient) WatchBestSnapshots(ctx context.Context, in *GetBestSnapshotsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BestSnapshotsEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Tracker_ServiceDesc.Streams[0], Tracker_WatchBestSnapshots_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetBestSnapshotsRequest, BestSnapshotsEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Tracker_WatchBestSnapshotsClient = grpc.ServerStreamingClient[BestSnapshotsEvent]
+
+// TrackerServer is the server API for Tracker service.
+// All implementations must embed UnimplementedTrackerServer
+// for forward compatibility.
+//
+// Tracker serves the snapshot index, mirroring the HTTP API in api/tracker.json.
+//
+// Calls require the read role if the tracker is configured with API clients.
+// Tokens are passed in the "authorization" metadata as "Bearer <token>".
+type TrackerServer interface {
+	// Lists known snapshots matching the query, one page at a time.
+	ListSnapshots(context.Context, *ListSnapshotsRequest) (*ListSnapshotsResponse, error)
+	// Finds the best snapshots.
+	GetBestSnapshots(context.Context, *GetBestSnapshotsRequest) (*GetBestSnapshotsResponse, error)
+	// Streams the best snapshots initially and whenever the ranking changes.
+	WatchBestSnapshots(*GetBestSnapshotsRequest, grpc.ServerStreamingServer[BestSnapshotsEvent]) error
+	mustEmbedUnimplementedTrackerServer()
+}
+
+// UnimplementedTrackerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTrackerServer struct{}
+
+func (UnimplementedTrackerServer) ListSnapshots(context.Context, *ListSnapshotsRequest) (*ListSnapshotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSnapshots not implemented")
+}
+func (UnimplementedTrackerServer) GetBestSnapshots(context.Context, *GetBestSnapshotsRequest) (*GetBestSnapshotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBestSnapshots not implemented")
+}
+func (UnimplementedTrackerServer) WatchBestSnapshots(*GetBestSnapshotsRequest, grpc.ServerStreamingServer[BestSnapshotsEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchBestSnapshots not implemented")
+}
+func (UnimplementedTrackerServer) mustEmbedUnimplementedTrackerServer() {}
+func (UnimplementedTrackerServer) testEmbeddedByValue()                 {}
+
+// UnsafeTrackerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TrackerServer will
+// result in compilation errors.
+type UnsafeTrackerServer interface {
+	mustEmbedUnimplementedTrackerServer()
+}
+
+func RegisterTrackerServer(s grpc.ServiceRegistrar, srv TrackerServer) {
+	// If the following call pancis, it indicates UnimplementedTrackerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Tracker_ServiceDesc, srv)
+}
+
+func _Tracker_ListSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSnapshotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServer).ListSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tracker_ListSnapshots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServer).ListSnapshots(ctx, req.(*ListSnapshotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tracker_GetBestSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBestSnapshotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServer).GetBestSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tracker_GetBestSnapshots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServer).GetBestSnapshots(ctx, req.(*GetBestSnapshotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tracker_WatchBestSnapshots_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetBestSnapshotsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TrackerServer).WatchBestSnapshots(m, &grpc.GenericServerStream[GetBestSnapshotsRequest, BestSnapshotsEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Tracker_WatchBestSnapshotsServer = grpc.ServerStreamingServer[BestSnapshotsEvent]
+
+// Tracker_ServiceDesc is the grpc.ServiceDesc for Tracker service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Tracker_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "solana.cluster_manager.tracker.v1.Tracker",
+	HandlerType: (*TrackerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSnapshots",
+			Handler:    _Tracker_ListSnapshots_Handler,
+		},
+		{
+			MethodName: "GetBestSnapshots",
+			Handler:    _Tracker_GetBestSnapshots_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchBestSnapshots",
+			Handler:       _Tracker_WatchBestSnapshots_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "tracker.proto",
+}
@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -31,6 +32,7 @@ import (
 	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
 	"go.blockdaemon.com/solana/cluster-manager/internal/ledger"
 	"go.blockdaemon.com/solana/cluster-manager/internal/logger"
+	"go.blockdaemon.com/solana/cluster-manager/types"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -45,18 +47,20 @@ var Cmd = cobra.Command{
 }
 
 var (
-	ledgerDir       string
-	trackerURL      string
-	minSnapAge      uint64
-	maxSnapAge      uint64
-	requestTimeout  time.Duration
-	downloadTimeout time.Duration
+	ledgerDir        string
+	trackerURL       string
+	trackerTokenFile string
+	minSnapAge       uint64
+	maxSnapAge       uint64
+	requestTimeout   time.Duration
+	downloadTimeout  time.Duration
 )
 
 func init() {
 	flags := Cmd.Flags()
 	flags.StringVar(&ledgerDir, "ledger", "", "Path to ledger dir")
 	flags.StringVar(&trackerURL, "tracker", "", "Download as instructed by given tracker URL")
+	flags.StringVar(&trackerTokenFile, "tracker-token-file", "", "Path to file containing the bearer token for the tracker API")
 	flags.Uint64Var(&minSnapAge, "min-slots", 500, "Download only snapshots <n> slots newer than local")
 	flags.Uint64Var(&maxSnapAge, "max-slots", 10000, "Refuse to download <n> slots older than the newest")
 	flags.DurationVar(&requestTimeout, "request-timeout", 3*time.Second, "Max time to wait for headers (excluding download)")
@@ -85,11 +89,17 @@ func run() {
 	}
 
 	// Ask tracker for best snapshots.
-	trackerClient := fetch.NewTrackerClientWithResty(
-		resty.New().
-			SetHostURL(trackerURL).
-			SetTimeout(requestTimeout),
-	)
+	trackerResty := resty.New().
+		SetHostURL(trackerURL).
+		SetTimeout(requestTimeout)
+	if trackerTokenFile != "" {
+		buf, err := os.ReadFile(trackerTokenFile)
+		if err != nil {
+			log.Fatal("Failed to read tracker token", zap.Error(err))
+		}
+		trackerResty.SetAuthToken(strings.TrimSpace(string(buf)))
+	}
+	trackerClient := fetch.NewTrackerClientWithResty(trackerResty)
 	remoteSnaps, err := trackerClient.GetBestSnapshots(ctx, -1)
 	if err != nil {
 		log.Fatal("Failed to request snapshot info", zap.Error(err))
@@ -108,8 +118,25 @@ func run() {
 	case fetch.AdviceFetch:
 	}
 
-	// Print snapshot to user.
+	// Ask tracker for the least loaded source of the best snapshot.
+	// Trackers without the assignments API get the first source instead.
 	snap := &remoteSnaps[0]
+	hostname, _ := os.Hostname()
+	assignment, err := trackerClient.Assign(ctx, &types.AssignmentRequest{
+		Slot:   snap.Slot,
+		Hash:   snap.Hash,
+		Client: hostname,
+	})
+	if err != nil {
+		log.Warn("Source assignment unavailable, using first source", zap.Error(err))
+	} else {
+		snap = &assignment.Source
+		log.Info("Tracker assigned snapshot source",
+			zap.String("target", snap.Target),
+			zap.Int("active", assignment.Active))
+	}
+
+	// Print snapshot to user.
 	buf, _ := json.MarshalIndent(snap, "", "\t")
 	log.Info("Downloading a snapshot", zap.ByteString("snap", buf))
 
@@ -130,6 +157,13 @@ func run() {
 		},
 	})
 
+	// Keep the assignment alive while downloading.
+	renewCtx, stopRenew := context.WithCancel(ctx)
+	defer stopRenew()
+	if assignment != nil {
+		go renewAssignment(renewCtx, log, trackerClient, assignment)
+	}
+
 	// Download.
 	beforeDownload := time.Now()
 	group, ctx := errgroup.WithContext(ctx)
@@ -146,6 +180,19 @@ func run() {
 	}
 	downloadErr := group.Wait()
 	downloadDuration := time.Since(beforeDownload)
+	stopRenew()
+
+	if assignment != nil {
+		result := types.AssignmentCompleted
+		if downloadErr != nil {
+			result = types.AssignmentFailed
+		}
+		releaseCtx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		if err := trackerClient.ReleaseAssignment(releaseCtx, assignment.ID, result); err != nil {
+			log.Warn("Failed to release source assignment", zap.Error(err))
+		}
+		cancel()
+	}
 
 	if downloadErr == nil {
 		log.Info("Download completed", zap.Duration("download_time", downloadDuration))
@@ -153,3 +200,24 @@ func run() {
 		log.Info("Aborting download", zap.Duration("download_time", downloadDuration))
 	}
 }
+
+// renewAssignment renews the lease on the snapshot source until ctx is cancelled.
+// Gives up once a renewal fails, as the lease has likely expired already.
+func renewAssignment(ctx context.Context, log *zap.Logger, client *fetch.TrackerClient, assignment *types.Assignment) {
+	for {
+		interval := max(time.Until(assignment.ExpiresAt)/3, time.Second)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		renewed, err := client.RenewAssignment(ctx, assignment.ID)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Warn("Failed to renew source assignment", zap.Error(err))
+			}
+			return
+		}
+		assignment = renewed
+	}
+}
@@ -18,8 +18,11 @@ package mirror
 import (
 	"context"
 	"net/url"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/spf13/cobra"
@@ -40,18 +43,22 @@ var Cmd = cobra.Command{
 }
 
 var (
-	refreshInterval time.Duration
-	trackerURL      string
-	s3URL           string
-	s3Bucket        string
-	objectPrefix    string
-	s3Region        string
+	refreshInterval  time.Duration
+	trackerURL       string
+	trackerTokenFile string
+	s3URL            string
+	s3Bucket         string
+	objectPrefix     string
+	s3Region         string
+	watch            bool
 )
 
 func init() {
 	flags := Cmd.Flags()
 	flags.DurationVar(&refreshInterval, "refresh", 30*time.Second, "Refresh interval to discover new snapshots")
+	flags.BoolVar(&watch, "watch", false, "Follow snapshot changes reported by the tracker instead of polling")
 	flags.StringVar(&trackerURL, "tracker", "http://localhost:8458", "URL to tracker API")
+	flags.StringVar(&trackerTokenFile, "tracker-token-file", "", "Path to file containing the bearer token for the tracker API")
 	flags.StringVar(&s3URL, "s3-url", "", "URL to S3 API")
 	flags.StringVar(&s3Region, "s3-region", "", "S3 region (optional)")
 	flags.StringVar(&s3Bucket, "s3-bucket", "", "Bucket name")
@@ -68,7 +75,16 @@ func run(cmd *cobra.Command) {
 		cobra.CheckErr("required argument missing")
 	}
 
-	trackerClient := fetch.NewTrackerClient(trackerURL)
+	trackerResty := resty.New().SetHostURL(trackerURL)
+	if trackerTokenFile != "" {
+		buf, err := os.ReadFile(trackerTokenFile)
+		if err != nil {
+			log.Fatal("Failed to read tracker token", zap.Error(err))
+		}
+		trackerResty.SetAuthToken(strings.TrimSpace(string(buf)))
+	}
+	trackerClient := fetch.NewTrackerClientWithResty(trackerResty)
+	trackerClient.Log = log.Named("tracker")
 
 	parsedS3URL, err := url.Parse(s3URL)
 	cobra.CheckErr(err)
@@ -95,6 +111,7 @@ func run(cmd *cobra.Command) {
 		Log:       log.Named("uploader"),
 		Refresh:   refreshInterval,
 		SyncCount: 10, // TODO
+		Watch:     watch,
 	}
 	worker.Run(context.TODO())
 }
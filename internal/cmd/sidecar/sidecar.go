@@ -16,11 +16,22 @@
 package sidecar
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	ginzap "github.com/gin-contrib/zap"
 	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
 	"github.com/spf13/cobra"
+	"go.blockdaemon.com/solana/cluster-manager/api"
+	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
 	"go.blockdaemon.com/solana/cluster-manager/internal/logger"
 	"go.blockdaemon.com/solana/cluster-manager/internal/netx"
 	"go.blockdaemon.com/solana/cluster-manager/internal/sidecar"
@@ -38,10 +49,22 @@ var Cmd = cobra.Command{
 }
 
 var (
-	netInterface string
-	listenPort   uint16
-	ledgerDir    string
-	rpcWsUrl     string
+	netInterface   string
+	listenPort     uint16
+	ledgerDir      string
+	rpcWsUrl       string
+	tlsCertFile    string
+	tlsKeyFile     string
+	authFile       string
+	drainTimeout   time.Duration
+	holdDir        string
+	adminTokenFile string
+	holdGrace      time.Duration
+
+	announceURLs      []string
+	announceTarget    string
+	announceTokenFile string
+	announceInterval  time.Duration
 )
 
 func init() {
@@ -50,11 +73,40 @@ func init() {
 	flags.Uint16Var(&listenPort, "port", 13080, "Listen port")
 	flags.StringVar(&ledgerDir, "ledger", "", "Path to ledger dir")
 	flags.StringVar(&rpcWsUrl, "ws", "ws://localhost:8900", "Solana RPC PubSub WebSocket endpoint")
+	flags.StringVar(&tlsCertFile, "tls-cert", "", "Path to TLS certificate (enables HTTPS, reloaded on SIGHUP)")
+	flags.StringVar(&tlsKeyFile, "tls-key", "", "Path to TLS private key")
+	flags.StringVar(&authFile, "auth", "", "Path to YAML file listing accepted client credentials (reloaded on SIGHUP)")
+	flags.StringVar(&adminTokenFile, "admin-token-file", "", "Path to file containing the bearer token for admin endpoints like pins (disabled if empty, reloaded on SIGHUP)")
+	flags.DurationVar(&drainTimeout, "drain-timeout", 10*time.Minute, "Max time to wait for active downloads on shutdown")
+	flags.StringVar(&holdDir, "hold-dir", "", "Dir holding snapshots during downloads, must be on the ledger file system (default <ledger>/.sidecar-hold)")
+	flags.DurationVar(&holdGrace, "hold-grace", 10*time.Minute, "Time to keep holding a snapshot after its last download finished")
+	flags.StringSliceVar(&announceURLs, "announce", nil, "Tracker URLs to push snapshot changes to")
+	flags.StringVar(&announceTarget, "announce-target", "", "Address of this sidecar as discovered by the trackers (host:port)")
+	flags.StringVar(&announceTokenFile, "announce-token-file", "", "Path to file containing the announce token of --announce-target")
+	flags.DurationVar(&announceInterval, "announce-interval", 2*time.Second, "How often to check for snapshot changes to announce")
 	flags.AddFlagSet(logger.Flags)
 }
 
 func run() {
 	log := logger.GetLogger()
+
+	// Install signal handlers.
+	onReload := make(chan os.Signal, 1)
+	signal.Notify(onReload, syscall.SIGHUP)
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	creds := &sidecar.Credentials{
+		CertFile: tlsCertFile,
+		KeyFile:  tlsKeyFile,
+		AuthFile: authFile,
+
+		AdminTokenFile: adminTokenFile,
+	}
+	if err := creds.Reload(); err != nil {
+		log.Fatal("Failed to load credentials", zap.Error(err))
+	}
+
 	listener, listenAddrs, err := netx.ListenTCPInterface("tcp", netInterface, listenPort)
 	if err != nil {
 		cobra.CheckErr(err)
@@ -68,15 +120,110 @@ func run() {
 	httpLog := log.Named("http")
 	server.Use(ginzap.Ginzap(httpLog, time.RFC3339, true))
 	server.Use(ginzap.RecoveryWithZap(httpLog, false))
+	server.Use(creds.Middleware())
 
 	groupV1 := server.Group("/v1")
+	groupV1.GET("/openapi.json", api.Handler(api.Sidecar))
 
 	snapshotHandler := sidecar.NewSnapshotHandler(ledgerDir, httpLog)
+	if holdDir == "" {
+		holdDir = filepath.Join(ledgerDir, ".sidecar-hold")
+	}
+	snapshotHandler.Holds, err = sidecar.NewHoldManager(ledgerDir, holdDir, holdGrace, log.Named("hold"))
+	if err != nil {
+		log.Fatal("Failed to set up snapshot holds", zap.Error(err))
+	}
 	snapshotHandler.RegisterHandlers(groupV1)
+	snapshotHandler.RegisterAdminHandlers(groupV1.Group("", creds.RequireAdmin()))
+
+	if len(announceURLs) > 0 {
+		announcer, err := newAnnouncer(snapshotHandler, log.Named("announce"))
+		if err != nil {
+			log.Fatal("Failed to set up announcements", zap.Error(err))
+		}
+		// Keep announcing while draining, so trackers learn about it right away.
+		announceCtx, stopAnnounce := context.WithCancel(context.Background())
+		defer stopAnnounce()
+		go announcer.Run(announceCtx)
+	}
 
 	consensusHandler := sidecar.NewConsensusHandler(rpcWsUrl, httpLog)
 	consensusHandler.RegisterHandlers(groupV1)
+	go consensusHandler.Hub.Run(ctx)
 
-	err = server.RunListener(listener)
-	log.Error("Server stopped", zap.Error(err))
+	httpServer := &http.Server{
+		Handler:   server,
+		TLSConfig: creds.TLSConfig(),
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		if httpServer.TLSConfig != nil {
+			serverErr <- httpServer.ServeTLS(listener, "", "")
+		} else {
+			serverErr <- httpServer.Serve(listener)
+		}
+	}()
+
+	// Serve until terminated.
+serve:
+	for {
+		select {
+		case err := <-serverErr:
+			log.Error("Server stopped", zap.Error(err))
+			return
+		case <-onReload:
+			if err := creds.Reload(); err != nil {
+				log.Error("Failed to reload credentials", zap.Error(err))
+			} else {
+				log.Info("Reloaded credentials")
+			}
+		case <-ctx.Done():
+			break serve
+		}
+	}
+
+	// Stop accepting new downloads and wait for active ones.
+	log.Info("Draining active downloads",
+		zap.Int("active_downloads", snapshotHandler.Drainer.Active()),
+		zap.Duration("drain_timeout", drainTimeout))
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancelDrain()
+	if err := snapshotHandler.Drainer.Drain(drainCtx); err != nil {
+		log.Warn("Drain timeout exceeded, aborting downloads",
+			zap.Int("active_downloads", snapshotHandler.Drainer.Active()))
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		_ = httpServer.Close()
+	}
+	log.Info("Shut down")
+}
+
+func newAnnouncer(snapshots *sidecar.SnapshotHandler, log *zap.Logger) (*sidecar.Announcer, error) {
+	if announceTarget == "" {
+		return nil, fmt.Errorf("--announce-target is required")
+	}
+	var token string
+	if announceTokenFile != "" {
+		buf, err := os.ReadFile(announceTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(buf))
+	}
+	trackers := make(map[string]*fetch.TrackerClient, len(announceURLs))
+	for _, u := range announceURLs {
+		client := resty.New().
+			SetHostURL(u).
+			SetTimeout(10 * time.Second)
+		if token != "" {
+			client.SetAuthToken(token)
+		}
+		trackers[u] = fetch.NewTrackerClientWithResty(client)
+	}
+	announcer := sidecar.NewAnnouncer(snapshots, announceTarget, trackers, log)
+	announcer.Interval = announceInterval
+	return announcer, nil
 }
@@ -0,0 +1,76 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"context"
+	"time"
+
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.uber.org/zap"
+)
+
+// persister periodically writes the in-memory index to disk.
+type persister struct {
+	store      *index.Store
+	db         *index.DB
+	interval   time.Duration
+	restoreTTL time.Duration
+	log        *zap.Logger
+}
+
+// restore loads the index written by a previous run.
+func (p *persister) restore() {
+	start := time.Now()
+	n, err := p.store.Load(p.db)
+	if err != nil {
+		p.log.Warn("Failed to restore index, starting empty", zap.Error(err))
+		return
+	}
+	p.log.Info("Restored index",
+		zap.Int("num_snapshots", n),
+		zap.Duration("duration", time.Since(start)))
+}
+
+// run saves the index periodically until the context is cancelled.
+// Restored entries that have not been re-scraped within restoreTTL are dropped.
+func (p *persister) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	expire := time.After(p.restoreTTL)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expire:
+			if n := p.db.DeleteUnverifiedSnapshots(); n > 0 {
+				p.log.Info("Dropped restored snapshots that were not confirmed by a scrape",
+					zap.Int("num_snapshots", n))
+			}
+		case <-ticker.C:
+			p.save()
+		}
+	}
+}
+
+// save writes the index to disk.
+func (p *persister) save() {
+	n, err := p.store.Save(p.db)
+	if err != nil {
+		p.log.Error("Failed to save index", zap.Error(err))
+		return
+	}
+	p.log.Debug("Saved index", zap.Int("num_snapshots", n))
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap"
+)
+
+var (
+	configReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "solana_cluster",
+		Subsystem: "tracker",
+		Name:      "config_reloads_total",
+		Help:      "Number of config reload attempts",
+	}, []string{"result"})
+	configLastReloadSuccessful = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "solana_cluster",
+		Subsystem: "tracker",
+		Name:      "config_last_reload_successful",
+		Help:      "Whether the last config reload attempt was successful",
+	})
+	configLastReloadSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "solana_cluster",
+		Subsystem: "tracker",
+		Name:      "config_last_reload_success_timestamp_seconds",
+		Help:      "Timestamp of the last successful config reload",
+	})
+)
+
+// reloader applies the config file to the scrape manager.
+type reloader struct {
+	configPath string
+	manager    *scraper.Manager
+	files      []func() (func(), error) // load additional files, like credentials, returning a function applying them
+	configure  []func(*types.Config)    // applied to a valid config before the scrape manager
+	log        *zap.Logger
+
+	lock sync.Mutex
+}
+
+// reloadResponse is returned by the /reload endpoint.
+type reloadResponse struct {
+	scraper.UpdateResult
+	Error string `json:"error,omitempty"`
+}
+
+// reload re-reads the config and additional files and restarts scrapers of changed groups.
+// Nothing is changed if the config or any file is invalid, or any group fails to load:
+// Everything is loaded and validated first, then all of it is applied together.
+func (r *reloader) reload() (result scraper.UpdateResult, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	defer func() {
+		if err != nil {
+			configReloads.WithLabelValues("failure").Inc()
+			configLastReloadSuccessful.Set(0)
+			r.log.Error("Config reload failed", zap.Error(err))
+			return
+		}
+		configReloads.WithLabelValues("success").Inc()
+		configLastReloadSuccessful.Set(1)
+		configLastReloadSuccess.Set(float64(time.Now().Unix()))
+		r.log.Info("Config reloaded",
+			zap.Strings("added", result.Added),
+			zap.Strings("changed", result.Changed),
+			zap.Strings("removed", result.Removed),
+			zap.Int("unchanged", len(result.Unchanged)))
+	}()
+
+	config, err := types.LoadConfig(r.configPath)
+	if err != nil {
+		return
+	}
+	commits := make([]func(), 0, len(r.files))
+	for _, load := range r.files {
+		var commit func()
+		if commit, err = load(); err != nil {
+			return
+		}
+		commits = append(commits, commit)
+	}
+	// Prepared last, as nothing may fail after the scrapers are created.
+	plan, err := r.manager.Prepare(config)
+	if err != nil {
+		return
+	}
+	for _, commit := range commits {
+		commit()
+	}
+	for _, fn := range r.configure {
+		fn(config)
+	}
+	return r.manager.Apply(plan), nil
+}
+
+// ServeHTTP implements the /reload endpoint.
+func (r *reloader) ServeHTTP(wr http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(wr, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	result, err := r.reload()
+	res := reloadResponse{UpdateResult: result}
+	status := http.StatusOK
+	if err != nil {
+		res.Error = err.Error()
+		status = http.StatusInternalServerError
+	}
+	wr.Header().Set("content-type", "application/json")
+	wr.WriteHeader(status)
+	_ = json.NewEncoder(wr).Encode(&res)
+}
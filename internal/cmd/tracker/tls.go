@@ -0,0 +1,96 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"go.uber.org/atomic"
+)
+
+// serverTLS holds the certificate and client CA of the public listener.
+// Both can be reloaded from disk while the server is running.
+type serverTLS struct {
+	certFile     string // TLS disabled if empty
+	keyFile      string
+	clientCAFile string // client certificates not requested if empty
+
+	cert      atomic.Pointer[tls.Certificate]
+	clientCAs atomic.Pointer[x509.CertPool]
+}
+
+// reload reads all configured files.
+// If any file fails to load, the previous settings remain in effect.
+func (s *serverTLS) reload() error {
+	commit, err := s.load()
+	if err != nil {
+		return err
+	}
+	commit()
+	return nil
+}
+
+// load reads all configured files without applying them.
+// The returned function puts the new settings into effect.
+func (s *serverTLS) load() (commit func(), err error) {
+	if s.certFile == "" {
+		return func() {}, nil
+	}
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS cert and key: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if s.clientCAFile != "" {
+		caBytes, err := os.ReadFile(s.clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caBytes) {
+			return nil, fmt.Errorf("no certificates found in client CA file")
+		}
+	}
+	return func() {
+		s.cert.Store(&cert)
+		s.clientCAs.Store(clientCAs)
+	}, nil
+}
+
+// config returns a server TLS config always using the latest files.
+// Client certificates are verified if presented, but not required,
+// so that clients can authenticate with tokens instead.
+// Returns nil if TLS is disabled.
+func (s *serverTLS) config() *tls.Config {
+	if s.certFile == "" {
+		return nil
+	}
+	return &tls.Config{
+		GetConfigForClient: func(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+			config := &tls.Config{
+				Certificates: []tls.Certificate{*s.cert.Load()},
+				NextProtos:   []string{"h2", "http/1.1"},
+			}
+			if clientCAs := s.clientCAs.Load(); clientCAs != nil {
+				config.ClientCAs = clientCAs
+				config.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+			return config, nil
+		},
+	}
+}
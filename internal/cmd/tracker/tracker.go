@@ -17,10 +17,13 @@ package tracker
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -29,13 +32,20 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
+	"go.blockdaemon.com/solana/cluster-manager/api"
+	"go.blockdaemon.com/solana/cluster-manager/api/trackerpb"
+	"go.blockdaemon.com/solana/cluster-manager/internal/ha"
 	"go.blockdaemon.com/solana/cluster-manager/internal/index"
 	"go.blockdaemon.com/solana/cluster-manager/internal/logger"
+	"go.blockdaemon.com/solana/cluster-manager/internal/notify"
 	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/internal/slotmon"
 	"go.blockdaemon.com/solana/cluster-manager/internal/tracker"
 	"go.blockdaemon.com/solana/cluster-manager/types"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var Cmd = cobra.Command{
@@ -53,6 +63,30 @@ var (
 	configPath     string
 	internalListen string
 	listen         string
+	grpcListen     string
+	slotMonitor    bool
+	maxBest        int
+	assignmentTTL  time.Duration
+
+	authFile        string
+	tlsCertFile     string
+	tlsKeyFile      string
+	tlsClientCAFile string
+	internalTLS     bool
+
+	dashboardListen     string
+	dashboardTrackerURL string
+
+	indexFile         string
+	indexSaveInterval time.Duration
+	indexRestoreTTL   time.Duration
+
+	haSelf           string
+	haMembers        []string
+	haHealthInterval time.Duration
+	haSecretFile     string
+
+	webhookInterval time.Duration
 )
 
 func init() {
@@ -60,6 +94,25 @@ func init() {
 	flags.StringVar(&configPath, "config", "", "Path to config file")
 	flags.StringVar(&internalListen, "internal-listen", ":8457", "Internal listen URL")
 	flags.StringVar(&listen, "listen", ":8458", "Listen URL")
+	flags.StringVar(&grpcListen, "grpc-listen", "", "Listen URL of the gRPC API (disabled if empty, same TLS and auth as --listen)")
+	flags.BoolVar(&slotMonitor, "slot-monitor", true, "Follow slot updates of all sidecars")
+	flags.StringVar(&authFile, "auth", "", "Path to YAML file listing API clients and their roles (reloaded on SIGHUP)")
+	flags.StringVar(&tlsCertFile, "tls-cert", "", "Path to TLS certificate of the public listener (enables HTTPS, reloaded on SIGHUP)")
+	flags.StringVar(&tlsKeyFile, "tls-key", "", "Path to TLS private key")
+	flags.StringVar(&tlsClientCAFile, "tls-client-ca", "", "Path to CA certificates verifying TLS client identities")
+	flags.BoolVar(&internalTLS, "internal-tls", false, "Serve the internal listener with the TLS settings of --listen (accepts client certificates on /reload)")
+	flags.StringVar(&dashboardListen, "dashboard-listen", "", "Separate listen URL for the web dashboard (same TLS as --listen, served at /dashboard/ on the internal listener if empty)")
+	flags.StringVar(&dashboardTrackerURL, "dashboard-tracker-url", "http://localhost:8458", "Tracker URL shown in dashboard fetch commands")
+	flags.IntVar(&maxBest, "max-best-snapshots", 25, "Max number of results returned by best_snapshots")
+	flags.DurationVar(&assignmentTTL, "assignment-ttl", 2*time.Minute, "Expire download source assignments not renewed within this duration")
+	flags.StringVar(&indexFile, "index-file", "", "Path to file persisting the snapshot index across restarts")
+	flags.DurationVar(&indexSaveInterval, "index-save-interval", time.Minute, "How often to write the index to disk")
+	flags.DurationVar(&indexRestoreTTL, "index-restore-ttl", 10*time.Minute, "Drop restored snapshots not confirmed by a scrape after this duration")
+	flags.StringVar(&haSelf, "ha-self", "", "Internal URL of this replica as reachable by other replicas")
+	flags.StringSliceVar(&haMembers, "ha-members", nil, "Internal URLs of all tracker replicas (enables HA mode)")
+	flags.DurationVar(&haHealthInterval, "ha-health-interval", 5*time.Second, "How often to health check other replicas")
+	flags.StringVar(&haSecretFile, "ha-secret-file", "", "Path to file containing the secret shared by all replicas (required in HA mode)")
+	flags.DurationVar(&webhookInterval, "webhook-interval", 15*time.Second, "How often to evaluate webhook rules")
 	flags.AddFlagSet(logger.Flags)
 }
 
@@ -73,14 +126,6 @@ func run() {
 	defer cancel()
 
 	// Install HTTP handlers.
-	http.HandleFunc("/reload", func(wr http.ResponseWriter, req *http.Request) {
-		if req.Method != http.MethodPost {
-			http.Error(wr, "method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		onReload <- syscall.SIGHUP
-		http.Error(wr, "reloaded", http.StatusOK)
-	})
 	httpErrLog, err := zap.NewStdLogAt(log.Named("prometheus"), zap.ErrorLevel)
 	if err != nil {
 		panic(err.Error())
@@ -94,41 +139,215 @@ func run() {
 
 	// Create result collector.
 	db := index.NewDB()
+	var persist *persister
+	if indexFile != "" {
+		store, err := index.OpenStore(indexFile)
+		if err != nil {
+			log.Fatal("Failed to open index file", zap.Error(err))
+		}
+		defer store.Close()
+		persist = &persister{
+			store:      store,
+			db:         db,
+			interval:   indexSaveInterval,
+			restoreTTL: indexRestoreTTL,
+			log:        log.Named("index"),
+		}
+		persist.restore()
+	}
 	collector := scraper.NewCollector(db)
 	collector.Log = log.Named("collector")
 	collector.Start()
 	defer collector.Close()
+	prometheus.MustRegister(collector, db)
+
+	// Load credentials.
+	auth := tracker.NewAuth(authFile, log.Named("audit"))
+	serverTLS := &serverTLS{
+		certFile:     tlsCertFile,
+		keyFile:      tlsKeyFile,
+		clientCAFile: tlsClientCAFile,
+	}
+	if tlsClientCAFile != "" && tlsCertFile == "" {
+		log.Fatal("--tls-client-ca requires --tls-cert")
+	}
+	if internalTLS && tlsCertFile == "" {
+		log.Fatal("--internal-tls requires --tls-cert")
+	}
+	for _, reload := range []func() error{auth.Reload, serverTLS.reload} {
+		if err := reload(); err != nil {
+			log.Fatal("Failed to load credentials", zap.Error(err))
+		}
+	}
 
 	gin.SetMode(gin.ReleaseMode)
 	server := gin.New()
 	httpLog := log.Named("http")
 	server.Use(ginzap.Ginzap(httpLog, time.RFC3339, true))
 	server.Use(ginzap.RecoveryWithZap(httpLog, false))
+	server.Use(tracker.Metrics())
 
+	groupV1 := server.Group("/v1")
+	groupV1.GET("/openapi.json", api.Handler(api.Tracker))
+	readV1 := groupV1.Group("", auth.Require(types.RoleRead))
+	adminV1 := groupV1.Group("", auth.Require(types.RoleAdmin), auth.Audit())
+	cordons := tracker.NewCordons()
 	handler := tracker.NewHandler(db)
-	handler.RegisterHandlers(server.Group("/v1"))
+	handler.Cordons = cordons
+	handler.MaxBestSnapshots = maxBest
+	scorer := tracker.NewWeightedScorer(collector.Targets)
+	handler.Scorer = scorer
+	handler.RegisterHandlers(readV1)
+	tracker.NewCordonHandler(cordons).RegisterHandlers(adminV1)
+	assignHandler := tracker.NewAssignHandler(db, tracker.NewAssignments(assignmentTTL), log.Named("assign"))
+	assignHandler.Cordons = cordons
+	assignHandler.RegisterHandlers(readV1)
+	adviceHandler := tracker.NewAdviceHandler(handler)
+	adviceHandler.RegisterHandlers(readV1)
+	targetsHandler := tracker.NewTargetsHandler(collector)
+	targetsHandler.RegisterHandlers(readV1)
+	http.Handle("/targets", auth.RequireHTTP(types.RoleRead, targetsHandler))
+	dashboard := tracker.NewDashboardHandler(db, collector)
+	dashboard.TrackerURL = dashboardTrackerURL
+	if dashboardListen == "" {
+		http.Handle("/dashboard/", auth.RequireHTTP(types.RoleRead, http.StripPrefix("/dashboard", dashboard)))
+	}
+
+	// Create webhook notifier.
+	notifier := notify.NewNotifier(db, collector.Targets)
+	notifier.Log = log.Named("notify")
+	notifier.Interval = webhookInterval
+
+	// Split scrape targets with other replicas.
+	results := collector.Probes()
+	var observers []scraper.TargetObserver
+	var filter func(group, target string) bool
+	if len(haMembers) > 0 {
+		if haSelf == "" {
+			log.Fatal("--ha-self is required in HA mode")
+		}
+		if haSecretFile == "" {
+			log.Fatal("--ha-secret-file is required in HA mode")
+		}
+		secret, err := os.ReadFile(haSecretFile)
+		if err != nil {
+			log.Fatal("Failed to read HA secret", zap.Error(err))
+		}
+		cluster := ha.NewCluster(haSelf, haMembers)
+		cluster.Secret = strings.TrimSpace(string(secret))
+		if cluster.Secret == "" {
+			log.Fatal("HA secret is empty")
+		}
+		cluster.Log = log.Named("ha")
+		cluster.HealthInterval = haHealthInterval
+		replicator := ha.NewReplicator(cluster, results)
+		replicator.Log = log.Named("ha")
+		replicator.Start()
+		defer replicator.Close()
+		leases := ha.NewLeases(cluster, assignHandler.Assignments)
+		leases.Log = log.Named("ha")
+		assignHandler.Assignments = leases
+		haHandler := ha.NewHandler(cluster, results)
+		haHandler.Leases = leases
+		haHandler.Log = log.Named("ha")
+		haMux := http.NewServeMux()
+		haHandler.RegisterHandlers(haMux)
+		http.Handle("/ha/", auth.RequireHTTP(types.RolePeer, haMux))
+		auth.PeerSecret = cluster.Secret
+		go cluster.Run(ctx)
+
+		results = replicator.Probes()
+		observers = append(observers, replicator)
+		filter = func(group, target string) bool {
+			return cluster.Owns(ha.TargetKey(group, target))
+		}
+		notifier.Filter = cluster.Owns
+		log.Info("Running in HA mode",
+			zap.String("self", cluster.Self),
+			zap.Strings("peers", cluster.Peers()))
+	}
+
+	// Create slot monitor, following only the nodes owned by this replica.
+	if slotMonitor {
+		monitor := slotmon.NewMonitor()
+		monitor.Log = log.Named("slotmon")
+		monitor.Filter = filter
+		defer monitor.Close()
+		prometheus.MustRegister(monitor)
+		observers = append(observers, monitor)
+		scorer.Lag = monitor.Lag
+		tracker.NewClusterHandler(monitor).RegisterHandlers(readV1)
+	}
+
+	// Accept pushed snapshot lists, authenticated per target group.
+	announceHandler := tracker.NewAnnounceHandler(results, collector, log.Named("announce"))
+	announceHandler.RegisterHandlers(groupV1)
 
 	// Start services.
 	group, ctx := errgroup.WithContext(ctx)
+	var internalTLSConfig *tls.Config
+	if internalTLS {
+		internalTLSConfig = serverTLS.config()
+	}
 	if internalListen != "" {
-		httpLog.Info("Starting internal server", zap.String("listen", internalListen))
+		httpLog.Info("Starting internal server", zap.String("listen", internalListen), zap.Bool("tls", internalTLS))
 	}
-	runGroupServer(ctx, group, internalListen, nil) // default handler
-	httpLog.Info("Starting server", zap.String("listen", listen))
-	runGroupServer(ctx, group, listen, server) // public handler
-
-	// Create config reloader.
-	config, err := types.LoadConfig(configPath)
-	if err != nil {
-		log.Fatal("Failed to load config", zap.Error(err))
+	runGroupServer(ctx, group, internalListen, nil, internalTLSConfig) // default handler
+	httpLog.Info("Starting server", zap.String("listen", listen), zap.Bool("tls", tlsCertFile != ""))
+	runGroupServer(ctx, group, listen, server, serverTLS.config()) // public handler
+	if grpcListen != "" {
+		grpcOpts := []grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(auth.UnaryInterceptor(types.RoleRead)),
+			grpc.ChainStreamInterceptor(auth.StreamInterceptor(types.RoleRead)),
+		}
+		if tlsConfig := serverTLS.config(); tlsConfig != nil {
+			grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		grpcServer := grpc.NewServer(grpcOpts...)
+		trackerpb.RegisterTrackerServer(grpcServer, tracker.NewGRPCServer(handler))
+		log.Info("Starting gRPC server", zap.String("listen", grpcListen), zap.Bool("tls", tlsCertFile != ""))
+		runGroupGRPCServer(ctx, group, grpcListen, grpcServer)
+	}
+	if dashboardListen != "" {
+		httpLog.Info("Starting dashboard server", zap.String("listen", dashboardListen), zap.Bool("tls", tlsCertFile != ""))
+		runGroupServer(ctx, group, dashboardListen, auth.RequireHTTP(types.RoleRead, dashboard), serverTLS.config())
 	}
 
 	// Create scrape managers.
-	manager := scraper.NewManager(collector.Probes())
+	manager := scraper.NewManager(results)
 	manager.Log = log.Named("scraper")
-	manager.Update(config)
+	manager.Observers = append(observers, collector)
+	manager.Filter = filter
+	defer manager.Reset()
 
-	// TODO Config reloading
+	// Load config and install reloader.
+	reloader := &reloader{
+		configPath: configPath,
+		manager:    manager,
+		files:      []func() (func(), error){auth.Load, serverTLS.load},
+		configure:  []func(*types.Config){collector.Configure, announceHandler.Configure, dashboard.Configure, scorer.Configure, adviceHandler.Configure, notifier.Configure},
+		log:        log.Named("config"),
+	}
+	if _, err := reloader.reload(); err != nil {
+		log.Fatal("Failed to load config", zap.Error(err))
+	}
+	http.Handle("/reload", auth.RequireHTTP(types.RoleAdmin, reloader))
+	notifier.Start()
+	defer notifier.Close()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-onReload:
+				_, _ = reloader.reload()
+			}
+		}
+	}()
+
+	if persist != nil {
+		go persist.run(ctx)
+	}
 
 	// Wait until crash or graceful exit.
 	if err := group.Wait(); err != nil {
@@ -136,22 +355,46 @@ func run() {
 	} else {
 		log.Info("Shutting down")
 	}
+	if persist != nil {
+		persist.save()
+	}
 }
 
-func runGroupServer(ctx context.Context, group *errgroup.Group, listen string, handler http.Handler) {
+func runGroupServer(ctx context.Context, group *errgroup.Group, listen string, handler http.Handler, tlsConfig *tls.Config) {
 	group.Go(func() error {
 		server := http.Server{
-			Addr:    listen,
-			Handler: handler,
+			Addr:      listen,
+			Handler:   handler,
+			TLSConfig: tlsConfig,
 		}
 		go func() {
 			<-ctx.Done()
 			_ = server.Close()
 		}()
-		if err := server.ListenAndServe(); errors.Is(err, http.ErrServerClosed) {
+		var err error
+		if tlsConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if errors.Is(err, http.ErrServerClosed) {
 			return nil
 		} else {
 			return err
 		}
 	})
 }
+
+func runGroupGRPCServer(ctx context.Context, group *errgroup.Group, listen string, server *grpc.Server) {
+	group.Go(func() error {
+		lis, err := net.Listen("tcp", listen)
+		if err != nil {
+			return err
+		}
+		go func() {
+			<-ctx.Done()
+			server.Stop()
+		}()
+		return server.Serve(lis)
+	})
+}
@@ -42,3 +42,17 @@ func NewFromConfig(t *types.TargetGroup) (Discoverer, error) {
 	}
 	return nil, fmt.Errorf("missing config")
 }
+
+// SourceName returns a short name of the discovery mechanism used by a target group.
+func SourceName(t *types.TargetGroup) string {
+	switch {
+	case t.StaticTargets != nil:
+		return "static"
+	case t.FileTargets != nil:
+		return "file"
+	case t.ConsulSDConfig != nil:
+		return "consul"
+	default:
+		return ""
+	}
+}
@@ -17,6 +17,7 @@ package fetch
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -25,14 +26,16 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/gagliardetto/solana-go/rpc/ws"
 	"github.com/go-resty/resty/v2"
 	"go.blockdaemon.com/solana/cluster-manager/types"
 	"go.uber.org/zap"
 )
 
-// TODO rewrite this package to use OpenAPI code gen
-
 // SidecarClient accesses the sidecar API.
+//
+// The API is specified in api/sidecar.json.
+// Integration tests check the traffic of this client against the spec.
 type SidecarClient struct {
 	resty           *resty.Client
 	log             *zap.Logger
@@ -96,6 +99,9 @@ func (c *SidecarClient) StreamSnapshot(ctx context.Context, name string) (res *h
 	if err != nil {
 		return nil, err
 	}
+	for key, values := range c.resty.Header {
+		req.Header[key] = values
+	}
 	res, err = c.resty.GetClient().Do(req)
 	if err != nil {
 		return
@@ -109,6 +115,46 @@ func (c *SidecarClient) StreamSnapshot(ctx context.Context, name string) (res *h
 	return
 }
 
+// StreamSlotUpdates subscribes to the sidecar's slot update stream
+// and invokes fn for each received event until the stream ends or ctx is cancelled.
+//
+// If lastEventID is non-empty, the sidecar resumes the stream after the given event.
+func (c *SidecarClient) StreamSlotUpdates(
+	ctx context.Context,
+	lastEventID string,
+	fn func(id string, update *ws.SlotsUpdatesResult) error,
+) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.resty.HostURL+"/v1/slot_updates", nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range c.resty.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("last-event-id", lastEventID)
+	}
+	res, err := c.resty.GetClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := expectOK(res, "stream slot updates"); err != nil {
+		return err
+	}
+	return ReadServerSentEvents(res.Body, func(event *ServerSentEvent) error {
+		if event.Event != "slot_update" {
+			return nil
+		}
+		update := new(ws.SlotsUpdatesResult)
+		if err := json.Unmarshal(event.Data, update); err != nil {
+			return fmt.Errorf("invalid slot update: %w", err)
+		}
+		return fn(event.ID, update)
+	})
+}
+
 // DownloadSnapshotFile downloads a snapshot to a file in the local file system.
 func (c *SidecarClient) DownloadSnapshotFile(ctx context.Context, destDir string, name string) error {
 	res, err := c.StreamSnapshot(ctx, name)
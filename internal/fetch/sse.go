@@ -0,0 +1,68 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// ServerSentEvent is a single message of a text/event-stream.
+type ServerSentEvent struct {
+	ID    string
+	Event string
+	Data  []byte
+}
+
+// ReadServerSentEvents parses a text/event-stream and invokes fn for each event.
+// Returns when the stream ends, a read fails, or fn returns an error.
+func ReadServerSentEvents(rd io.Reader, fn func(*ServerSentEvent) error) error {
+	scn := bufio.NewScanner(rd)
+	scn.Buffer(make([]byte, 0, 4096), 1<<20)
+	var event ServerSentEvent
+	var data bytes.Buffer
+	for scn.Scan() {
+		line := scn.Text()
+		if line == "" {
+			// Blank line dispatches the event.
+			if data.Len() > 0 {
+				event.Data = bytes.Clone(bytes.TrimSuffix(data.Bytes(), []byte{'\n'}))
+				if err := fn(&event); err != nil {
+					return err
+				}
+			}
+			event = ServerSentEvent{}
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.ID = value
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		}
+	}
+	return scn.Err()
+}
@@ -0,0 +1,41 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadServerSentEvents(t *testing.T) {
+	const stream = ": comment\n" +
+		"id:1\nevent:slot_update\ndata:{\"slot\":1}\n\n" +
+		"id: 2\nevent: other\ndata: a\ndata: b\n\n" +
+		"\n" +
+		"data:trailing"
+	var events []ServerSentEvent
+	err := ReadServerSentEvents(strings.NewReader(stream), func(event *ServerSentEvent) error {
+		events = append(events, *event)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []ServerSentEvent{
+		{ID: "1", Event: "slot_update", Data: []byte(`{"slot":1}`)},
+		{ID: "2", Event: "other", Data: []byte("a\nb")},
+	}, events)
+}
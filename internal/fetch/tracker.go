@@ -17,17 +17,27 @@ package fetch
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/go-resty/resty/v2"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
 	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap"
 )
 
 // TrackerClient accesses the tracker API.
+//
+// The API is specified in api/tracker.json.
+// Integration tests check the traffic of this client against the spec.
 type TrackerClient struct {
 	resty *resty.Client
+
+	Log *zap.Logger
 }
 
 func NewTrackerClient(trackerURL string) *TrackerClient {
@@ -35,13 +45,26 @@ func NewTrackerClient(trackerURL string) *TrackerClient {
 }
 
 func NewTrackerClientWithResty(client *resty.Client) *TrackerClient {
-	return &TrackerClient{resty: client}
+	return &TrackerClient{
+		resty: client,
+		Log:   zap.NewNop(),
+	}
 }
 
 func (c *TrackerClient) GetBestSnapshots(ctx context.Context, count int) (sources []types.SnapshotSource, err error) {
+	return c.FindBestSnapshots(ctx, count, nil)
+}
+
+// FindBestSnapshots returns the best snapshots matching the query filters.
+// A nil query matches all snapshots.
+func (c *TrackerClient) FindBestSnapshots(ctx context.Context, count int, query *types.SnapshotQuery) (sources []types.SnapshotSource, err error) {
+	if query == nil {
+		query = new(types.SnapshotQuery)
+	}
 	res, err := c.resty.R().
 		SetContext(ctx).
 		SetHeader("accept", "application/json").
+		SetQueryParamsFromValues(query.Values()).
 		SetQueryParam("max", strconv.Itoa(count)).
 		SetResult(&sources).
 		Get("/v1/best_snapshots")
@@ -53,3 +76,203 @@ func (c *TrackerClient) GetBestSnapshots(ctx context.Context, count int) (source
 	}
 	return
 }
+
+// ListSnapshots returns one page of all snapshots matching the query.
+// If more snapshots are available, next holds the cursor to pass in the following query.
+func (c *TrackerClient) ListSnapshots(ctx context.Context, query *types.SnapshotQuery) (entries []*index.SnapshotEntry, next string, err error) {
+	res, err := c.resty.R().
+		SetContext(ctx).
+		SetHeader("accept", "application/json").
+		SetQueryParamsFromValues(query.Values()).
+		SetResult(&entries).
+		Get("/v1/snapshots")
+	if err != nil {
+		return nil, "", err
+	}
+	if res.StatusCode() != http.StatusOK {
+		return nil, "", fmt.Errorf("list snapshots: %s", res.Status())
+	}
+	next = res.Header().Get("X-Next-Cursor")
+	return
+}
+
+// Announce pushes the snapshot list of a sidecar to the tracker.
+// The target is the address under which the tracker discovers the sidecar.
+func (c *TrackerClient) Announce(ctx context.Context, target string, announcement *types.SnapshotAnnouncement) error {
+	res, err := c.resty.R().
+		SetContext(ctx).
+		SetQueryParam("target", target).
+		SetBody(announcement).
+		Post("/v1/announce")
+	if err != nil {
+		return err
+	}
+	if !res.IsSuccess() {
+		return fmt.Errorf("announce: %s", res.Status())
+	}
+	return nil
+}
+
+// Assign leases the least loaded source of the given snapshot.
+//
+// The lease must be renewed before it expires and released once the download is done.
+// Fails on trackers without the assignments API.
+func (c *TrackerClient) Assign(ctx context.Context, req *types.AssignmentRequest) (*types.Assignment, error) {
+	assignment := new(types.Assignment)
+	res, err := c.resty.R().
+		SetContext(ctx).
+		SetHeader("accept", "application/json").
+		SetBody(req).
+		SetResult(assignment).
+		Post("/v1/assignments")
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("assign: %s", res.Status())
+	}
+	return assignment, nil
+}
+
+// RenewAssignment extends a lease.
+func (c *TrackerClient) RenewAssignment(ctx context.Context, id string) (*types.Assignment, error) {
+	assignment := new(types.Assignment)
+	res, err := c.resty.R().
+		SetContext(ctx).
+		SetHeader("accept", "application/json").
+		SetPathParam("id", id).
+		SetResult(assignment).
+		Post("/v1/assignments/{id}/renew")
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("renew assignment: %s", res.Status())
+	}
+	return assignment, nil
+}
+
+// ReleaseAssignment drops a lease, reporting whether the download completed or failed.
+func (c *TrackerClient) ReleaseAssignment(ctx context.Context, id string, result string) error {
+	res, err := c.resty.R().
+		SetContext(ctx).
+		SetPathParam("id", id).
+		SetQueryParam("result", result).
+		Delete("/v1/assignments/{id}")
+	if err != nil {
+		return err
+	}
+	if !res.IsSuccess() {
+		return fmt.Errorf("release assignment: %s", res.Status())
+	}
+	return nil
+}
+
+// Advise asks the tracker whether and what to download, given the local snapshots.
+func (c *TrackerClient) Advise(ctx context.Context, req *types.AdviceRequest) (*types.AdviceResponse, error) {
+	advice := new(types.AdviceResponse)
+	res, err := c.resty.R().
+		SetContext(ctx).
+		SetHeader("accept", "application/json").
+		SetBody(req).
+		SetResult(advice).
+		Post("/v1/advice")
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("advice: %s", res.Status())
+	}
+	return advice, nil
+}
+
+// WatchBestSnapshots follows changes of the best snapshots ranking
+// and invokes fn for each event until ctx is cancelled or fn returns an error.
+//
+// Broken streams are re-established with exponential backoff.
+// The first event after each (re-)connect carries the full current ranking.
+func (c *TrackerClient) WatchBestSnapshots(
+	ctx context.Context,
+	count int,
+	query *types.SnapshotQuery,
+	fn func(*types.BestSnapshotsEvent) error,
+) error {
+	const minBackoff, maxBackoff = time.Second, 30 * time.Second
+	backoff := minBackoff
+	for {
+		var fnErr error
+		received := false
+		err := c.watchBestSnapshots(ctx, count, query, func(event *types.BestSnapshotsEvent) error {
+			received = true
+			fnErr = fn(event)
+			return fnErr
+		})
+		if fnErr != nil {
+			return fnErr
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if received {
+			backoff = minBackoff
+		}
+		c.Log.Warn("Best snapshots stream broken, reconnecting",
+			zap.Error(err), zap.Duration("backoff", backoff))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// watchBestSnapshots consumes a single watch stream.
+func (c *TrackerClient) watchBestSnapshots(
+	ctx context.Context,
+	count int,
+	query *types.SnapshotQuery,
+	fn func(*types.BestSnapshotsEvent) error,
+) error {
+	params := url.Values{}
+	if query != nil {
+		params = query.Values()
+	}
+	params.Set("max", strconv.Itoa(count))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.resty.HostURL+"/v1/best_snapshots/watch?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range c.resty.Header {
+		req.Header[key] = values
+	}
+	if c.resty.Token != "" {
+		scheme := c.resty.AuthScheme
+		if scheme == "" {
+			scheme = "Bearer"
+		}
+		req.Header.Set("authorization", scheme+" "+c.resty.Token)
+	} else if c.resty.UserInfo != nil {
+		req.SetBasicAuth(c.resty.UserInfo.Username, c.resty.UserInfo.Password)
+	}
+	req.Header.Set("accept", "text/event-stream")
+	res, err := c.resty.GetClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := expectOK(res, "watch best snapshots"); err != nil {
+		return err
+	}
+	return ReadServerSentEvents(res.Body, func(sse *ServerSentEvent) error {
+		if sse.Event != "best_snapshots" {
+			return nil
+		}
+		event := new(types.BestSnapshotsEvent)
+		if err := json.Unmarshal(sse.Data, event); err != nil {
+			return fmt.Errorf("invalid best snapshots event: %w", err)
+		}
+		return fn(event)
+	})
+}
@@ -0,0 +1,238 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ha splits scrape work across a group of tracker replicas.
+//
+// Each replica scrapes only the targets it owns on a consistent hash ring
+// and streams the results to all other replicas,
+// such that every replica serves the full index.
+package ha
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+// Cluster tracks the health of tracker replicas and the ownership of targets.
+//
+// Membership is static: all replicas are configured with the same member list.
+// Replicas that fail health checks are taken out of the ring until they recover.
+type Cluster struct {
+	Self           string
+	Secret         string // shared by all replicas, authenticates replica-to-replica requests
+	Log            *zap.Logger
+	HealthInterval time.Duration
+
+	client *resty.Client
+	ring   atomic.Pointer[Ring]
+
+	lock   sync.Mutex
+	peers  map[string]*peerState
+	onJoin []func(member string)
+}
+
+type peerState struct {
+	healthy   bool
+	lastCheck time.Time
+	lastErr   error
+}
+
+// MemberStatus describes a replica as seen by the local replica.
+type MemberStatus struct {
+	URL       string    `json:"url"`
+	Self      bool      `json:"self,omitempty"`
+	Healthy   bool      `json:"healthy"`
+	LastCheck time.Time `json:"last_check"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// NewCluster creates a new cluster view.
+//
+// self is the URL under which other replicas reach this one.
+// members lists the URLs of all replicas and may contain self.
+// Peers are considered down until their first successful health check.
+func NewCluster(self string, members []string) *Cluster {
+	self = normalizeURL(self)
+	c := &Cluster{
+		Self:           self,
+		Log:            zap.NewNop(),
+		HealthInterval: 5 * time.Second,
+		client:         resty.New().SetTimeout(2 * time.Second),
+		peers:          make(map[string]*peerState),
+	}
+	for _, member := range members {
+		member = normalizeURL(member)
+		if member != "" && member != self {
+			c.peers[member] = new(peerState)
+		}
+	}
+	c.rebuild()
+	return c
+}
+
+func normalizeURL(u string) string {
+	return strings.TrimRight(strings.TrimSpace(u), "/")
+}
+
+// OnJoin registers a callback invoked when a peer becomes healthy.
+// Must be called before Run.
+func (c *Cluster) OnJoin(fn func(member string)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.onJoin = append(c.onJoin, fn)
+}
+
+// Run health checks peers until the context is cancelled.
+func (c *Cluster) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.HealthInterval)
+	defer ticker.Stop()
+	for {
+		c.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Cluster) checkAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, peer := range c.Peers() {
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+			c.setHealth(peer, c.check(ctx, peer))
+		}(peer)
+	}
+	wg.Wait()
+}
+
+func (c *Cluster) check(ctx context.Context, peer string) error {
+	res, err := c.client.R().
+		SetContext(ctx).
+		SetAuthToken(c.Secret).
+		Get(peer + "/ha/health")
+	if err != nil {
+		return err
+	}
+	if !res.IsSuccess() {
+		return fmt.Errorf("health check returned %s", res.Status())
+	}
+	return nil
+}
+
+func (c *Cluster) setHealth(peer string, err error) {
+	c.lock.Lock()
+	state := c.peers[peer]
+	wasHealthy := state.healthy
+	state.healthy = err == nil
+	state.lastCheck = time.Now()
+	state.lastErr = err
+	changed := wasHealthy != state.healthy
+	if changed {
+		c.rebuild()
+	}
+	onJoin := c.onJoin
+	c.lock.Unlock()
+
+	if !changed {
+		return
+	}
+	if err != nil {
+		c.Log.Warn("Peer down, rebalancing", zap.String("peer", peer), zap.Error(err))
+		return
+	}
+	c.Log.Info("Peer up, rebalancing", zap.String("peer", peer))
+	for _, fn := range onJoin {
+		fn(peer)
+	}
+}
+
+// rebuild recreates the ring from the healthy members. Requires lock.
+func (c *Cluster) rebuild() {
+	members := []string{c.Self}
+	for peer, state := range c.peers {
+		if state.healthy {
+			members = append(members, peer)
+		}
+	}
+	c.ring.Store(NewRing(members))
+}
+
+// Owner returns the replica responsible for the given key.
+func (c *Cluster) Owner(key string) string {
+	return c.ring.Load().Owner(key)
+}
+
+// Owns returns whether this replica is responsible for the given key.
+func (c *Cluster) Owns(key string) bool {
+	return c.Owner(key) == c.Self
+}
+
+// Peers returns all configured peers excluding self.
+func (c *Cluster) Peers() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	peers := make([]string, 0, len(c.peers))
+	for peer := range c.peers {
+		peers = append(peers, peer)
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// IsPeer returns whether the URL belongs to a configured peer.
+func (c *Cluster) IsPeer(member string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.peers[normalizeURL(member)]
+	return ok
+}
+
+// Healthy returns whether a peer passed its last health check.
+func (c *Cluster) Healthy(peer string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	state, ok := c.peers[peer]
+	return ok && state.healthy
+}
+
+// Members returns the status of all replicas, including self.
+func (c *Cluster) Members() []MemberStatus {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	members := []MemberStatus{{URL: c.Self, Self: true, Healthy: true}}
+	for peer, state := range c.peers {
+		status := MemberStatus{
+			URL:       peer,
+			Healthy:   state.healthy,
+			LastCheck: state.lastCheck,
+		}
+		if state.lastErr != nil {
+			status.Error = state.lastErr.Error()
+		}
+		members = append(members, status)
+	}
+	sort.Slice(members[1:], func(i, j int) bool { return members[1+i].URL < members[1+j].URL })
+	return members
+}
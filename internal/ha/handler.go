@@ -0,0 +1,145 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ha
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.uber.org/zap"
+)
+
+// headerMember identifies the replica sending results.
+const headerMember = "X-Tracker-Member"
+
+// Handler serves the replica-to-replica API.
+//
+// All requests must carry the cluster secret as bearer token.
+// Results are only accepted from configured peers, and only for targets the sending peer owns,
+// except for announcements, which may arrive at any replica.
+// Lease calls forwarded by peers are applied to the local lease table.
+type Handler struct {
+	Cluster *Cluster
+	Results chan<- scraper.ProbeResult // collector input
+	Leases  *Leases                    // serves lease calls forwarded by peers, optional
+	Log     *zap.Logger
+}
+
+// NewHandler creates a new replication API feeding received results into the given channel.
+func NewHandler(cluster *Cluster, results chan<- scraper.ProbeResult) *Handler {
+	return &Handler{
+		Cluster: cluster,
+		Results: results,
+		Log:     zap.NewNop(),
+	}
+}
+
+// RegisterHandlers registers this API with the given mux.
+func (h *Handler) RegisterHandlers(mux *http.ServeMux) {
+	mux.Handle("/ha/health", h.authenticate(h.health))
+	mux.Handle("/ha/members", h.authenticate(h.members))
+	mux.Handle("/ha/results", h.authenticate(h.results))
+	mux.Handle("/ha/leases", h.authenticate(h.leases))
+}
+
+// authenticate rejects requests lacking the cluster secret.
+func (h *Handler) authenticate(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+		token, _ := strings.CutPrefix(req.Header.Get("authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.Cluster.Secret)) != 1 {
+			h.Log.Warn("Rejected request without cluster secret",
+				zap.String("path", req.URL.Path),
+				zap.String("remote_addr", req.RemoteAddr))
+			http.Error(wr, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next(wr, req)
+	})
+}
+
+func (h *Handler) health(wr http.ResponseWriter, _ *http.Request) {
+	writeJSON(wr, http.StatusOK, map[string]string{"member": h.Cluster.Self})
+}
+
+func (h *Handler) members(wr http.ResponseWriter, _ *http.Request) {
+	writeJSON(wr, http.StatusOK, h.Cluster.Members())
+}
+
+func (h *Handler) results(wr http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(wr, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	peer := normalizeURL(req.Header.Get(headerMember))
+	if !h.Cluster.IsPeer(peer) {
+		h.Log.Warn("Rejected results from non-member",
+			zap.String("peer", peer),
+			zap.String("remote_addr", req.RemoteAddr))
+		http.Error(wr, "not a member", http.StatusForbidden)
+		return
+	}
+	var batch []wireResult
+	if err := json.NewDecoder(req.Body).Decode(&batch); err != nil {
+		http.Error(wr, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.Log.Debug("Received results",
+		zap.String("peer", peer),
+		zap.Int("num_results", len(batch)))
+	for i := range batch {
+		if !batch[i].Announced && h.Cluster.Owner(TargetKey(batch[i].Group, batch[i].Target)) != peer {
+			h.Log.Debug("Dropping result of target not owned by peer",
+				zap.String("peer", peer),
+				zap.String("group", batch[i].Group),
+				zap.String("target", batch[i].Target))
+			continue
+		}
+		select {
+		case h.Results <- batch[i].probeResult():
+		case <-req.Context().Done():
+			return
+		}
+	}
+	wr.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) leases(wr http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(wr, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Leases == nil {
+		http.Error(wr, "leases not served", http.StatusServiceUnavailable)
+		return
+	}
+	peer := normalizeURL(req.Header.Get(headerMember))
+	if !h.Cluster.IsPeer(peer) {
+		h.Log.Warn("Rejected lease call from non-member",
+			zap.String("peer", peer),
+			zap.String("remote_addr", req.RemoteAddr))
+		http.Error(wr, "not a member", http.StatusForbidden)
+		return
+	}
+	h.Leases.serve(wr, req)
+}
+
+func writeJSON(wr http.ResponseWriter, status int, v any) {
+	wr.Header().Set("content-type", "application/json")
+	wr.WriteHeader(status)
+	_ = json.NewEncoder(wr).Encode(v)
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ha
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.blockdaemon.com/solana/cluster-manager/internal/tracker"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap"
+)
+
+// leasesKey is the ring key of the replica holding the lease table.
+const leasesKey = "assignments"
+
+const (
+	leaseAcquire = "acquire"
+	leaseRenew   = "renew"
+	leaseRelease = "release"
+)
+
+// leaseRequest is the JSON encoding of a lease call forwarded between replicas.
+type leaseRequest struct {
+	Op      string                 `json:"op"`
+	ID      string                 `json:"id,omitempty"`
+	Result  string                 `json:"result,omitempty"`
+	Sources []types.SnapshotSource `json:"sources,omitempty"`
+}
+
+// Leases routes download leases to a single replica.
+//
+// All lease calls are forwarded to the owner of one ring key,
+// so leases can be renewed and released through any replica,
+// and sources are balanced by the leases of the whole cluster.
+// If the owner cannot be reached, the local table is used until the ring is rebuilt.
+// Leases held by a replica that goes down are lost; fetchers then get a new source.
+type Leases struct {
+	Cluster *Cluster
+	Local   tracker.Leases
+	Log     *zap.Logger
+}
+
+// NewLeases creates a lease router on top of the local lease table.
+func NewLeases(cluster *Cluster, local tracker.Leases) *Leases {
+	return &Leases{
+		Cluster: cluster,
+		Local:   local,
+		Log:     zap.NewNop(),
+	}
+}
+
+// Acquire implements tracker.Leases.
+func (l *Leases) Acquire(sources []types.SnapshotSource, now time.Time) (types.Assignment, bool) {
+	return l.route(&leaseRequest{Op: leaseAcquire, Sources: sources}, now)
+}
+
+// Renew implements tracker.Leases.
+func (l *Leases) Renew(id string, now time.Time) (types.Assignment, bool) {
+	return l.route(&leaseRequest{Op: leaseRenew, ID: id}, now)
+}
+
+// Release implements tracker.Leases.
+func (l *Leases) Release(id string, result string, now time.Time) (types.Assignment, bool) {
+	return l.route(&leaseRequest{Op: leaseRelease, ID: id, Result: result}, now)
+}
+
+func (l *Leases) route(req *leaseRequest, now time.Time) (types.Assignment, bool) {
+	owner := l.Cluster.Owner(leasesKey)
+	if owner == l.Cluster.Self {
+		return l.local(req, now)
+	}
+	assignment, ok, err := l.forward(owner, req)
+	if err != nil {
+		l.Log.Warn("Failed to forward lease call, using local table",
+			zap.String("owner", owner),
+			zap.String("op", req.Op),
+			zap.Error(err))
+		return l.local(req, now)
+	}
+	return assignment, ok
+}
+
+func (l *Leases) local(req *leaseRequest, now time.Time) (types.Assignment, bool) {
+	switch req.Op {
+	case leaseAcquire:
+		return l.Local.Acquire(req.Sources, now)
+	case leaseRenew:
+		return l.Local.Renew(req.ID, now)
+	case leaseRelease:
+		return l.Local.Release(req.ID, req.Result, now)
+	default:
+		return types.Assignment{}, false
+	}
+}
+
+func (l *Leases) forward(owner string, req *leaseRequest) (types.Assignment, bool, error) {
+	var assignment types.Assignment
+	res, err := l.Cluster.client.R().
+		SetHeader(headerMember, l.Cluster.Self).
+		SetAuthToken(l.Cluster.Secret).
+		SetBody(req).
+		SetResult(&assignment).
+		Post(owner + "/ha/leases")
+	if err != nil {
+		return types.Assignment{}, false, err
+	}
+	switch {
+	case res.StatusCode() == http.StatusNotFound:
+		return types.Assignment{}, false, nil
+	case !res.IsSuccess():
+		return types.Assignment{}, false, fmt.Errorf("owner returned %s", res.Status())
+	}
+	return assignment, true, nil
+}
+
+// serve applies a lease call forwarded by a peer to the local table.
+func (l *Leases) serve(wr http.ResponseWriter, req *http.Request) {
+	var call leaseRequest
+	if err := json.NewDecoder(req.Body).Decode(&call); err != nil {
+		http.Error(wr, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch call.Op {
+	case leaseAcquire, leaseRenew, leaseRelease:
+	default:
+		http.Error(wr, "invalid op: "+call.Op, http.StatusBadRequest)
+		return
+	}
+	assignment, ok := l.local(&call, time.Now())
+	if !ok {
+		http.Error(wr, "assignment not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(wr, http.StatusOK, &assignment)
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ha
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+func TestLeases(t *testing.T) {
+	a, b := newReplica(t), newReplica(t)
+	members := []string{a.server.URL, b.server.URL}
+	a.start(t, members)
+	b.start(t, members)
+	ctx := context.TODO()
+	b.cluster.checkAll(ctx)
+	a.cluster.checkAll(ctx)
+
+	owner, other := a, b
+	if !a.cluster.Owns(leasesKey) {
+		owner, other = b, a
+	}
+	require.Equal(t, owner.cluster.Self, other.cluster.Owner(leasesKey))
+
+	// Leases granted through one replica are renewed and released through the other.
+	now := time.Now()
+	sources := []types.SnapshotSource{{Target: "host1"}, {Target: "host2"}}
+	first, ok := other.leases.Acquire(sources, now)
+	require.True(t, ok)
+	assert.Equal(t, "host1", first.Source.Target)
+	second, ok := owner.leases.Acquire(sources, now)
+	require.True(t, ok)
+	assert.Equal(t, "host2", second.Source.Target, "balanced across replicas")
+	assert.Equal(t, map[string]int{"host1": 1, "host2": 1}, owner.local.Active(now))
+	assert.Empty(t, other.local.Active(now))
+
+	renewed, ok := owner.leases.Renew(first.ID, now.Add(time.Second))
+	require.True(t, ok)
+	assert.Equal(t, first.ID, renewed.ID)
+	_, ok = other.leases.Release(second.ID, types.AssignmentCompleted, now)
+	assert.True(t, ok)
+	_, ok = other.leases.Renew(second.ID, now)
+	assert.False(t, ok, "released lease is gone")
+	assert.Equal(t, map[string]int{"host1": 1}, owner.local.Active(now))
+
+	// Without the owner, the remaining replica falls back to its local table.
+	owner.server.Close()
+	other.cluster.checkAll(ctx)
+	assert.True(t, other.cluster.Owns(leasesKey))
+	_, ok = other.leases.Renew(first.ID, now)
+	assert.False(t, ok)
+	_, ok = other.leases.Acquire(sources, now)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]int{"host1": 1}, other.local.Active(now))
+}
@@ -0,0 +1,281 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap"
+)
+
+// Replicator sits between the scrapers and the collector.
+//
+// It passes local probe results through to the collector
+// and streams them to all healthy peers.
+// When a peer (re-)joins, it gets the latest result of each target owned by this replica.
+type Replicator struct {
+	Cluster   *Cluster
+	Log       *zap.Logger
+	BatchSize int // max results per request
+	QueueSize int // max results queued per peer before dropping
+
+	in     chan scraper.ProbeResult
+	out    chan<- scraper.ProbeResult
+	client *resty.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	lock    sync.Mutex
+	last    map[string]scraper.ProbeResult // group/target => latest local result
+	senders map[string]chan wireResult     // peer => queue
+}
+
+// wireResult is the JSON encoding of a probe result exchanged between replicas.
+type wireResult struct {
+	Time     time.Time             `json:"time"`
+	Duration time.Duration         `json:"duration"`
+	Group    string                `json:"group"`
+	Source   string                `json:"source,omitempty"`
+	Target   string                `json:"target"`
+	Infos    []*types.SnapshotInfo `json:"infos,omitempty"`
+	Error    string                `json:"error,omitempty"`
+
+	Federated bool                   `json:"federated,omitempty"`
+	Entries   []*index.SnapshotEntry `json:"entries,omitempty"`
+	Announced bool                   `json:"announced,omitempty"`
+}
+
+func toWire(res scraper.ProbeResult) wireResult {
+	w := wireResult{
+		Time:     res.Time,
+		Duration: res.Duration,
+		Group:    res.Group,
+		Source:   res.Source,
+		Target:   res.Target,
+		Infos:    res.Infos,
+
+		Federated: res.Federated,
+		Entries:   res.Entries,
+		Announced: res.Announced,
+	}
+	if res.Err != nil {
+		w.Error = res.Err.Error()
+	}
+	return w
+}
+
+func (w *wireResult) probeResult() scraper.ProbeResult {
+	res := scraper.ProbeResult{
+		Time:     w.Time,
+		Duration: w.Duration,
+		Group:    w.Group,
+		Source:   w.Source,
+		Target:   w.Target,
+		Infos:    w.Infos,
+
+		Federated: w.Federated,
+		Entries:   w.Entries,
+		Announced: w.Announced,
+	}
+	if w.Error != "" {
+		res.Err = errors.New(w.Error)
+	}
+	return res
+}
+
+// TargetKey returns the ring key of a scrape target.
+func TargetKey(group, target string) string {
+	return group + "/" + target
+}
+
+// NewReplicator creates a replicator forwarding results to out.
+func NewReplicator(cluster *Cluster, out chan<- scraper.ProbeResult) *Replicator {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Replicator{
+		Cluster:   cluster,
+		Log:       zap.NewNop(),
+		BatchSize: 256,
+		QueueSize: 4096,
+		in:        make(chan scraper.ProbeResult),
+		out:       out,
+		client:    resty.New().SetTimeout(10 * time.Second),
+		ctx:       ctx,
+		cancel:    cancel,
+		last:      make(map[string]scraper.ProbeResult),
+		senders:   make(map[string]chan wireResult),
+	}
+	cluster.OnJoin(r.resync)
+	return r
+}
+
+// Probes returns the channel accepting local probe results.
+func (r *Replicator) Probes() chan<- scraper.ProbeResult {
+	return r.in
+}
+
+// Start launches the forwarding and sender goroutines.
+func (r *Replicator) Start() {
+	for _, peer := range r.Cluster.Peers() {
+		queue := make(chan wireResult, r.QueueSize)
+		r.senders[peer] = queue
+		r.wg.Add(1)
+		go r.send(peer, queue)
+	}
+	r.wg.Add(1)
+	go r.run()
+}
+
+// Close stops all goroutines. Must be called after all scrapers stopped.
+func (r *Replicator) Close() {
+	close(r.in)
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *Replicator) run() {
+	defer r.wg.Done()
+	for res := range r.in {
+		r.out <- res
+		key := TargetKey(res.Group, res.Target)
+		r.lock.Lock()
+		r.last[key] = res
+		r.lock.Unlock()
+		r.broadcast(toWire(res))
+	}
+}
+
+func (r *Replicator) broadcast(res wireResult) {
+	for peer, queue := range r.senders {
+		if !r.Cluster.Healthy(peer) {
+			continue // will get resync when it comes back
+		}
+		r.enqueue(peer, queue, res)
+	}
+}
+
+func (r *Replicator) enqueue(peer string, queue chan<- wireResult, res wireResult) {
+	select {
+	case queue <- res:
+	default:
+		r.Log.Warn("Replication queue full, dropping result",
+			zap.String("peer", peer), zap.String("target", res.Target))
+	}
+}
+
+// resync sends the latest results of all targets owned by this replica to a peer.
+func (r *Replicator) resync(peer string) {
+	queue, ok := r.senders[peer]
+	if !ok {
+		return
+	}
+	r.lock.Lock()
+	results := make([]wireResult, 0, len(r.last))
+	for key, res := range r.last {
+		if r.Cluster.Owns(key) {
+			results = append(results, toWire(res))
+		}
+	}
+	r.lock.Unlock()
+	r.Log.Info("Resyncing peer", zap.String("peer", peer), zap.Int("num_targets", len(results)))
+	for _, res := range results {
+		r.enqueue(peer, queue, res)
+	}
+}
+
+// send pushes queued results to a peer in batches.
+func (r *Replicator) send(peer string, queue <-chan wireResult) {
+	defer r.wg.Done()
+	log := r.Log.With(zap.String("peer", peer))
+	batch := make([]wireResult, 0, r.BatchSize)
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case res := <-queue:
+			batch = append(batch[:0], res)
+		}
+	drain:
+		for len(batch) < r.BatchSize {
+			select {
+			case res := <-queue:
+				batch = append(batch, res)
+			default:
+				break drain
+			}
+		}
+		if err := r.push(peer, batch); err != nil {
+			log.Warn("Failed to replicate results",
+				zap.Int("num_results", len(batch)), zap.Error(err))
+		}
+	}
+}
+
+func (r *Replicator) push(peer string, batch []wireResult) error {
+	res, err := r.client.R().
+		SetContext(r.ctx).
+		SetHeader(headerMember, r.Cluster.Self).
+		SetAuthToken(r.Cluster.Secret).
+		SetBody(batch).
+		Post(peer + "/ha/results")
+	if err != nil {
+		return err
+	}
+	if !res.IsSuccess() {
+		return fmt.Errorf("peer returned %s", res.Status())
+	}
+	return nil
+}
+
+// ObserveTargets implements scraper.TargetObserver.
+//
+// Forgets the latest results of targets that are no longer discovered.
+func (r *Replicator) ObserveTargets(group string, _ *scraper.Prober, targets []string) {
+	keep := make(map[string]struct{}, len(targets))
+	for _, target := range targets {
+		keep[TargetKey(group, target)] = struct{}{}
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for key, res := range r.last {
+		if _, ok := keep[key]; !ok && res.Group == group {
+			delete(r.last, key)
+		}
+	}
+}
+
+// ResetGroup implements scraper.TargetObserver.
+//
+// Results of targets that are no longer discovered are forgotten by ObserveTargets.
+func (r *Replicator) ResetGroup(string) {}
+
+// RemoveGroup implements scraper.TargetObserver.
+func (r *Replicator) RemoveGroup(group string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for key, res := range r.last {
+		if res.Group == group {
+			delete(r.last, key)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ha
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/internal/slotmon"
+	"go.blockdaemon.com/solana/cluster-manager/internal/tracker"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/atomic"
+	"go.uber.org/zap/zaptest"
+)
+
+type replica struct {
+	server     *httptest.Server
+	mux        *http.ServeMux
+	cluster    *Cluster
+	replicator *Replicator
+	collected  chan scraper.ProbeResult
+	local      *tracker.Assignments
+	leases     *Leases
+}
+
+func newReplica(t *testing.T) *replica {
+	r := &replica{
+		mux:       http.NewServeMux(),
+		collected: make(chan scraper.ProbeResult, 16),
+	}
+	r.server = httptest.NewServer(r.mux)
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *replica) start(t *testing.T, members []string) {
+	r.cluster = NewCluster(r.server.URL, members)
+	r.cluster.Secret = "secret"
+	r.cluster.Log = zaptest.NewLogger(t)
+	r.replicator = NewReplicator(r.cluster, r.collected)
+	r.replicator.Log = zaptest.NewLogger(t)
+	r.replicator.Start()
+	t.Cleanup(r.replicator.Close)
+	r.local = tracker.NewAssignments(time.Minute)
+	r.leases = NewLeases(r.cluster, r.local)
+	r.leases.Log = zaptest.NewLogger(t)
+	handler := NewHandler(r.cluster, r.collected)
+	handler.Leases = r.leases
+	handler.RegisterHandlers(r.mux)
+}
+
+func TestReplicator(t *testing.T) {
+	a, b := newReplica(t), newReplica(t)
+	members := []string{a.server.URL, b.server.URL + "/"}
+	a.start(t, members)
+	b.start(t, members)
+
+	assert.Equal(t, []string{b.server.URL}, a.cluster.Peers())
+	assert.False(t, a.cluster.Healthy(b.server.URL))
+	assert.True(t, a.cluster.Owns("group/foo"), "owns everything while alone")
+
+	// Results produced before the peer is up get resynced on join.
+	first := scraper.ProbeResult{
+		Time:   time.Date(2022, 4, 27, 15, 33, 20, 0, time.UTC),
+		Group:  "group",
+		Target: "key0",
+		Infos:  []*types.SnapshotInfo{{Slot: 100}},
+	}
+	a.replicator.Probes() <- first
+	assert.Equal(t, first, <-a.collected)
+
+	ctx := context.Background()
+	// The receiving replica must see the sender as healthy to accept its results.
+	b.cluster.checkAll(ctx)
+	a.cluster.checkAll(ctx)
+	assert.True(t, a.cluster.Healthy(b.server.URL))
+	status := a.cluster.Members()
+	assert.Len(t, status, 2)
+	assert.Equal(t, MemberStatus{URL: a.server.URL, Self: true, Healthy: true}, status[0])
+	assert.True(t, status[1].Healthy)
+
+	if a.cluster.Owns("group/key0") {
+		assert.Equal(t, first, <-b.collected)
+	}
+
+	// Regular results of owned targets are streamed.
+	owned := "key1"
+	for i := 2; !a.cluster.Owns(TargetKey("group", owned)); i++ {
+		owned = fmt.Sprintf("key%d", i)
+	}
+	second := scraper.ProbeResult{
+		Time:   first.Time.Add(time.Second),
+		Group:  "group",
+		Target: owned,
+		Err:    errors.New("connection refused"),
+	}
+	a.replicator.Probes() <- second
+	assert.Equal(t, second, <-a.collected)
+	assert.Equal(t, second, <-b.collected)
+
+	// Ownership is consistent across replicas.
+	for _, key := range []string{"group/key0", "group/key1", "group/key2", "group/key3"} {
+		assert.Equal(t, a.cluster.Owner(key), b.cluster.Owner(key))
+		assert.NotEqual(t, a.cluster.Owns(key), b.cluster.Owns(key))
+	}
+
+	// Losing a replica rebalances its targets.
+	b.server.Close()
+	a.cluster.checkAll(ctx)
+	assert.False(t, a.cluster.Healthy(b.server.URL))
+	for _, key := range []string{"group/key0", "group/key1", "group/key2", "group/key3"} {
+		assert.True(t, a.cluster.Owns(key))
+	}
+}
+
+func TestHandler_Results(t *testing.T) {
+	a := newReplica(t)
+	const peer = "http://peer.example.org:8457"
+	a.start(t, []string{a.server.URL, peer})
+	a.cluster.setHealth(peer, nil)
+
+	post := func(token, member string, results ...wireResult) int {
+		body, err := json.Marshal(results)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, a.server.URL+"/ha/results", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("authorization", "Bearer "+token)
+		req.Header.Set(headerMember, member)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	// Find a target owned by each replica.
+	var ownedByPeer, ownedBySelf string
+	for i := 0; ownedByPeer == "" || ownedBySelf == ""; i++ {
+		target := fmt.Sprintf("key%d", i)
+		if a.cluster.Owns(TargetKey("group", target)) {
+			ownedBySelf = target
+		} else {
+			ownedByPeer = target
+		}
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post("wrong", peer))
+	assert.Equal(t, http.StatusForbidden, post("secret", "http://intruder.example.org"))
+	assert.Equal(t, http.StatusNoContent, post("secret", peer,
+		wireResult{Group: "group", Target: ownedBySelf},
+		wireResult{Group: "group", Target: ownedBySelf, Announced: true},
+		wireResult{Group: "group", Target: ownedByPeer},
+	))
+	assert.Equal(t, scraper.ProbeResult{Group: "group", Target: ownedBySelf, Announced: true}, <-a.collected,
+		"announcements are accepted from any member")
+	assert.Equal(t, scraper.ProbeResult{Group: "group", Target: ownedByPeer}, <-a.collected)
+	assert.Empty(t, a.collected, "results of targets not owned by the sender are dropped")
+}
+
+func TestSlotMonitorOwnership(t *testing.T) {
+	a, b := newReplica(t), newReplica(t)
+	members := []string{a.server.URL, b.server.URL}
+	a.start(t, members)
+	b.start(t, members)
+	ctx := context.Background()
+	a.cluster.checkAll(ctx)
+	b.cluster.checkAll(ctx)
+
+	// Fake sidecars count the slot update streams opened to them.
+	var targets []string
+	streams := make(map[string]*atomic.Int32)
+	for i := 0; i < 8; i++ {
+		var n atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n.Inc()
+			w.Header().Set("content-type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		t.Cleanup(server.Close)
+		target := strings.TrimPrefix(server.URL, "http://")
+		targets = append(targets, target)
+		streams[target] = &n
+	}
+	prober, err := scraper.NewProber(&types.TargetGroup{Scheme: "http"})
+	require.NoError(t, err)
+
+	followed := make(map[string]string)
+	for _, r := range []*replica{a, b} {
+		monitor := slotmon.NewMonitor()
+		monitor.Log = zaptest.NewLogger(t)
+		monitor.Filter = func(group, target string) bool {
+			return r.cluster.Owns(TargetKey(group, target))
+		}
+		defer monitor.Close()
+		monitor.ObserveTargets("group", prober, targets)
+		for _, group := range monitor.Status().Groups {
+			for _, n := range group.Nodes {
+				assert.NotContains(t, followed, n.Target, "followed by both replicas")
+				followed[n.Target] = r.server.URL
+			}
+		}
+	}
+	assert.Len(t, followed, len(targets), "every node is followed by one replica")
+
+	// Each sidecar serves a single stream.
+	require.Eventually(t, func() bool {
+		for _, n := range streams {
+			if n.Load() == 0 {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	for target, n := range streams {
+		assert.Equal(t, int32(1), n.Load(), target)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ha
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// vnodes is the number of points each member occupies on the ring.
+const vnodes = 128
+
+// Ring assigns keys to members using consistent hashing.
+//
+// Adding or removing a member only moves the keys owned by that member.
+type Ring struct {
+	points  []uint64
+	members map[uint64]string
+}
+
+// NewRing creates a ring over the given member names.
+func NewRing(members []string) *Ring {
+	r := &Ring{
+		points:  make([]uint64, 0, len(members)*vnodes),
+		members: make(map[uint64]string, len(members)*vnodes),
+	}
+	for _, member := range members {
+		for i := 0; i < vnodes; i++ {
+			point := xxhash.Sum64String(member + "#" + strconv.Itoa(i))
+			if _, ok := r.members[point]; ok {
+				continue // collision, first member wins
+			}
+			r.points = append(r.points, point)
+			r.members[point] = member
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+	return r
+}
+
+// Owner returns the member owning the given key.
+// Returns an empty string if the ring has no members.
+func (r *Ring) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	hash := xxhash.Sum64String(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.members[r.points[i]]
+}
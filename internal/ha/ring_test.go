@@ -0,0 +1,45 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ha
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing(t *testing.T) {
+	assert.Equal(t, "", NewRing(nil).Owner("foo"))
+
+	members := []string{"http://a", "http://b", "http://c"}
+	full := NewRing(members)
+	reduced := NewRing(members[:2])
+
+	counts := make(map[string]int)
+	const numKeys = 3000
+	for i := 0; i < numKeys; i++ {
+		key := "group/10.0.0." + strconv.Itoa(i)
+		owner := full.Owner(key)
+		counts[owner]++
+		// Only keys of the removed member move.
+		if owner != "http://c" {
+			assert.Equal(t, owner, reduced.Owner(key))
+		}
+	}
+	for _, member := range members {
+		assert.InDelta(t, numKeys/3, counts[member], numKeys/6, member)
+	}
+}
@@ -16,6 +16,7 @@
 package index
 
 import (
+	"sort"
 	"time"
 
 	"github.com/hashicorp/go-memdb"
@@ -39,7 +40,7 @@ func NewDB() *DB {
 // UpsertSnapshots inserts the given snapshot entries.
 // All entries must come from the same given target.
 //
-// Snapshots that have the same (target, slot) combination get replaced.
+// Snapshots that have the same (target, slot, origin) combination get replaced.
 // Returns the number of snapshots that have been replaced (excluding new inserts).
 func (d *DB) UpsertSnapshots(entries ...*SnapshotEntry) {
 	txn := d.DB.Txn(true)
@@ -69,7 +70,7 @@ func (d *DB) GetSnapshotsByTarget(target string) (entries []*SnapshotEntry) {
 
 // GetAllSnapshots returns a list of all snapshots.
 func (d *DB) GetAllSnapshots() (entries []*SnapshotEntry) {
-	iter, err := d.DB.Txn(false).LowerBound(tableSnapshotEntry, "id", "", uint64(0))
+	iter, err := d.DB.Txn(false).LowerBound(tableSnapshotEntry, "id", "", uint64(0), "")
 	if err != nil {
 		panic("getting best snapshots failed: " + err.Error())
 	}
@@ -83,21 +84,58 @@ func (d *DB) GetAllSnapshots() (entries []*SnapshotEntry) {
 	return
 }
 
+// WatchCh returns a channel that is closed on the next change to the index.
+func (d *DB) WatchCh() <-chan struct{} {
+	iter, err := d.DB.Txn(false).Get(tableSnapshotEntry, "id_prefix")
+	if err != nil {
+		panic("watching snapshots failed: " + err.Error())
+	}
+	return iter.WatchCh()
+}
+
 // GetBestSnapshots returns newest-to-oldest snapshots.
+// Among snapshots of the same slot, sources that are draining come last,
+// and sources learned from federated trackers come after local ones.
 // The `max` argument controls the max number of snapshots to return.
 // If max is negative, it returns all snapshots.
 func (d *DB) GetBestSnapshots(max int) (entries []*SnapshotEntry) {
+	return d.GetBestSnapshotsFunc(max, nil)
+}
+
+// GetBestSnapshotsFunc is like GetBestSnapshots,
+// but skips entries for which keep returns false.
+// A nil keep function accepts all entries.
+func (d *DB) GetBestSnapshotsFunc(max int, keep func(*SnapshotEntry) bool) (entries []*SnapshotEntry) {
 	res, err := d.DB.Txn(false).Get(tableSnapshotEntry, "slot")
 	if err != nil {
 		panic("getting best snapshots failed: " + err.Error())
 	}
-	for max < 0 || len(entries) <= max {
+	for {
 		entry := res.Next()
 		if entry == nil {
 			break
 		}
+		if keep != nil && !keep(entry.(*SnapshotEntry)) {
+			continue
+		}
+		// Keep reading past max to order the last slot entirely.
+		if max >= 0 && len(entries) > max && entries[len(entries)-1].InverseSlot != entry.(*SnapshotEntry).InverseSlot {
+			break
+		}
 		entries = append(entries, entry.(*SnapshotEntry))
 	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].InverseSlot != entries[j].InverseSlot {
+			return entries[i].InverseSlot < entries[j].InverseSlot
+		}
+		if entries[i].Info.Draining != entries[j].Info.Draining {
+			return entries[j].Info.Draining
+		}
+		return entries[i].Origin == "" && entries[j].Origin != ""
+	})
+	if max >= 0 && len(entries) > max+1 {
+		entries = entries[:max+1]
+	}
 	return
 }
 
@@ -125,6 +163,33 @@ func (d *DB) DeleteOldSnapshots(minTime time.Time) (n int) {
 	return
 }
 
+// DeleteOldSnapshotsByGroup deletes snapshot entries of a target group older than the given timestamp.
+// Returns the deleted entries.
+func (d *DB) DeleteOldSnapshotsByGroup(group string, minTime time.Time) (deleted []*SnapshotEntry) {
+	txn := d.DB.Txn(true)
+	defer txn.Abort()
+	res, err := txn.Get(tableSnapshotEntry, "group", group)
+	if err != nil {
+		panic("failed to range over group snapshots: " + err.Error())
+	}
+	for {
+		entry := res.Next()
+		if entry == nil {
+			break
+		}
+		if entry.(*SnapshotEntry).UpdatedAt.Before(minTime) {
+			deleted = append(deleted, entry.(*SnapshotEntry))
+		}
+	}
+	for _, entry := range deleted {
+		if err := txn.Delete(tableSnapshotEntry, entry); err != nil {
+			panic("failed to delete expired snapshot: " + err.Error())
+		}
+	}
+	txn.Commit()
+	return
+}
+
 // DeleteSnapshotsByTarget deletes all snapshots owned by a given target.
 // Returns the number of deletions made.
 func (d *DB) DeleteSnapshotsByTarget(target string) int {
@@ -138,6 +203,58 @@ func (d *DB) DeleteSnapshotsByTarget(target string) int {
 	return n
 }
 
+// DeleteSnapshotsByGroup deletes all snapshots of a given target group.
+// Returns the number of deletions made.
+func (d *DB) DeleteSnapshotsByGroup(group string) int {
+	txn := d.DB.Txn(true)
+	defer txn.Abort()
+	n, err := txn.DeleteAll(tableSnapshotEntry, "group", group)
+	if err != nil {
+		panic("failed to delete snapshots by group: " + err.Error())
+	}
+	txn.Commit()
+	return n
+}
+
+// DeleteSnapshotsByOrigin deletes all snapshots learned from a given federated tracker.
+// Returns the number of deletions made.
+func (d *DB) DeleteSnapshotsByOrigin(origin string) int {
+	txn := d.DB.Txn(true)
+	defer txn.Abort()
+	n, err := txn.DeleteAll(tableSnapshotEntry, "origin", origin)
+	if err != nil {
+		panic("failed to delete snapshots by origin: " + err.Error())
+	}
+	txn.Commit()
+	return n
+}
+
+// DeleteUnverifiedSnapshots deletes all snapshots restored from disk
+// that have not been confirmed by a scrape since.
+// Returns the number of deletions made.
+func (d *DB) DeleteUnverifiedSnapshots() (n int) {
+	txn := d.DB.Txn(true)
+	defer txn.Abort()
+	res, err := txn.Get(tableSnapshotEntry, "id_prefix")
+	if err != nil {
+		panic("failed to range over all snapshots: " + err.Error())
+	}
+	for {
+		entry := res.Next()
+		if entry == nil {
+			break
+		}
+		if entry.(*SnapshotEntry).Unverified {
+			if err := txn.Delete(tableSnapshotEntry, entry); err != nil {
+				panic("failed to delete unverified snapshot: " + err.Error())
+			}
+			n++
+		}
+	}
+	txn.Commit()
+	return
+}
+
 func insertSnapshotEntry(txn *memdb.Txn, snap *SnapshotEntry) {
 	if err := txn.Insert(tableSnapshotEntry, snap); err != nil {
 		panic("failed to insert snapshot entry: " + err.Error())
@@ -115,3 +115,74 @@ func TestDB(t *testing.T) {
 		},
 		db.GetBestSnapshots(-1))
 }
+
+func TestDB_GetBestSnapshots_Draining(t *testing.T) {
+	db := NewDB()
+	draining := &SnapshotEntry{
+		SnapshotKey: NewSnapshotKey("host1", 100),
+		UpdatedAt:   dummyTime1,
+		Info: &types.SnapshotInfo{
+			Slot:     100,
+			Hash:     solana.Hash{0x03},
+			Draining: true,
+		},
+	}
+	db.UpsertSnapshots(draining, snapshotEntry2, snapshotEntry3)
+	assert.Equal(t,
+		[]*SnapshotEntry{
+			snapshotEntry3,
+			draining,
+			snapshotEntry2,
+		},
+		db.GetBestSnapshots(-1))
+	assert.Equal(t,
+		[]*SnapshotEntry{
+			snapshotEntry3,
+		},
+		db.GetBestSnapshots(0))
+}
+
+func TestDB_DeleteSnapshotsByGroup(t *testing.T) {
+	db := NewDB()
+	entry1 := *snapshotEntry1
+	entry1.Group = "mainnet"
+	entry3 := *snapshotEntry3
+	entry3.Group = "testnet"
+	db.UpsertSnapshots(&entry1, &entry3)
+
+	assert.Equal(t, 0, db.DeleteSnapshotsByGroup("devnet"))
+	assert.Equal(t, 1, db.DeleteSnapshotsByGroup("mainnet"))
+	assert.Equal(t, []*SnapshotEntry{&entry3}, db.GetBestSnapshots(-1))
+}
+
+func TestDB_Federated(t *testing.T) {
+	db := NewDB()
+	federated := &SnapshotEntry{
+		SnapshotKey: NewSnapshotKey("host0", 100),
+		UpdatedAt:   dummyTime1,
+		Info:        &types.SnapshotInfo{Slot: 100, Hash: solana.Hash{0x03}},
+		Origin:      "tracker:8458",
+	}
+	db.UpsertSnapshots(federated, snapshotEntry3)
+	assert.Equal(t, []*SnapshotEntry{snapshotEntry3, federated}, db.GetBestSnapshots(-1))
+
+	assert.Equal(t, 0, db.DeleteSnapshotsByOrigin("host0"))
+	assert.Equal(t, 1, db.DeleteSnapshotsByOrigin("tracker:8458"))
+	assert.Equal(t, []*SnapshotEntry{snapshotEntry3}, db.GetBestSnapshots(-1))
+}
+
+func TestDB_WatchCh(t *testing.T) {
+	db := NewDB()
+	watch := db.WatchCh()
+	select {
+	case <-watch:
+		t.Fatal("watch fired without change")
+	default:
+	}
+	db.UpsertSnapshots(snapshotEntry1)
+	select {
+	case <-watch:
+	case <-time.After(time.Second):
+		t.Fatal("watch did not fire after upsert")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+var (
+	indexSnapshotsDesc = prometheus.NewDesc(
+		"solana_cluster_tracker_index_snapshots",
+		"Number of snapshots in the index",
+		[]string{"group"}, nil)
+	newestSlotDesc = prometheus.NewDesc(
+		"solana_cluster_tracker_newest_snapshot_slot",
+		"Slot of the newest snapshot available in a group",
+		[]string{"group", "kind"}, nil)
+	newestAgeDesc = prometheus.NewDesc(
+		"solana_cluster_tracker_newest_snapshot_age_seconds",
+		"Time since the newest snapshot available in a group was created",
+		[]string{"group", "kind"}, nil)
+)
+
+// Describe implements prometheus.Collector.
+func (d *DB) Describe(descs chan<- *prometheus.Desc) {
+	descs <- indexSnapshotsDesc
+	descs <- newestSlotDesc
+	descs <- newestAgeDesc
+}
+
+// Collect implements prometheus.Collector.
+func (d *DB) Collect(metrics chan<- prometheus.Metric) {
+	type newest struct {
+		slot    uint64
+		created time.Time
+	}
+	counts := make(map[string]int)
+	newestByKind := make(map[[2]string]newest)
+	for _, entry := range d.GetAllSnapshots() {
+		counts[entry.Group]++
+		kind := types.SnapshotKindFull
+		if !entry.Info.IsFull() {
+			kind = types.SnapshotKindIncremental
+		}
+		key := [2]string{entry.Group, kind}
+		if cur, ok := newestByKind[key]; !ok || entry.Slot() > cur.slot {
+			newestByKind[key] = newest{slot: entry.Slot(), created: entry.CreatedAt()}
+		}
+	}
+	now := time.Now()
+	for group, n := range counts {
+		metrics <- prometheus.MustNewConstMetric(indexSnapshotsDesc, prometheus.GaugeValue, float64(n), group)
+	}
+	for key, n := range newestByKind {
+		metrics <- prometheus.MustNewConstMetric(newestSlotDesc, prometheus.GaugeValue, float64(n.slot), key[0], key[1])
+		metrics <- prometheus.MustNewConstMetric(newestAgeDesc, prometheus.GaugeValue, now.Sub(n.created).Seconds(), key[0], key[1])
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+func TestDB_Collect(t *testing.T) {
+	db := NewDB()
+	full := *snapshotEntry1
+	full.Group = "mainnet"
+	older := *snapshotEntry2
+	older.Group = "mainnet"
+	incremental := &SnapshotEntry{
+		SnapshotKey: NewSnapshotKey("host2", 110),
+		Group:       "mainnet",
+		UpdatedAt:   dummyTime1,
+		Info: &types.SnapshotInfo{
+			Slot:  110,
+			Files: []*types.SnapshotFile{{Slot: 110, BaseSlot: 100}, {Slot: 100}},
+		},
+	}
+	db.UpsertSnapshots(&full, &older, incremental)
+
+	assert.NoError(t, testutil.CollectAndCompare(db, strings.NewReader(`
+# HELP solana_cluster_tracker_index_snapshots Number of snapshots in the index
+# TYPE solana_cluster_tracker_index_snapshots gauge
+solana_cluster_tracker_index_snapshots{group="mainnet"} 3
+# HELP solana_cluster_tracker_newest_snapshot_slot Slot of the newest snapshot available in a group
+# TYPE solana_cluster_tracker_newest_snapshot_slot gauge
+solana_cluster_tracker_newest_snapshot_slot{group="mainnet",kind="full"} 100
+solana_cluster_tracker_newest_snapshot_slot{group="mainnet",kind="incremental"} 110
+`), "solana_cluster_tracker_index_snapshots", "solana_cluster_tracker_newest_snapshot_slot"))
+	assert.Equal(t, 5, testutil.CollectAndCount(db))
+}
@@ -0,0 +1,210 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+// Query is a compiled types.SnapshotQuery.
+type Query struct {
+	types.SnapshotQuery
+	hash   *solana.Hash
+	minAge time.Time
+	less   func(a, b *SnapshotEntry) bool
+	after  *SnapshotEntry // decoded cursor
+}
+
+// cursor is the opaque pagination token.
+// It holds the sort keys of the last entry returned.
+type cursor struct {
+	Target    string    `json:"t"`
+	Slot      uint64    `json:"s"`
+	UpdatedAt time.Time `json:"u"`
+	Size      uint64    `json:"z"`
+	Draining  bool      `json:"d,omitempty"`
+	Origin    string    `json:"o,omitempty"`
+}
+
+// NewQuery validates the given query.
+// Age filters are evaluated against the given current time.
+func NewQuery(q types.SnapshotQuery, now time.Time) (*Query, error) {
+	c := &Query{SnapshotQuery: q}
+	if q.Hash != "" {
+		hash, err := solana.HashFromBase58(q.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hash: %w", err)
+		}
+		c.hash = &hash
+	}
+	switch q.Kind {
+	case "", types.SnapshotKindFull, types.SnapshotKindIncremental:
+	default:
+		return nil, fmt.Errorf("invalid kind: %q", q.Kind)
+	}
+	if q.MaxAge < 0 {
+		return nil, fmt.Errorf("invalid max_age: %s", q.MaxAge)
+	}
+	if q.MaxAge > 0 {
+		c.minAge = now.Add(-q.MaxAge)
+	}
+	if q.Limit < 0 {
+		return nil, fmt.Errorf("invalid limit: %d", q.Limit)
+	}
+	var ok bool
+	if c.less, ok = sortOrders[q.Sort]; !ok {
+		return nil, fmt.Errorf("invalid sort: %q", q.Sort)
+	}
+	if q.Cursor != "" {
+		buf, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		var cur cursor
+		if err := json.Unmarshal(buf, &cur); err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		c.after = &SnapshotEntry{
+			SnapshotKey: NewSnapshotKey(cur.Target, cur.Slot),
+			UpdatedAt:   cur.UpdatedAt,
+			Origin:      cur.Origin,
+			Info: &types.SnapshotInfo{
+				Slot:      cur.Slot,
+				TotalSize: cur.Size,
+				Draining:  cur.Draining,
+			},
+		}
+	}
+	return c, nil
+}
+
+// Match returns whether the entry passes all filters.
+func (q *Query) Match(entry *SnapshotEntry) bool {
+	info := entry.Info
+	switch {
+	case q.Group != "" && entry.Group != q.Group,
+		q.Target != "" && entry.Target != q.Target,
+		q.MinSlot != 0 && info.Slot < q.MinSlot,
+		q.MaxSlot != 0 && info.Slot > q.MaxSlot,
+		q.hash != nil && info.Hash != *q.hash,
+		q.Kind == types.SnapshotKindFull && !info.IsFull(),
+		q.Kind == types.SnapshotKindIncremental && info.IsFull(),
+		q.BaseSlot != nil && info.BaseSlot() != *q.BaseSlot,
+		q.MinSize != 0 && info.TotalSize < q.MinSize,
+		!q.minAge.IsZero() && entry.CreatedAt().Before(q.minAge),
+		q.Verified && entry.Unverified,
+		q.LocalOnly && entry.Origin != "":
+		return false
+	}
+	return true
+}
+
+// sortOrders maps sort names to strict orderings.
+// All orders are total thanks to the (target, slot, origin) tie breaker,
+// which makes cursors stable.
+var sortOrders = map[string]func(a, b *SnapshotEntry) bool{
+	"":                      bySlotDesc,
+	types.SortSlotDesc:      bySlotDesc,
+	types.SortSlotAsc:       func(a, b *SnapshotEntry) bool { return cmpSlot(a, b, true) },
+	types.SortUpdatedAtDesc: func(a, b *SnapshotEntry) bool { return cmpTime(b, a, a, b) },
+	types.SortUpdatedAtAsc:  func(a, b *SnapshotEntry) bool { return cmpTime(a, b, a, b) },
+	types.SortSizeDesc:      func(a, b *SnapshotEntry) bool { return cmpSize(b, a, a, b) },
+	types.SortSizeAsc:       func(a, b *SnapshotEntry) bool { return cmpSize(a, b, a, b) },
+}
+
+// bySlotDesc matches the ordering of GetBestSnapshots.
+func bySlotDesc(a, b *SnapshotEntry) bool {
+	return cmpSlot(a, b, false)
+}
+
+func cmpSlot(a, b *SnapshotEntry, asc bool) bool {
+	if a.InverseSlot != b.InverseSlot {
+		return (a.InverseSlot < b.InverseSlot) != asc
+	}
+	if a.Info.Draining != b.Info.Draining {
+		return b.Info.Draining
+	}
+	if a.Target != b.Target {
+		return a.Target < b.Target
+	}
+	return a.Origin < b.Origin
+}
+
+// cmpTime orders x before y by update time, falling back to the key of a and b.
+// Entries scraped in the same pass share an update time,
+// so the unique (target, slot, origin) key keeps cursors stable.
+func cmpTime(x, y, a, b *SnapshotEntry) bool {
+	if !x.UpdatedAt.Equal(y.UpdatedAt) {
+		return x.UpdatedAt.Before(y.UpdatedAt)
+	}
+	return cmpKey(a, b)
+}
+
+// cmpSize orders x before y by total size, falling back to the key of a and b.
+func cmpSize(x, y, a, b *SnapshotEntry) bool {
+	if x.Info.TotalSize != y.Info.TotalSize {
+		return x.Info.TotalSize < y.Info.TotalSize
+	}
+	return cmpKey(a, b)
+}
+
+func cmpKey(a, b *SnapshotEntry) bool {
+	if c := strings.Compare(a.Target, b.Target); c != 0 {
+		return c < 0
+	}
+	if a.InverseSlot != b.InverseSlot {
+		return a.InverseSlot < b.InverseSlot
+	}
+	return a.Origin < b.Origin
+}
+
+// FindSnapshots returns one page of entries matching the query.
+// If more entries are available, next is the cursor for the following page.
+func (d *DB) FindSnapshots(q *Query) (entries []*SnapshotEntry, next string) {
+	for _, entry := range d.GetAllSnapshots() {
+		if !q.Match(entry) {
+			continue
+		}
+		if q.after != nil && !q.less(q.after, entry) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return q.less(entries[i], entries[j])
+	})
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+		last := entries[len(entries)-1]
+		buf, _ := json.Marshal(&cursor{
+			Target:    last.Target,
+			Slot:      last.Slot(),
+			UpdatedAt: last.UpdatedAt,
+			Size:      last.Info.TotalSize,
+			Draining:  last.Info.Draining,
+			Origin:    last.Origin,
+		})
+		next = base64.RawURLEncoding.EncodeToString(buf)
+	}
+	return
+}
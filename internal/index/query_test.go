@@ -0,0 +1,132 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+func TestDB_FindSnapshots(t *testing.T) {
+	createdAt := dummyTime1.Add(-time.Minute)
+	incremental := &SnapshotEntry{
+		SnapshotKey: NewSnapshotKey("host2", 110),
+		Group:       "mainnet",
+		UpdatedAt:   dummyTime1.Add(time.Minute),
+		Info: &types.SnapshotInfo{
+			Slot: 110,
+			Files: []*types.SnapshotFile{
+				{Slot: 110, BaseSlot: 100, ModTime: &createdAt},
+				{Slot: 100},
+			},
+			TotalSize: 10,
+		},
+	}
+	db := NewDB()
+	db.UpsertSnapshots(snapshotEntry1, snapshotEntry2, snapshotEntry3, incremental)
+
+	find := func(q types.SnapshotQuery) ([]*SnapshotEntry, string) {
+		query, err := NewQuery(q, dummyTime1.Add(time.Minute))
+		require.NoError(t, err)
+		return db.FindSnapshots(query)
+	}
+	baseSlot := uint64(100)
+
+	tests := []struct {
+		name  string
+		query types.SnapshotQuery
+		want  []*SnapshotEntry
+	}{
+		{"All", types.SnapshotQuery{}, []*SnapshotEntry{incremental, snapshotEntry1, snapshotEntry3, snapshotEntry2}},
+		{"Group", types.SnapshotQuery{Group: "mainnet"}, []*SnapshotEntry{incremental}},
+		{"Target", types.SnapshotQuery{Target: "host1"}, []*SnapshotEntry{snapshotEntry1, snapshotEntry2}},
+		{"SlotRange", types.SnapshotQuery{MinSlot: 100, MaxSlot: 105}, []*SnapshotEntry{snapshotEntry1, snapshotEntry3}},
+		{"Hash", types.SnapshotQuery{Hash: snapshotEntry2.Info.Hash.String()}, []*SnapshotEntry{snapshotEntry2}},
+		{"Full", types.SnapshotQuery{Kind: types.SnapshotKindFull, MinSlot: 100}, []*SnapshotEntry{snapshotEntry1, snapshotEntry3}},
+		{"Incremental", types.SnapshotQuery{Kind: types.SnapshotKindIncremental}, []*SnapshotEntry{incremental}},
+		{"BaseSlot", types.SnapshotQuery{BaseSlot: &baseSlot}, []*SnapshotEntry{incremental}},
+		{"MinSize", types.SnapshotQuery{MinSize: 1}, []*SnapshotEntry{incremental}},
+		{"MaxAge", types.SnapshotQuery{MaxAge: 70 * time.Second}, []*SnapshotEntry{snapshotEntry1, snapshotEntry3}},
+		{"SortUpdatedAt", types.SnapshotQuery{Sort: types.SortUpdatedAtAsc}, []*SnapshotEntry{snapshotEntry2, snapshotEntry1, snapshotEntry3, incremental}},
+		{"SortSize", types.SnapshotQuery{Sort: types.SortSizeDesc, Target: "host2"}, []*SnapshotEntry{incremental, snapshotEntry3}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, next := find(tc.query)
+			assert.Equal(t, tc.want, entries)
+			assert.Empty(t, next)
+		})
+	}
+
+	t.Run("Pagination", func(t *testing.T) {
+		query := types.SnapshotQuery{Sort: types.SortSlotAsc, Limit: 3}
+		entries, next := find(query)
+		assert.Equal(t, []*SnapshotEntry{snapshotEntry2, snapshotEntry1, snapshotEntry3}, entries)
+		require.NotEmpty(t, next)
+
+		query.Cursor = next
+		entries, next = find(query)
+		assert.Equal(t, []*SnapshotEntry{incremental}, entries)
+		assert.Empty(t, next)
+	})
+
+	t.Run("PaginationEqualUpdatedAt", func(t *testing.T) {
+		// snapshotEntry1 and snapshotEntry3 share the same update time.
+		query := types.SnapshotQuery{Sort: types.SortUpdatedAtDesc, Limit: 1}
+		var all []*SnapshotEntry
+		for {
+			entries, next := find(query)
+			require.Len(t, entries, 1)
+			all = append(all, entries...)
+			if next == "" {
+				break
+			}
+			query.Cursor = next
+		}
+		assert.Equal(t, []*SnapshotEntry{incremental, snapshotEntry1, snapshotEntry3, snapshotEntry2}, all)
+	})
+
+	t.Run("LocalOnly", func(t *testing.T) {
+		federated := &SnapshotEntry{
+			SnapshotKey: NewSnapshotKey("host3", 120),
+			Info:        &types.SnapshotInfo{Slot: 120},
+			Origin:      "tracker:8458",
+		}
+		db.UpsertSnapshots(federated)
+		defer db.DeleteSnapshotsByOrigin("tracker:8458")
+
+		entries, _ := find(types.SnapshotQuery{MinSlot: 110})
+		assert.Equal(t, []*SnapshotEntry{federated, incremental}, entries)
+		entries, _ = find(types.SnapshotQuery{MinSlot: 110, LocalOnly: true})
+		assert.Equal(t, []*SnapshotEntry{incremental}, entries)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, q := range []types.SnapshotQuery{
+			{Hash: "foo"},
+			{Kind: "foo"},
+			{Sort: "foo"},
+			{Cursor: "!"},
+			{Limit: -1},
+		} {
+			_, err := NewQuery(q, time.Now())
+			assert.Error(t, err, "%+v", q)
+		}
+	})
+}
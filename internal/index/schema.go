@@ -30,10 +30,25 @@ var schema = memdb.DBSchema{
 						Indexes: []memdb.Indexer{
 							&memdb.StringFieldIndex{Field: "Target"},
 							&memdb.UintFieldIndex{Field: "InverseSlot"},
+							// Federated trackers may report the same target,
+							// so their entries are keyed by origin, too.
+							&memdb.StringFieldIndex{Field: "Origin"},
 						},
-						AllowMissing: false,
+						AllowMissing: true, // local entries have no origin
 					},
 				},
+				"group": {
+					Name:         "group",
+					Unique:       false,
+					AllowMissing: true,
+					Indexer:      &memdb.StringFieldIndex{Field: "Group"},
+				},
+				"origin": {
+					Name:         "origin",
+					Unique:       false,
+					AllowMissing: true,
+					Indexer:      &memdb.StringFieldIndex{Field: "Origin"},
+				},
 				"slot": {
 					Name:    "slot",
 					Unique:  false,
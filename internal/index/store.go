@@ -0,0 +1,123 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var bucketSnapshots = []byte("snapshots")
+
+// Store persists snapshot entries to an on-disk BoltDB file.
+//
+// It is not kept in sync with the in-memory index on every write.
+// Instead, the whole index is written out periodically
+// and read back on startup to serve requests before the first scrape finished.
+type Store struct {
+	db *bbolt.DB
+}
+
+// OpenStore opens or creates the store at the given file path.
+func OpenStore(path string) (*Store, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save replaces the stored entries with a snapshot of the given index.
+// Returns the number of entries written.
+func (s *Store) Save(d *DB) (n int, err error) {
+	entries := d.GetAllSnapshots()
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(bucketSnapshots) != nil {
+			if err := tx.DeleteBucket(bucketSnapshots); err != nil {
+				return err
+			}
+		}
+		bucket, err := tx.CreateBucket(bucketSnapshots)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			buf, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(storeKey(entry), buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// Load reads all stored entries into the given index.
+// Loaded entries are marked as unverified.
+// Keys are not parsed, so files written with older key formats load unchanged
+// and are rewritten in the current format by the next Save.
+// Returns the number of entries loaded.
+func (s *Store) Load(d *DB) (n int, err error) {
+	var entries []*SnapshotEntry
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(bucketSnapshots)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			entry := new(SnapshotEntry)
+			if err := json.Unmarshal(v, entry); err != nil {
+				return fmt.Errorf("invalid entry %q: %w", k, err)
+			}
+			if entry.Info == nil {
+				return fmt.Errorf("invalid entry %q: missing info", k)
+			}
+			entry.Unverified = true
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	d.UpsertSnapshots(entries...)
+	return len(entries), nil
+}
+
+// storeKey mirrors the unique (target, slot, origin) index of the DB,
+// such that federated entries of the same target and slot do not replace each other.
+func storeKey(entry *SnapshotEntry) []byte {
+	key := make([]byte, 0, len(entry.Target)+len(entry.Origin)+10)
+	key = append(key, entry.Target...)
+	key = append(key, 0)
+	key = append(key, entry.Origin...)
+	key = append(key, 0)
+	return binary.BigEndian.AppendUint64(key, entry.InverseSlot)
+}
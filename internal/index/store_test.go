@@ -0,0 +1,128 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.etcd.io/bbolt"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+
+	store, err := OpenStore(path)
+	require.NoError(t, err)
+	db := NewDB()
+	db.UpsertSnapshots(snapshotEntry1, snapshotEntry2, snapshotEntry3)
+	n, err := store.Save(db)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// Saving again replaces the previous contents.
+	assert.Equal(t, 2, db.DeleteSnapshotsByTarget("host1"))
+	n, err = store.Save(db)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, store.Close())
+
+	store, err = OpenStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	restored := NewDB()
+	n, err = store.Load(restored)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	entries := restored.GetBestSnapshots(-1)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Unverified)
+	assert.Equal(t, snapshotEntry3.SnapshotKey, entries[0].SnapshotKey)
+	assert.Equal(t, snapshotEntry3.Info, entries[0].Info)
+	assert.True(t, snapshotEntry3.UpdatedAt.Equal(entries[0].UpdatedAt))
+
+	// Scraped entries replace restored ones.
+	restored.UpsertSnapshots(snapshotEntry1)
+	verified := func(entry *SnapshotEntry) bool { return !entry.Unverified }
+	assert.Equal(t, []*SnapshotEntry{snapshotEntry1}, restored.GetBestSnapshotsFunc(-1, verified))
+	assert.Equal(t, 1, restored.DeleteUnverifiedSnapshots())
+	assert.Equal(t, []*SnapshotEntry{snapshotEntry1}, restored.GetBestSnapshots(-1))
+}
+
+func TestStore_Origins(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), "index.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	db := NewDB()
+	for _, origin := range []string{"", "tracker1:8458", "tracker2:8458"} {
+		db.UpsertSnapshots(&SnapshotEntry{
+			SnapshotKey: NewSnapshotKey("host1", 100),
+			Info:        &types.SnapshotInfo{Slot: 100},
+			Origin:      origin,
+		})
+	}
+	n, err := store.Save(db)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	restored := NewDB()
+	n, err = store.Load(restored)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	var origins []string
+	for _, entry := range restored.GetSnapshotsByTarget("host1") {
+		origins = append(origins, entry.Origin)
+	}
+	assert.ElementsMatch(t, []string{"", "tracker1:8458", "tracker2:8458"}, origins)
+}
+
+func TestStore_LegacyKeys(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), "index.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	// Keys used to consist of target and inverse slot only.
+	require.NoError(t, store.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucket(bucketSnapshots)
+		if err != nil {
+			return err
+		}
+		buf, err := json.Marshal(snapshotEntry1)
+		if err != nil {
+			return err
+		}
+		key := append([]byte("host1\x00"), binary.BigEndian.AppendUint64(nil, snapshotEntry1.InverseSlot)...)
+		return bucket.Put(key, buf)
+	}))
+
+	restored := NewDB()
+	n, err := store.Load(restored)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = store.Save(restored)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, store.db.View(func(tx *bbolt.Tx) error {
+		assert.NotNil(t, tx.Bucket(bucketSnapshots).Get(storeKey(snapshotEntry1)))
+		return nil
+	}))
+}
@@ -22,8 +22,26 @@ import (
 
 type SnapshotEntry struct {
 	SnapshotKey
+	Group     string              `json:"group"`
 	Info      *types.SnapshotInfo `json:"info"`
 	UpdatedAt time.Time           `json:"updated_at"`
+
+	// Unverified is set on entries restored from disk
+	// until the target has been scraped again.
+	Unverified bool `json:"unverified,omitempty"`
+
+	// Origin is the federated tracker the entry was learned from.
+	// Empty for targets scraped by this tracker.
+	Origin string `json:"origin,omitempty"`
+}
+
+// CreatedAt returns the modification time of the newest snapshot file,
+// or the scrape time if the sidecar did not report it.
+func (e *SnapshotEntry) CreatedAt() time.Time {
+	if files := e.Info.Files; len(files) > 0 && files[0].ModTime != nil {
+		return *files[0].ModTime
+	}
+	return e.UpdatedAt
 }
 
 type SnapshotKey struct {
@@ -0,0 +1,124 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtest
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/api"
+	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/internal/tracker"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+// TestAdvice asks the tracker what to download.
+func TestAdvice(t *testing.T) {
+	full := &types.SnapshotFile{
+		FileName: fmt.Sprintf("snapshot-1000-%s.tar.zst", solana.Hash{0x01}),
+		Slot:     1000,
+		Hash:     solana.Hash{0x01},
+		Ext:      ".tar.zst",
+	}
+	incremental := &types.SnapshotFile{
+		FileName: fmt.Sprintf("incremental-snapshot-1000-1600-%s.tar.zst", solana.Hash{0x02}),
+		Slot:     1600,
+		BaseSlot: 1000,
+		Hash:     solana.Hash{0x02},
+		Ext:      ".tar.zst",
+	}
+	db := index.NewDB()
+	for _, target := range []string{"node1:13080", "node2:13080"} {
+		db.UpsertSnapshots(&index.SnapshotEntry{
+			SnapshotKey: index.NewSnapshotKey(target, 1600),
+			Group:       "mainnet",
+			UpdatedAt:   time.Now(),
+			Info: &types.SnapshotInfo{
+				Slot:  1600,
+				Hash:  solana.Hash{0x02},
+				Files: []*types.SnapshotFile{incremental, full},
+			},
+		})
+	}
+
+	handler := tracker.NewAdviceHandler(tracker.NewHandler(db))
+	handler.Configure(&types.Config{
+		TargetGroups: []*types.TargetGroup{{
+			Group:   "mainnet",
+			Scheme:  "https",
+			APIPath: "/sidecar",
+			Labels:  map[string]string{tracker.ClusterLabel: "mainnet-beta"},
+		}},
+	})
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	engine.Use(specMiddleware(t, api.Tracker))
+	handler.RegisterHandlers(engine.Group("/v1"))
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	client := fetch.NewTrackerClientWithResty(resty.NewWithClient(server.Client()).SetHostURL(server.URL))
+	ctx := context.TODO()
+
+	t.Run("NothingFound", func(t *testing.T) {
+		advice, err := client.Advise(ctx, &types.AdviceRequest{Cluster: "testnet"})
+		require.NoError(t, err)
+		assert.Equal(t, types.AdviceNothingFound, advice.Advice)
+		assert.Equal(t, []string{"no target group is labeled cluster=testnet"}, advice.Reasons)
+	})
+
+	t.Run("UpToDate", func(t *testing.T) {
+		advice, err := client.Advise(ctx, &types.AdviceRequest{
+			Snapshots: []*types.SnapshotInfo{{Slot: 1200, Files: []*types.SnapshotFile{}}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, types.AdviceUpToDate, advice.Advice)
+		assert.Empty(t, advice.Files)
+	})
+
+	t.Run("Fetch", func(t *testing.T) {
+		advice, err := client.Advise(ctx, &types.AdviceRequest{Cluster: "mainnet-beta", MaxSources: 1})
+		require.NoError(t, err)
+		assert.Equal(t, types.AdviceFetch, advice.Advice)
+		assert.Equal(t, uint64(1600), advice.Slot)
+		require.Len(t, advice.Files, 2)
+		assert.Equal(t, incremental.FileName, advice.Files[0].FileName)
+		assert.Equal(t, full.FileName, advice.Files[1].FileName)
+		require.Len(t, advice.Files[0].Sources, 1)
+		assert.Equal(t, "https://node1:13080/sidecar/v1/snapshot/"+incremental.FileName, advice.Files[0].Sources[0].URL)
+	})
+
+	t.Run("FetchIncremental", func(t *testing.T) {
+		minSlots := uint64(100)
+		advice, err := client.Advise(ctx, &types.AdviceRequest{
+			Snapshots: []*types.SnapshotInfo{{Slot: 1000, Hash: solana.Hash{0x01}, Files: []*types.SnapshotFile{full}}},
+			MinSlots:  &minSlots,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, types.AdviceFetch, advice.Advice)
+		require.Len(t, advice.Files, 1)
+		assert.Equal(t, incremental.FileName, advice.Files[0].FileName)
+		assert.Len(t, advice.Files[0].Sources, 2)
+		assert.Contains(t, advice.Reasons, full.FileName+" is present locally")
+	})
+}
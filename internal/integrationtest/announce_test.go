@@ -0,0 +1,124 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/api"
+	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/internal/sidecar"
+	"go.blockdaemon.com/solana/cluster-manager/internal/tracker"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap/zaptest"
+)
+
+// TestAnnounce pushes snapshots from a sidecar announcer into a tracker.
+func TestAnnounce(t *testing.T) {
+	sidecarServer, root := newSidecar(t, 100)
+	defer sidecarServer.Close()
+	snapshots := &sidecar.SnapshotHandler{
+		LedgerDir: root.GetLedgerDir(t),
+		Log:       zaptest.NewLogger(t),
+	}
+
+	// Create tracker accepting announcements.
+	db := index.NewDB()
+	collector := scraper.NewCollector(db)
+	collector.Log = zaptest.NewLogger(t).Named("collector")
+	collector.Start()
+	defer collector.Close()
+	handler := tracker.NewAnnounceHandler(collector.Probes(), collector, zaptest.NewLogger(t).Named("announce"))
+	handler.Configure(&types.Config{
+		TargetGroups: []*types.TargetGroup{
+			{
+				Group:        "test",
+				AnnounceAuth: &types.AnnounceAuth{Secret: "secret"},
+			},
+		},
+	})
+	collector.ObserveTargets("test", nil, []string{"node1:13080"})
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	engine.Use(specMiddleware(t, api.Tracker))
+	handler.RegisterHandlers(engine.Group("/v1"))
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	newClient := func(token string) *fetch.TrackerClient {
+		return fetch.NewTrackerClientWithResty(resty.NewWithClient(server.Client()).
+			SetHostURL(server.URL).
+			SetAuthToken(token))
+	}
+	ctx := context.TODO()
+
+	token := types.AnnounceToken("secret", "node1:13080")
+
+	t.Run("Rejected", func(t *testing.T) {
+		err := newClient("secret").Announce(ctx, "node1:13080", &types.SnapshotAnnouncement{Snapshots: []*types.SnapshotInfo{}})
+		assert.EqualError(t, err, "announce: 401 Unauthorized")
+		// The token of node1 does not allow announcing for another target.
+		err = newClient(token).Announce(ctx, "node2:13080", &types.SnapshotAnnouncement{Snapshots: []*types.SnapshotInfo{}})
+		assert.EqualError(t, err, "announce: 401 Unauthorized")
+		err = newClient(types.AnnounceToken("secret", "node2:13080")).Announce(ctx, "node2:13080", &types.SnapshotAnnouncement{Snapshots: []*types.SnapshotInfo{}})
+		assert.EqualError(t, err, "announce: 403 Forbidden")
+	})
+
+	t.Run("Body", func(t *testing.T) {
+		// Skip the spec middleware, which reads the whole body.
+		engine := gin.New()
+		handler.RegisterHandlers(engine.Group("/v1"))
+		announce := func(token, body string) int {
+			req := httptest.NewRequest(http.MethodPost, "/v1/announce?target=node1:13080", strings.NewReader(body))
+			req.Header.Set("authorization", "Bearer "+token)
+			req.Header.Set("content-type", "application/json")
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			return rec.Code
+		}
+		// The token is checked before the body is read.
+		assert.Equal(t, http.StatusUnauthorized, announce("secret", "{"))
+		assert.Equal(t, http.StatusBadRequest, announce(token, "{"))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, announce(token, strings.Repeat(" ", 2<<20)+`{"snapshots":[]}`))
+	})
+
+	t.Run("Accepted", func(t *testing.T) {
+		announcer := sidecar.NewAnnouncer(snapshots, "node1:13080",
+			map[string]*fetch.TrackerClient{server.URL: newClient(token)},
+			zaptest.NewLogger(t).Named("announcer"))
+		announcer.Interval = 10 * time.Millisecond
+		announceCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go announcer.Run(announceCtx)
+
+		require.Eventually(t, func() bool {
+			return len(db.GetSnapshotsByTarget("node1:13080")) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		entry := db.GetSnapshotsByTarget("node1:13080")[0]
+		assert.Equal(t, "test", entry.Group)
+		assert.Equal(t, uint64(100), entry.Slot())
+	})
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtest
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/api"
+	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/internal/tracker"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap/zaptest"
+)
+
+// TestAssign spreads downloads of the same snapshot across its sources.
+func TestAssign(t *testing.T) {
+	db := index.NewDB()
+	now := time.Now()
+	for i, target := range []string{"host1", "host2", "host3", "host4"} {
+		db.UpsertSnapshots(&index.SnapshotEntry{
+			SnapshotKey: index.NewSnapshotKey(target, 100),
+			Group:       "test",
+			UpdatedAt:   now.Add(-time.Duration(i) * time.Second),
+			Info: &types.SnapshotInfo{
+				Slot:     100,
+				Hash:     solana.Hash{0x01},
+				Files:    []*types.SnapshotFile{},
+				Draining: target == "host3",
+			},
+		})
+	}
+	cordons := tracker.NewCordons()
+	cordons.Add(types.Cordon{Target: "host4"})
+	assignments := tracker.NewAssignments(time.Minute)
+	handler := tracker.NewAssignHandler(db, assignments, zaptest.NewLogger(t))
+	handler.Cordons = cordons
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	engine.Use(specMiddleware(t, api.Tracker))
+	handler.RegisterHandlers(engine.Group("/v1"))
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	client := fetch.NewTrackerClientWithResty(resty.NewWithClient(server.Client()).SetHostURL(server.URL))
+	ctx := context.TODO()
+	req := &types.AssignmentRequest{Slot: 100, Hash: solana.Hash{0x01}, Client: "test"}
+
+	// Leases alternate between the two healthy sources, newest scrape first.
+	var leases []*types.Assignment
+	for _, want := range []string{"host1", "host2", "host1", "host2"} {
+		assignment, err := client.Assign(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, want, assignment.Source.Target)
+		assert.Equal(t, uint64(100), assignment.Source.Slot)
+		leases = append(leases, assignment)
+	}
+	assert.Equal(t, map[string]int{"host1": 2, "host2": 2}, assignments.Active(time.Now()))
+
+	// Released capacity is assigned again.
+	require.NoError(t, client.ReleaseAssignment(ctx, leases[1].ID, types.AssignmentFailed))
+	assignment, err := client.Assign(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "host2", assignment.Source.Target)
+	assert.Equal(t, 2, assignment.Active)
+
+	// Renewal extends the lease.
+	renewed, err := client.RenewAssignment(ctx, leases[0].ID)
+	require.NoError(t, err)
+	assert.False(t, renewed.ExpiresAt.Before(leases[0].ExpiresAt))
+	assert.Equal(t, "host1", renewed.Source.Target)
+
+	// Unknown leases and snapshots are rejected.
+	require.NoError(t, client.ReleaseAssignment(ctx, leases[0].ID, types.AssignmentCompleted))
+	assert.EqualError(t, client.ReleaseAssignment(ctx, leases[0].ID, types.AssignmentCompleted),
+		"release assignment: 404 Not Found")
+	_, err = client.RenewAssignment(ctx, leases[0].ID)
+	assert.EqualError(t, err, "renew assignment: 404 Not Found")
+	_, err = client.Assign(ctx, &types.AssignmentRequest{Slot: 100, Hash: solana.Hash{0x02}})
+	assert.EqualError(t, err, "assign: 404 Not Found")
+
+	// Draining sources are used as a last resort.
+	cordons.Add(types.Cordon{Target: "host1"})
+	cordons.Add(types.Cordon{Target: "host2"})
+	assignment, err = client.Assign(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "host3", assignment.Source.Target)
+
+	// Leases expire if not renewed.
+	assert.Empty(t, assignments.Active(time.Now().Add(time.Minute)))
+}
@@ -0,0 +1,189 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/api"
+	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/internal/tracker"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestAuth checks roles and audit logging of the tracker API.
+func TestAuth(t *testing.T) {
+	authFile := filepath.Join(t.TempDir(), "auth.yml")
+	require.NoError(t, os.WriteFile(authFile, []byte(`
+tokens:
+  - name: fetcher
+    role: read
+    token: read-token
+  - name: operator
+    role: admin
+    token: admin-token
+`), 0o600))
+	auditCore, audit := observer.New(zap.InfoLevel)
+	auth := tracker.NewAuth(authFile, zap.New(auditCore))
+	require.NoError(t, auth.Reload())
+
+	db := index.NewDB()
+	for i, target := range []string{"host1", "host2"} {
+		db.UpsertSnapshots(&index.SnapshotEntry{
+			SnapshotKey: index.NewSnapshotKey(target, uint64(100+i)),
+			UpdatedAt:   time.Now(),
+			Info: &types.SnapshotInfo{
+				Slot:  uint64(100 + i),
+				Hash:  solana.Hash{byte(i)},
+				Files: []*types.SnapshotFile{},
+			},
+		})
+	}
+
+	// Set up routes like the tracker command.
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	engine.Use(specMiddleware(t, api.Tracker))
+	groupV1 := engine.Group("/v1")
+	readV1 := groupV1.Group("", auth.Require(types.RoleRead))
+	adminV1 := groupV1.Group("", auth.Require(types.RoleAdmin), auth.Audit())
+	cordons := tracker.NewCordons()
+	handler := tracker.NewHandler(db)
+	handler.Cordons = cordons
+	handler.RegisterHandlers(readV1)
+	tracker.NewCordonHandler(cordons).RegisterHandlers(adminV1)
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	newClient := func(token string) *resty.Client {
+		client := resty.NewWithClient(server.Client()).SetHostURL(server.URL)
+		if token != "" {
+			client.SetAuthToken(token)
+		}
+		return client
+	}
+	ctx := context.TODO()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := fetch.NewTrackerClientWithResty(newClient("")).GetBestSnapshots(ctx, -1)
+		assert.EqualError(t, err, "get best snapshots: 401 Unauthorized")
+		_, err = fetch.NewTrackerClientWithResty(newClient("wrong")).GetBestSnapshots(ctx, -1)
+		assert.EqualError(t, err, "get best snapshots: 401 Unauthorized")
+	})
+
+	t.Run("Read", func(t *testing.T) {
+		sources, err := fetch.NewTrackerClientWithResty(newClient("read-token")).GetBestSnapshots(ctx, -1)
+		require.NoError(t, err)
+		assert.Len(t, sources, 2)
+		res, err := newClient("read-token").R().Put("/v1/cordons/host2")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode())
+		assert.False(t, cordons.Cordoned("host2"))
+	})
+
+	t.Run("Cordon", func(t *testing.T) {
+		admin := newClient("admin-token")
+		res, err := admin.R().SetQueryParam("reason", "bad disk").Put("/v1/cordons/host2")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, res.StatusCode())
+
+		var list []types.Cordon
+		res, err = admin.R().SetResult(&list).Get("/v1/cordons")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		require.Len(t, list, 1)
+		assert.Equal(t, "host2", list[0].Target)
+		assert.Equal(t, "bad disk", list[0].Reason)
+		assert.Equal(t, "operator", list[0].CreatedBy)
+
+		// Cordoned targets are skipped.
+		sources, err := fetch.NewTrackerClientWithResty(newClient("read-token")).GetBestSnapshots(ctx, -1)
+		require.NoError(t, err)
+		require.Len(t, sources, 1)
+		assert.Equal(t, "host1", sources[0].Target)
+
+		res, err = admin.R().Delete("/v1/cordons/host2")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, res.StatusCode())
+		res, err = admin.R().Delete("/v1/cordons/host2")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode())
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		var actions []string
+		for _, entry := range audit.All() {
+			fields := entry.ContextMap()
+			actions = append(actions, entry.Message+" "+fields["method"].(string)+" "+fields["path"].(string)+" "+fields["principal"].(string))
+		}
+		assert.Equal(t, []string{
+			"Denied request PUT /v1/cordons/host2 fetcher",
+			"Admin action PUT /v1/cordons/host2 operator",
+			"Admin action GET /v1/cordons operator",
+			"Admin action DELETE /v1/cordons/host2 operator",
+			"Admin action DELETE /v1/cordons/host2 operator",
+		}, actions)
+	})
+}
+
+// TestAuth_Peer checks that only replicas may access the replication API.
+func TestAuth_Peer(t *testing.T) {
+	authFile := filepath.Join(t.TempDir(), "auth.yml")
+	require.NoError(t, os.WriteFile(authFile, []byte(`
+tokens:
+  - name: operator
+    role: admin
+    token: admin-token
+`), 0o600))
+	auth := tracker.NewAuth(authFile, zap.NewNop())
+	auth.PeerSecret = "cluster-secret"
+	require.NoError(t, auth.Reload())
+
+	mux := http.NewServeMux()
+	ok := http.HandlerFunc(func(wr http.ResponseWriter, _ *http.Request) { wr.WriteHeader(http.StatusNoContent) })
+	mux.Handle("/ha/health", auth.RequireHTTP(types.RolePeer, ok))
+	mux.Handle("/reload", auth.RequireHTTP(types.RoleAdmin, ok))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, tc := range []struct {
+		path, token string
+		status      int
+	}{
+		{"/ha/health", "", http.StatusUnauthorized},
+		{"/ha/health", "admin-token", http.StatusForbidden},
+		{"/ha/health", "cluster-secret", http.StatusNoContent},
+		{"/reload", "cluster-secret", http.StatusForbidden},
+		{"/reload", "admin-token", http.StatusNoContent},
+	} {
+		res, err := resty.New().SetAuthToken(tc.token).R().Post(server.URL + tc.path)
+		require.NoError(t, err)
+		assert.Equal(t, tc.status, res.StatusCode(), "%s with %q", tc.path, tc.token)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtest
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap/zaptest"
+)
+
+// TestFederation scrapes a tracker from another tracker.
+func TestFederation(t *testing.T) {
+	sidecarServer, _ := newSidecar(t, 100)
+	defer sidecarServer.Close()
+	sidecarURL, err := url.Parse(sidecarServer.URL)
+	require.NoError(t, err)
+
+	// Remote tracker knows the sidecar, and a source it learned from yet another tracker.
+	remoteDB := index.NewDB()
+	remoteDB.UpsertSnapshots(&index.SnapshotEntry{
+		SnapshotKey: index.NewSnapshotKey(sidecarURL.Host, 100),
+		Group:       "remote",
+		Info:        &types.SnapshotInfo{Slot: 100},
+		UpdatedAt:   time.Now(),
+	}, &index.SnapshotEntry{
+		SnapshotKey: index.NewSnapshotKey("elsewhere:8899", 101),
+		Group:       "remote",
+		Info:        &types.SnapshotInfo{Slot: 101},
+		UpdatedAt:   time.Now(),
+		Origin:      "tracker.elsewhere:8458",
+	})
+	remoteServer := newTracker(t, remoteDB)
+	defer remoteServer.Close()
+	remoteURL, err := url.Parse(remoteServer.URL)
+	require.NoError(t, err)
+
+	// Local tracker federates the remote tracker.
+	group := &types.TargetGroup{
+		Group:         "federated",
+		Scheme:        "http",
+		Federation:    &types.Federation{Penalty: 100},
+		StaticTargets: &types.StaticTargets{Targets: []string{remoteURL.Host}},
+	}
+	require.NoError(t, group.Validate())
+	db := index.NewDB()
+	collector := scraper.NewCollector(db)
+	collector.Log = zaptest.NewLogger(t).Named("collector")
+	collector.Start()
+	defer collector.Close()
+	prober, err := scraper.NewProber(group)
+	require.NoError(t, err)
+	scraper_ := scraper.NewScraper(prober, group.StaticTargets)
+	scraper_.Group = group.Group
+	scraper_.Start(collector.Probes(), 50*time.Millisecond)
+	defer scraper_.Close()
+
+	require.Eventually(t, func() bool {
+		return len(db.GetAllSnapshots()) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	entries := db.GetAllSnapshots()
+	require.Len(t, entries, 1, "sources of other trackers must not be re-exported")
+	assert.Equal(t, sidecarURL.Host, entries[0].Target)
+	assert.Equal(t, "federated", entries[0].Group)
+	assert.Equal(t, remoteURL.Host, entries[0].Origin)
+
+	server := newTracker(t, db)
+	defer server.Close()
+	client := fetch.NewTrackerClientWithResty(resty.NewWithClient(server.Client()).SetHostURL(server.URL))
+	ctx := context.TODO()
+
+	t.Run("BestSnapshots", func(t *testing.T) {
+		snaps, err := client.GetBestSnapshots(ctx, -1)
+		require.NoError(t, err)
+		require.Len(t, snaps, 1)
+		assert.Equal(t, sidecarURL.Host, snaps[0].Target)
+		assert.Equal(t, remoteURL.Host, snaps[0].Origin)
+	})
+
+	t.Run("LocalOnly", func(t *testing.T) {
+		local, _, err := client.ListSnapshots(ctx, &types.SnapshotQuery{LocalOnly: true})
+		require.NoError(t, err)
+		assert.Empty(t, local)
+	})
+}
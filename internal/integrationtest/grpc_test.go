@@ -0,0 +1,177 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtest
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/api/trackerpb"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/internal/tracker"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestGRPC queries snapshots scraped from a sidecar fleet through the gRPC API.
+func TestGRPC(t *testing.T) {
+	const sidecarCount = 3
+	var targets []string
+	for i := uint64(100); i < 100+sidecarCount; i++ {
+		server, _ := newSidecar(t, i)
+		defer server.Close()
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		targets = append(targets, u.Host)
+	}
+	group := &types.TargetGroup{
+		Group:         "test",
+		Scheme:        "http",
+		StaticTargets: &types.StaticTargets{Targets: targets},
+	}
+
+	// Scrape the fleet.
+	db := index.NewDB()
+	collector := scraper.NewCollector(db)
+	collector.Log = zaptest.NewLogger(t).Named("scraper")
+	collector.Start()
+	defer collector.Close()
+	prober, err := scraper.NewProber(group)
+	require.NoError(t, err)
+	scraper_ := scraper.NewScraper(prober, group.StaticTargets)
+	defer scraper_.Close()
+	scraper_.Start(collector.Probes(), 50*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return len(db.GetBestSnapshots(-1)) == sidecarCount
+	}, 5*time.Second, 25*time.Millisecond)
+	scraper_.Close()
+
+	// Serve the gRPC API with token auth.
+	authFile := filepath.Join(t.TempDir(), "auth.yml")
+	require.NoError(t, os.WriteFile(authFile, []byte(`
+tokens:
+  - name: fetcher
+    role: read
+    token: read-token
+`), 0o600))
+	auth := tracker.NewAuth(authFile, zaptest.NewLogger(t).Named("audit"))
+	require.NoError(t, auth.Reload())
+	handler := tracker.NewHandler(db)
+	handler.Cordons = tracker.NewCordons()
+	handler.WatchDebounce = 10 * time.Millisecond
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.UnaryInterceptor(types.RoleRead)),
+		grpc.ChainStreamInterceptor(auth.StreamInterceptor(types.RoleRead)),
+	)
+	trackerpb.RegisterTrackerServer(server, tracker.NewGRPCServer(handler))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := trackerpb.NewTrackerClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer read-token")
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := client.GetBestSnapshots(ctx, &trackerpb.GetBestSnapshotsRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		badCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer wrong")
+		stream, err := client.WatchBestSnapshots(badCtx, &trackerpb.GetBestSnapshotsRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("GetBestSnapshots", func(t *testing.T) {
+		res, err := client.GetBestSnapshots(authCtx, &trackerpb.GetBestSnapshotsRequest{Max: 10})
+		require.NoError(t, err)
+		var slots []uint64
+		for _, source := range res.Snapshots {
+			slots = append(slots, source.Info.Slot)
+			assert.Contains(t, targets, source.Target)
+			assert.NotNil(t, source.UpdatedAt)
+			require.Len(t, source.Info.Files, 1)
+			assert.Equal(t, source.Info.Hash, source.Info.Files[0].Hash)
+		}
+		assert.Equal(t, []uint64{102, 101, 100}, slots)
+
+		res, err = client.GetBestSnapshots(authCtx, &trackerpb.GetBestSnapshotsRequest{
+			Query: &trackerpb.SnapshotQuery{MaxSlot: 101},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Snapshots, 1)
+		assert.Equal(t, uint64(101), res.Snapshots[0].Info.Slot)
+
+		_, err = client.GetBestSnapshots(authCtx, &trackerpb.GetBestSnapshotsRequest{
+			Query: &trackerpb.SnapshotQuery{Kind: "bogus"},
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("ListSnapshots", func(t *testing.T) {
+		var slots []uint64
+		req := &trackerpb.ListSnapshotsRequest{Sort: types.SortSlotAsc, Limit: 2}
+		for {
+			res, err := client.ListSnapshots(authCtx, req)
+			require.NoError(t, err)
+			for _, entry := range res.Snapshots {
+				slots = append(slots, entry.Info.Slot)
+				assert.Contains(t, targets, entry.Target)
+			}
+			if res.NextCursor == "" {
+				break
+			}
+			req.Cursor = res.NextCursor
+		}
+		assert.Equal(t, []uint64{100, 101, 102}, slots)
+	})
+
+	t.Run("WatchBestSnapshots", func(t *testing.T) {
+		stream, err := client.WatchBestSnapshots(authCtx, &trackerpb.GetBestSnapshotsRequest{Max: 10})
+		require.NoError(t, err)
+		event, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Len(t, event.Snapshots, 3)
+		assert.Empty(t, event.Added)
+
+		// Cordoning the newest source removes its snapshot.
+		newest := event.Snapshots[0]
+		handler.Cordons.Add(types.Cordon{Target: newest.Target})
+		event, err = stream.Recv()
+		require.NoError(t, err)
+		assert.Len(t, event.Snapshots, 2)
+		require.Len(t, event.Removed, 1)
+		assert.Equal(t, newest.Info.Slot, event.Removed[0].Slot)
+		assert.Equal(t, newest.Info.Hash, event.Removed[0].Hash)
+	})
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/api"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/internal/openapi"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/internal/sidecar"
+	"go.blockdaemon.com/solana/cluster-manager/internal/slotmon"
+	"go.blockdaemon.com/solana/cluster-manager/internal/tracker"
+	"go.uber.org/zap/zaptest"
+)
+
+// specMiddleware fails the test on requests or responses violating the given OpenAPI spec.
+//
+// Installed on test servers, it checks both the server handlers and the clients talking to them.
+func specMiddleware(t *testing.T, spec []byte) gin.HandlerFunc {
+	parsed, err := openapi.Load(spec)
+	require.NoError(t, err)
+	return openapi.Middleware(parsed, func(err error) {
+		t.Error("OpenAPI violation:", err)
+	})
+}
+
+// TestOpenAPIRoutes checks that the specs document exactly the routes served.
+func TestOpenAPIRoutes(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	log := zaptest.NewLogger(t)
+
+	t.Run("Sidecar", func(t *testing.T) {
+		engine := gin.New()
+		groupV1 := engine.Group("/v1")
+		snapshotHandler := sidecar.NewSnapshotHandler(t.TempDir(), log)
+		snapshotHandler.RegisterHandlers(groupV1)
+		snapshotHandler.RegisterAdminHandlers(groupV1)
+		sidecar.NewConsensusHandler("ws://localhost:8900", log).RegisterHandlers(groupV1)
+		groupV1.GET("/openapi.json", api.Handler(api.Sidecar))
+		assertRoutes(t, api.Sidecar, engine)
+	})
+
+	t.Run("Tracker", func(t *testing.T) {
+		db := index.NewDB()
+		collector := scraper.NewCollector(db)
+		engine := gin.New()
+		groupV1 := engine.Group("/v1")
+		handler := tracker.NewHandler(db)
+		handler.RegisterHandlers(groupV1)
+		tracker.NewAdviceHandler(handler).RegisterHandlers(groupV1)
+		tracker.NewTargetsHandler(collector).RegisterHandlers(groupV1)
+		tracker.NewClusterHandler(slotmon.NewMonitor()).RegisterHandlers(groupV1)
+		tracker.NewAnnounceHandler(collector.Probes(), collector, log).RegisterHandlers(groupV1)
+		tracker.NewCordonHandler(tracker.NewCordons()).RegisterHandlers(groupV1)
+		tracker.NewAssignHandler(db, tracker.NewAssignments(time.Minute), log).RegisterHandlers(groupV1)
+		groupV1.GET("/openapi.json", api.Handler(api.Tracker))
+		assertRoutes(t, api.Tracker, engine)
+	})
+}
+
+func assertRoutes(t *testing.T, spec []byte, engine *gin.Engine) {
+	parsed, err := openapi.Load(spec)
+	require.NoError(t, err)
+	var routes []string
+	for _, route := range engine.Routes() {
+		segments := strings.Split(route.Path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = "{" + segment[1:] + "}"
+			}
+		}
+		routes = append(routes, route.Method+" "+strings.Join(segments, "/"))
+	}
+	sort.Strings(routes)
+	assert.Equal(t, parsed.Routes(), routes)
+
+	// The spec is served as is.
+	engine.Use(specMiddleware(t, spec))
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, server.URL+"/v1/openapi.json", nil)
+	require.NoError(t, err)
+	res, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var served map[string]any
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&served))
+	assert.Equal(t, parsed.OpenAPI, served["openapi"])
+}
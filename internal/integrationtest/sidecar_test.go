@@ -26,6 +26,7 @@ import (
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/api"
 	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
 	"go.blockdaemon.com/solana/cluster-manager/internal/ledgertest"
 	"go.blockdaemon.com/solana/cluster-manager/internal/sidecar"
@@ -93,6 +94,7 @@ func newSidecar(t *testing.T, slots ...uint64) (server *httptest.Server, root *l
 
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
+	engine.Use(specMiddleware(t, api.Sidecar))
 	handler.RegisterHandlers(engine.Group("/v1"))
 	server = httptest.NewServer(engine)
 	return
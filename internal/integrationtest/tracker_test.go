@@ -26,6 +26,7 @@ import (
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/api"
 	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
 	"go.blockdaemon.com/solana/cluster-manager/internal/index"
 	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
@@ -67,7 +68,7 @@ func TestTracker(t *testing.T) {
 	defer scraper_.Close()
 
 	// Create tracker server.
-	server := newTracker(db)
+	server := newTracker(t, db)
 	defer server.Close()
 
 	// Scrape for a while.
@@ -169,12 +170,43 @@ func TestTracker(t *testing.T) {
 			},
 		},
 		snaps)
+
+	// Filter by slot range and hash.
+	snaps, err = client.FindBestSnapshots(context.TODO(), -1, &types.SnapshotQuery{
+		MinSlot: 101,
+		Hash:    "7sAawX1cAHVpfZGNtUAYKX2KPzdd1uPUZUTaLteWX4SB",
+		Kind:    types.SnapshotKindFull,
+	})
+	require.NoError(t, err)
+	require.Len(t, snaps, 1)
+	assert.Equal(t, uint64(102), snaps[0].Slot)
+
+	// Page through all snapshots.
+	var slots []uint64
+	query := &types.SnapshotQuery{Sort: types.SortSlotAsc, Limit: 3}
+	for {
+		entries, next, err := client.ListSnapshots(context.TODO(), query)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			slots = append(slots, entry.Slot())
+		}
+		if next == "" {
+			break
+		}
+		query.Cursor = next
+	}
+	assert.Equal(t, []uint64{100, 101, 102, 103}, slots)
+
+	// Invalid queries are rejected.
+	_, _, err = client.ListSnapshots(context.TODO(), &types.SnapshotQuery{Sort: "foo"})
+	assert.EqualError(t, err, "list snapshots: 400 Bad Request")
 }
 
-func newTracker(db *index.DB) *httptest.Server {
+func newTracker(t *testing.T, db *index.DB) *httptest.Server {
 	handler := tracker.NewHandler(db)
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
+	engine.Use(specMiddleware(t, api.Tracker))
 	handler.RegisterHandlers(engine.Group("/v1"))
 	return httptest.NewServer(engine)
 }
@@ -0,0 +1,97 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrationtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap/zaptest"
+)
+
+// TestWatchBestSnapshots follows index changes through the tracker watch stream.
+func TestWatchBestSnapshots(t *testing.T) {
+	db := index.NewDB()
+	server := newTracker(t, db)
+	defer server.Close()
+
+	client := fetch.NewTrackerClientWithResty(resty.New().SetBaseURL(server.URL))
+	client.Log = zaptest.NewLogger(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	events := make(chan *types.BestSnapshotsEvent)
+	go func() {
+		_ = client.WatchBestSnapshots(ctx, 10, &types.SnapshotQuery{Group: "test"}, func(event *types.BestSnapshotsEvent) error {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+			return nil
+		})
+	}()
+	next := func() *types.BestSnapshotsEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-ctx.Done():
+			require.FailNow(t, "no event received")
+			return nil
+		}
+	}
+
+	// Initial state is empty.
+	event := next()
+	assert.Empty(t, event.Snapshots)
+
+	// New snapshot appears.
+	entry := &index.SnapshotEntry{
+		SnapshotKey: index.NewSnapshotKey("host1", 100),
+		Group:       "test",
+		UpdatedAt:   time.Now(),
+		Info: &types.SnapshotInfo{
+			Slot:  100,
+			Hash:  solana.Hash{0x01},
+			Files: []*types.SnapshotFile{},
+		},
+	}
+	db.UpsertSnapshots(entry)
+	event = next()
+	require.Len(t, event.Snapshots, 1)
+	assert.Equal(t, "host1", event.Snapshots[0].Target)
+	assert.Equal(t, []types.SnapshotID{{Slot: 100, Hash: solana.Hash{0x01}}}, event.Added)
+	assert.Empty(t, event.Removed)
+
+	// Snapshots of other groups are ignored.
+	other := *entry
+	other.SnapshotKey = index.NewSnapshotKey("host2", 101)
+	other.Group = "other"
+	db.UpsertSnapshots(&other)
+
+	// Snapshot loses its only source.
+	db.DeleteSnapshotsByTarget("host1")
+	event = next()
+	assert.Empty(t, event.Snapshots)
+	assert.Empty(t, event.Added)
+	assert.Equal(t, []types.SnapshotID{{Slot: 100, Hash: solana.Hash{0x01}}}, event.Removed)
+}
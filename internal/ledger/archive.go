@@ -0,0 +1,292 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/klauspost/compress/zstd"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+// ReadSnapshotMetadata reads the metadata of a snapshot archive in the ledger dir.
+func ReadSnapshotMetadata(ledgerDir fs.FS, file *types.SnapshotFile) (*types.SnapshotMetadata, error) {
+	f, err := ledgerDir.Open(file.FileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSnapshotMetadataFrom(f, file.Ext)
+}
+
+// ReadSnapshotMetadataFrom extracts metadata from a snapshot archive stream.
+//
+// Only the start of the archive is read:
+// The version file and the bank fields precede the (much larger) account storages.
+func ReadSnapshotMetadataFrom(rd io.Reader, ext string) (*types.SnapshotMetadata, error) {
+	tarRd, closer, err := openArchive(rd, ext)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	meta := new(types.SnapshotMetadata)
+	var haveVersion, haveBank bool
+	for !haveVersion || !haveBank {
+		hdr, err := tarRd.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		switch {
+		case hdr.Name == "version":
+			buf, err := io.ReadAll(io.LimitReader(tarRd, 64))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read version file: %w", err)
+			}
+			meta.Version = strings.TrimSpace(string(buf))
+			haveVersion = true
+		case isBankFileName(hdr.Name):
+			if err := readBankFields(bufio.NewReader(tarRd), meta); err != nil {
+				return nil, fmt.Errorf("failed to read bank fields: %w", err)
+			}
+			haveBank = true
+		case strings.HasPrefix(hdr.Name, "accounts/"):
+			// Metadata always precedes account storages.
+			if !haveBank {
+				return nil, fmt.Errorf("bank fields not found in archive")
+			}
+			return meta, nil
+		}
+	}
+	if !haveBank {
+		return nil, fmt.Errorf("bank fields not found in archive")
+	}
+	return meta, nil
+}
+
+func openArchive(rd io.Reader, ext string) (tarRd *tar.Reader, closer func(), err error) {
+	closer = func() {}
+	switch ext {
+	case ".tar":
+	case ".tar.bz2":
+		rd = bzip2.NewReader(rd)
+	case ".tar.gz":
+		gzipRd, err := gzip.NewReader(rd)
+		if err != nil {
+			return nil, nil, err
+		}
+		rd = gzipRd
+		closer = func() { _ = gzipRd.Close() }
+	case ".tar.zst":
+		zstdRd, err := zstd.NewReader(rd)
+		if err != nil {
+			return nil, nil, err
+		}
+		rd = zstdRd
+		closer = zstdRd.Close
+	default:
+		return nil, nil, fmt.Errorf("unsupported archive format: %s", ext)
+	}
+	return tar.NewReader(rd), closer, nil
+}
+
+// isBankFileName checks whether the name matches "snapshots/<slot>/<slot>".
+func isBankFileName(name string) bool {
+	parts := strings.Split(name, "/")
+	if len(parts) != 3 || parts[0] != "snapshots" || parts[1] != parts[2] {
+		return false
+	}
+	_, err := strconv.ParseUint(parts[1], 10, 64)
+	return err == nil
+}
+
+// readBankFields decodes the bincode-serialized bank and accounts DB fields.
+//
+// The accounts hash is located after the stakes, which can be large.
+// It is left empty if it cannot be decoded or is zero (in newer formats).
+func readBankFields(rd *bufio.Reader, meta *types.SnapshotMetadata) error {
+	b := bincodeReader{rd: rd}
+
+	// blockhash_queue
+	b.u64()                              // last_hash_index
+	b.option(func() { b.hash() })        // last_hash
+	b.mapOf(func() { b.skip(32 + 8*3) }) // ages: hash => (fee_calculator, hash_index, timestamp)
+	b.u64()                              // max_age
+
+	b.mapOf(func() { b.skip(8 + 8) }) // ancestors
+	b.hash()                          // hash
+	b.hash()                          // parent_hash
+	meta.ParentSlot = b.u64()
+	b.seqOf(func() { b.skip(8 + 8) }) // hard_forks
+	b.u64()                           // transaction_count
+	b.u64()                           // tick_height
+	b.u64()                           // signature_count
+	meta.Capitalization = b.u64()
+	b.u64()                      // max_tick_height
+	b.option(func() { b.u64() }) // hashes_per_tick
+	b.u64()                      // ticks_per_slot
+	b.skip(16)                   // ns_per_slot
+	b.u64()                      // genesis_creation_time
+	b.u64()                      // slots_per_year
+	b.u64()                      // accounts_data_len
+	b.u64()                      // slot
+	meta.Epoch = b.u64()
+	if b.err != nil {
+		return b.err
+	}
+
+	// Best effort from here on.
+	b.u64()                              // block_height
+	b.skip(32)                           // collector_id
+	b.u64()                              // collector_fees
+	b.u64()                              // fee_calculator
+	b.skip(8*4 + 1)                      // fee_rate_governor
+	b.u64()                              // collected_rent
+	b.skip(8 + epochSchedule + 8 + rent) // rent_collector
+	b.skip(epochSchedule)                // epoch_schedule
+	b.skip(8 * 6)                        // inflation
+	b.stakes()                           // stakes
+	b.seqOf(func() { b.skip(32) })       // unused_accounts.unused1
+	b.seqOf(func() { b.skip(32) })       // unused_accounts.unused2
+	b.mapOf(func() { b.skip(32 + 8) })   // unused_accounts.unused3
+	b.mapOf(func() {                     // epoch_stakes
+		b.u64() // epoch
+		b.stakes()
+		b.u64()          // total_stake
+		b.mapOf(func() { // node_id_to_vote_accounts
+			b.skip(32)
+			b.seqOf(func() { b.skip(32) })
+			b.u64()
+		})
+		b.mapOf(func() { b.skip(32 + 32) }) // epoch_authorized_voters
+	})
+	b.skip(1) // is_delta
+
+	// accounts_db_fields
+	b.mapOf(func() { // storages
+		b.u64()
+		b.seqOf(func() { b.skip(8 + 8) })
+	})
+	b.u64()  // write_version
+	b.u64()  // slot
+	b.hash() // accounts_delta_hash
+	accountsHash := b.hash()
+	if b.err == nil && !accountsHash.IsZero() {
+		meta.AccountsHash = &accountsHash
+	}
+	return nil
+}
+
+const (
+	epochSchedule = 8 + 8 + 1 + 8 + 8 // slots_per_epoch, leader_schedule_slot_offset, warmup, first_normal_epoch, first_normal_slot
+	rent          = 8 + 8 + 1         // lamports_per_byte_year, exemption_threshold, burn_percent
+)
+
+// maxBincodeLen caps collection lengths to detect corrupt input.
+const maxBincodeLen = 1 << 28
+
+// bincodeReader decodes Rust bincode (fixed-width little-endian integers).
+// The first error is sticky and turns all further reads into no-ops.
+type bincodeReader struct {
+	rd  *bufio.Reader
+	err error
+	buf [32]byte
+}
+
+func (b *bincodeReader) read(n int) []byte {
+	if b.err != nil {
+		return b.buf[:n]
+	}
+	_, b.err = io.ReadFull(b.rd, b.buf[:n])
+	return b.buf[:n]
+}
+
+func (b *bincodeReader) skip(n int64) {
+	if b.err != nil {
+		return
+	}
+	_, b.err = b.rd.Discard(int(n))
+}
+
+func (b *bincodeReader) u64() uint64 {
+	return binary.LittleEndian.Uint64(b.read(8))
+}
+
+func (b *bincodeReader) hash() (h solana.Hash) {
+	copy(h[:], b.read(32))
+	return
+}
+
+func (b *bincodeReader) length() uint64 {
+	n := b.u64()
+	if b.err == nil && n > maxBincodeLen {
+		b.err = fmt.Errorf("collection too large (%d)", n)
+	}
+	if b.err != nil {
+		return 0
+	}
+	return n
+}
+
+// option reads an Option<T> tag, calling value if present.
+func (b *bincodeReader) option(value func()) {
+	switch tag := b.read(1)[0]; {
+	case b.err != nil:
+	case tag == 1:
+		value()
+	case tag != 0:
+		b.err = fmt.Errorf("invalid option tag %d", tag)
+	}
+}
+
+// seqOf reads a length-prefixed sequence, calling elem for each element.
+func (b *bincodeReader) seqOf(elem func()) {
+	n := b.length()
+	for i := uint64(0); i < n && b.err == nil; i++ {
+		elem()
+	}
+}
+
+// mapOf reads a length-prefixed map, calling entry for each key-value pair.
+func (b *bincodeReader) mapOf(entry func()) {
+	b.seqOf(entry)
+}
+
+// stakes skips over a serialized Stakes<Delegation> object.
+func (b *bincodeReader) stakes() {
+	b.mapOf(func() { // vote_accounts
+		b.skip(32 + 8) // pubkey, stake
+		b.u64()        // lamports
+		b.skip(int64(b.length()))
+		b.skip(32 + 1 + 8) // owner, executable, rent_epoch
+	})
+	b.mapOf(func() { b.skip(32 + 32 + 8*4) }) // stake_delegations
+	b.u64()                                   // unused
+	b.u64()                                   // epoch
+	b.seqOf(func() { b.skip(8 + 8*3) })       // stake_history
+}
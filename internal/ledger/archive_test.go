@@ -0,0 +1,172 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+// bincodeWriter builds fake bank fields.
+type bincodeWriter struct{ bytes.Buffer }
+
+func (w *bincodeWriter) u64(v uint64) { _ = binary.Write(w, binary.LittleEndian, v) }
+func (w *bincodeWriter) zero(n int)   { w.Write(make([]byte, n)) }
+
+func (w *bincodeWriter) stakes() {
+	w.u64(1)           // vote_accounts
+	w.zero(32 + 8 + 8) // pubkey, stake, lamports
+	w.u64(3)           // data length
+	w.Write([]byte{1, 2, 3})
+	w.zero(32 + 1 + 8)    // owner, executable, rent_epoch
+	w.u64(1)              // stake_delegations
+	w.zero(32 + 32 + 8*4) // pubkey, delegation
+	w.u64(0)              // unused
+	w.u64(0)              // epoch
+	w.u64(1)              // stake_history
+	w.zero(8 + 8*3)       // entry
+}
+
+func fakeBankFields() []byte {
+	var w bincodeWriter
+	// blockhash_queue
+	w.u64(1)
+	w.WriteByte(1)
+	w.zero(32)
+	w.u64(1)
+	w.zero(32 + 8*3)
+	w.u64(300)
+	// bank fields
+	w.u64(0)        // ancestors
+	w.zero(32 + 32) // hash, parent_hash
+	w.u64(99)       // parent_slot
+	w.u64(1)        // hard_forks
+	w.zero(16)
+	w.zero(8 * 3)                  // transaction_count, tick_height, signature_count
+	w.u64(500_000)                 // capitalization
+	w.u64(0)                       // max_tick_height
+	w.WriteByte(0)                 // hashes_per_tick
+	w.zero(8 + 16 + 8 + 8 + 8 + 8) // ticks_per_slot .. slot
+	w.u64(7)                       // epoch
+	w.zero(8 + 32 + 8 + 8 + 33 + 8)
+	w.zero(8 + epochSchedule + 8 + rent + epochSchedule + 8*6)
+	w.stakes()
+	w.u64(0)
+	w.u64(0)
+	w.u64(0)
+	w.u64(1) // epoch_stakes
+	w.u64(7)
+	w.stakes()
+	w.u64(0)
+	w.u64(1) // node_id_to_vote_accounts
+	w.zero(32)
+	w.u64(1)
+	w.zero(32 + 8)
+	w.u64(0)       // epoch_authorized_voters
+	w.WriteByte(0) // is_delta
+	// accounts_db_fields
+	w.u64(1)
+	w.u64(100)
+	w.u64(1)
+	w.zero(16)
+	w.zero(8 + 8 + 32)
+	w.Write(bytes.Repeat([]byte{0x42}, 32)) // accounts_hash
+	w.zero(8 * 5)
+	return w.Bytes()
+}
+
+func fakeArchive(t *testing.T) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	addFile := func(name string, content []byte) {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+	}
+	addFile("snapshots/status_cache", []byte("junk"))
+	addFile("snapshots/100/100", fakeBankFields())
+	addFile("version", []byte("1.2.0\n"))
+	addFile("accounts/100.1", []byte("accounts"))
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func TestReadSnapshotMetadataFrom(t *testing.T) {
+	archive := fakeArchive(t)
+	accountsHash := solana.HashFromBytes(bytes.Repeat([]byte{0x42}, 32))
+	expected := &types.SnapshotMetadata{
+		Version:        "1.2.0",
+		Epoch:          7,
+		ParentSlot:     99,
+		Capitalization: 500_000,
+		AccountsHash:   &accountsHash,
+	}
+
+	compress := map[string]func(t *testing.T) []byte{
+		".tar": func(_ *testing.T) []byte { return archive },
+		".tar.gz": func(t *testing.T) []byte {
+			var buf bytes.Buffer
+			w := gzip.NewWriter(&buf)
+			_, err := w.Write(archive)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			return buf.Bytes()
+		},
+		".tar.zst": func(t *testing.T) []byte {
+			enc, err := zstd.NewWriter(nil)
+			require.NoError(t, err)
+			return enc.EncodeAll(archive, nil)
+		},
+	}
+	for ext, fn := range compress {
+		t.Run(ext, func(t *testing.T) {
+			meta, err := ReadSnapshotMetadataFrom(bytes.NewReader(fn(t)), ext)
+			require.NoError(t, err)
+			assert.Equal(t, expected, meta)
+		})
+	}
+
+	t.Run("Truncated", func(t *testing.T) {
+		_, err := ReadSnapshotMetadataFrom(io.LimitReader(bytes.NewReader(archive), 1024), ".tar")
+		assert.Error(t, err)
+	})
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := ReadSnapshotMetadataFrom(bytes.NewReader(archive), ".tar.xz")
+		assert.EqualError(t, err, "unsupported archive format: .tar.xz")
+	})
+}
+
+func TestIsBankFileName(t *testing.T) {
+	assert.True(t, isBankFileName("snapshots/100/100"))
+	assert.False(t, isBankFileName("snapshots/100/101"))
+	assert.False(t, isBankFileName("snapshots/status_cache"))
+	assert.False(t, isBankFileName("snapshots/100/100.pre"))
+	assert.False(t, isBankFileName("snapshots/abc/abc"))
+}
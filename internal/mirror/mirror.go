@@ -24,6 +24,7 @@ import (
 	"github.com/minio/minio-go/v7"
 	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
 	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 )
 
@@ -35,6 +36,7 @@ type Worker struct {
 
 	Refresh   time.Duration
 	SyncCount int
+	Watch     bool // follow the tracker's change stream instead of polling
 }
 
 func NewWorker(tracker *fetch.TrackerClient, uploader *Uploader) *Worker {
@@ -51,6 +53,10 @@ func (w *Worker) Run(ctx context.Context) {
 	w.Log.Info("Worker starting")
 	defer w.Log.Info("Worker stopped")
 
+	if w.Watch {
+		w.watch(ctx)
+		return
+	}
 	ticker := time.NewTicker(w.Refresh)
 	defer ticker.Stop()
 	for {
@@ -63,6 +69,57 @@ func (w *Worker) Run(ctx context.Context) {
 	}
 }
 
+// minRetryWait is the delay before retrying a failed sync in watch mode.
+// It doubles after each failure, up to the refresh interval.
+const minRetryWait = time.Second
+
+// watch syncs whenever the tracker reports a change.
+// Events arriving during a sync are coalesced, only the latest ranking is synced next.
+// A failed sync is retried with exponential backoff, capped at Refresh, until it succeeds.
+func (w *Worker) watch(ctx context.Context) {
+	latest := make(chan []types.SnapshotSource, 1)
+	go func() {
+		_ = w.Tracker.WatchBestSnapshots(ctx, w.SyncCount, nil, func(event *types.BestSnapshotsEvent) error {
+			select {
+			case <-latest:
+			default:
+			}
+			latest <- event.Snapshots
+			return nil
+		})
+	}()
+	var (
+		sources []types.SnapshotSource
+		retry   <-chan time.Time
+		wait    time.Duration
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sources = <-latest:
+			w.Log.Debug("Ranking changed")
+			wait = 0
+		case <-retry:
+			w.Log.Debug("Retrying failed sync")
+		}
+		retry = nil
+		if w.sync(ctx, sources) || ctx.Err() != nil {
+			wait = 0
+			continue
+		}
+		wait *= 2
+		if wait < minRetryWait {
+			wait = minRetryWait
+		}
+		if wait > w.Refresh {
+			wait = w.Refresh
+		}
+		w.Log.Warn("Sync failed, retrying", zap.Duration("wait", wait))
+		retry = time.After(wait)
+	}
+}
+
 func (w *Worker) tick(ctx context.Context) {
 	w.Log.Debug("Tick")
 	sources, err := w.Tracker.GetBestSnapshots(ctx, w.SyncCount)
@@ -70,7 +127,12 @@ func (w *Worker) tick(ctx context.Context) {
 		w.Log.Error("Failed to find new snapshots", zap.Error(err))
 		return
 	}
+	w.sync(ctx, sources)
+}
 
+// sync uploads all files of the given sources that are missing in S3.
+// Returns whether all files are now present.
+func (w *Worker) sync(ctx context.Context, sources []types.SnapshotSource) bool {
 	type fileSource struct {
 		target string
 		file   *types.SnapshotFile
@@ -88,6 +150,7 @@ func (w *Worker) tick(ctx context.Context) {
 	}
 
 	var wg sync.WaitGroup
+	var failed atomic.Bool
 	for _, src := range files {
 		// TODO Consider using a semaphore
 		job := UploadJob{
@@ -99,10 +162,13 @@ func (w *Worker) tick(ctx context.Context) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			job.Run(ctx)
+			if err := job.Run(ctx); err != nil {
+				failed.Store(true)
+			}
 		}()
 	}
 	wg.Wait()
+	return !failed.Load()
 }
 
 type UploadJob struct {
@@ -112,19 +178,21 @@ type UploadJob struct {
 	Log      *zap.Logger
 }
 
-func (j *UploadJob) Run(ctx context.Context) {
+// Run uploads the file unless it already exists in S3.
+// Errors are logged and returned.
+func (j *UploadJob) Run(ctx context.Context) error {
 	fileName := j.File.FileName
 	// Check if snapshot exists.
 	stat, statErr := j.Uploader.StatSnapshot(ctx, fileName)
 	if statErr == nil {
 		j.Log.Debug("Already uploaded",
 			zap.Time("last_modified", stat.LastModified))
-		return
+		return nil
 	}
 	statResp := minio.ToErrorResponse(statErr)
 	if statResp.StatusCode != http.StatusNotFound {
 		j.Log.Error("Unexpected error", zap.Error(statErr))
-		return
+		return statErr
 	}
 
 	// TODO use client factory
@@ -139,10 +207,11 @@ func (j *UploadJob) Run(ctx context.Context) {
 	if err != nil {
 		j.Log.Error("Upload failed", zap.Error(err),
 			zap.Duration("upload_duration", uploadDuration))
-		return
+		return err
 	}
 	j.Log.Info("Upload succeeded",
 		zap.String("bucket", uploadInfo.Bucket),
 		zap.String("object", uploadInfo.Key),
 		zap.Duration("upload_duration", uploadDuration))
+	return nil
 }
@@ -0,0 +1,168 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+// defaultMaxFailures is the target_failing threshold if the rule does not set one.
+const defaultMaxFailures = 3
+
+func matchGroup(rule *types.WebhookRule, group string) bool {
+	return rule.Group == "" || rule.Group == group
+}
+
+// newBestFull returns the newest full snapshot of each group.
+func newBestFull(rule *types.WebhookRule, entries []*index.SnapshotEntry) []types.WebhookEvent {
+	best := make(map[string]*index.SnapshotEntry)
+	for _, entry := range entries {
+		if !matchGroup(rule, entry.Group) || !entry.Info.IsFull() {
+			continue
+		}
+		if prev, ok := best[entry.Group]; !ok || entry.Slot() > prev.Slot() {
+			best[entry.Group] = entry
+		}
+	}
+	events := make([]types.WebhookEvent, 0, len(best))
+	for group, entry := range best {
+		events = append(events, types.WebhookEvent{
+			ID:      fmt.Sprintf("%s/%s/%d", rule.Name, group, entry.Slot()),
+			Group:   group,
+			Target:  entry.Target,
+			Slot:    entry.Slot(),
+			Hashes:  []solana.Hash{entry.Info.Hash},
+			Summary: fmt.Sprintf("New full snapshot at slot %d in group %s", entry.Slot(), group),
+		})
+	}
+	return events
+}
+
+// snapshotAge fires for groups whose newest snapshot is older than max_age or that have no snapshots.
+// Groups without snapshots are skipped until one of their targets has been scraped,
+// so a freshly started tracker does not fire for all of them.
+func snapshotAge(rule *types.WebhookRule, groups []string, entries []*index.SnapshotEntry, targets []types.TargetStatus, now time.Time) []types.WebhookEvent {
+	newest := make(map[string]*index.SnapshotEntry)
+	for _, entry := range entries {
+		if prev, ok := newest[entry.Group]; !ok || entry.Slot() > prev.Slot() {
+			newest[entry.Group] = entry
+		}
+	}
+	scraped := make(map[string]bool)
+	for _, target := range targets {
+		if !target.LastScrape.IsZero() {
+			scraped[target.Group] = true
+		}
+	}
+	var events []types.WebhookEvent
+	for _, group := range groups {
+		if !matchGroup(rule, group) {
+			continue
+		}
+		event := types.WebhookEvent{
+			ID:    rule.Name + "/" + group,
+			Group: group,
+		}
+		entry, ok := newest[group]
+		if !ok {
+			if !scraped[group] {
+				continue
+			}
+			event.Summary = fmt.Sprintf("Group %s has no snapshots", group)
+			events = append(events, event)
+			continue
+		}
+		age := now.Sub(entry.CreatedAt())
+		if age <= rule.MaxAge {
+			continue
+		}
+		event.Target = entry.Target
+		event.Slot = entry.Slot()
+		event.Summary = fmt.Sprintf("Newest snapshot of group %s at slot %d is %s old (max %s)",
+			group, entry.Slot(), age.Truncate(time.Second), rule.MaxAge)
+		events = append(events, event)
+	}
+	return events
+}
+
+// hashConflicts fires for snapshots of the same slot and base slot with different hashes within a group.
+func hashConflicts(rule *types.WebhookRule, entries []*index.SnapshotEntry) []types.WebhookEvent {
+	type key struct {
+		group          string
+		slot, baseSlot uint64
+	}
+	hashes := make(map[key]map[solana.Hash]struct{})
+	for _, entry := range entries {
+		if !matchGroup(rule, entry.Group) {
+			continue
+		}
+		k := key{entry.Group, entry.Slot(), entry.Info.BaseSlot()}
+		if hashes[k] == nil {
+			hashes[k] = make(map[solana.Hash]struct{})
+		}
+		hashes[k][entry.Info.Hash] = struct{}{}
+	}
+	var events []types.WebhookEvent
+	for k, set := range hashes {
+		if len(set) < 2 {
+			continue
+		}
+		list := make([]solana.Hash, 0, len(set))
+		for hash := range set {
+			list = append(list, hash)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return bytes.Compare(list[i][:], list[j][:]) < 0
+		})
+		events = append(events, types.WebhookEvent{
+			ID:       fmt.Sprintf("%s/%s/%d/%d", rule.Name, k.group, k.slot, k.baseSlot),
+			Group:    k.group,
+			Slot:     k.slot,
+			BaseSlot: k.baseSlot,
+			Hashes:   list,
+			Summary:  fmt.Sprintf("Targets of group %s report %d different hashes for slot %d", k.group, len(list), k.slot),
+		})
+	}
+	return events
+}
+
+// targetFailing fires for targets that failed max_failures scrapes in a row.
+func targetFailing(rule *types.WebhookRule, targets []types.TargetStatus) []types.WebhookEvent {
+	maxFailures := rule.MaxFailures
+	if maxFailures == 0 {
+		maxFailures = defaultMaxFailures
+	}
+	var events []types.WebhookEvent
+	for _, target := range targets {
+		if !matchGroup(rule, target.Group) || target.ConsecutiveFailures < maxFailures {
+			continue
+		}
+		events = append(events, types.WebhookEvent{
+			ID:     rule.Name + "/" + target.Group + "/" + target.Target,
+			Group:  target.Group,
+			Target: target.Target,
+			Summary: fmt.Sprintf("Target %s of group %s failed %d scrapes in a row: %s",
+				target.Target, target.Group, target.ConsecutiveFailures, target.LastError),
+		})
+	}
+	return events
+}
@@ -0,0 +1,158 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap"
+)
+
+// maxRetryWait caps the exponential backoff between delivery attempts.
+const maxRetryWait = time.Minute
+
+// slackMessage is the payload of Slack incoming webhooks.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// pagerDutyEvent is the payload of the PagerDuty Events API v2.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"` // trigger or resolve
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string              `json:"summary"`
+	Source        string              `json:"source"`
+	Severity      string              `json:"severity"`
+	Timestamp     time.Time           `json:"timestamp"`
+	Component     string              `json:"component,omitempty"`
+	Group         string              `json:"group,omitempty"`
+	Class         string              `json:"class,omitempty"`
+	CustomDetails *types.WebhookEvent `json:"custom_details,omitempty"`
+}
+
+// payload encodes an event in the format of the rule.
+func payload(rule *types.WebhookRule, event *types.WebhookEvent) (url string, body any) {
+	switch rule.Format {
+	case types.WebhookFormatSlack:
+		return rule.URL, &slackMessage{
+			Text: fmt.Sprintf("[%s] %s: %s", strings.ToUpper(event.Status), rule.Name, event.Summary),
+		}
+	case types.WebhookFormatPagerDuty:
+		url = rule.URL
+		if url == "" {
+			url = types.PagerDutyEventsURL
+		}
+		action := "trigger"
+		if event.Status == types.WebhookResolved {
+			action = "resolve"
+		}
+		severity := rule.Severity
+		if severity == "" {
+			severity = "warning"
+		}
+		source := event.Group
+		if event.Target != "" {
+			source = event.Target
+		}
+		return url, &pagerDutyEvent{
+			RoutingKey:  rule.RoutingKey,
+			EventAction: action,
+			DedupKey:    event.ID,
+			Payload: &pagerDutyPayload{
+				Summary:       event.Summary,
+				Source:        source,
+				Severity:      severity,
+				Timestamp:     event.Time,
+				Component:     event.Target,
+				Group:         event.Group,
+				Class:         event.Condition,
+				CustomDetails: event,
+			},
+		}
+	default:
+		return rule.URL, event
+	}
+}
+
+// send delivers queued events of a rule until the queue is closed.
+func (n *Notifier) send(queue <-chan delivery) {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case d, ok := <-queue:
+			if !ok {
+				return
+			}
+			n.deliver(d)
+		}
+	}
+}
+
+// deliver posts an event, retrying with exponential backoff.
+func (n *Notifier) deliver(d delivery) {
+	log := n.Log.With(zap.String("rule", d.rule.Name), zap.String("id", d.event.ID))
+	wait := n.RetryWait
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(d)
+		if err == nil {
+			deliveries.WithLabelValues(d.rule.Name, "success").Inc()
+			return
+		}
+		if !retry || attempt >= n.MaxAttempts {
+			deliveries.WithLabelValues(d.rule.Name, "failure").Inc()
+			log.Warn("Failed to deliver webhook", zap.Int("attempts", attempt), zap.Error(err))
+			return
+		}
+		log.Debug("Retrying webhook", zap.Int("attempt", attempt), zap.Error(err))
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > maxRetryWait {
+			wait = maxRetryWait
+		}
+	}
+}
+
+// post makes a single delivery attempt and reports whether a failure is worth retrying.
+func (n *Notifier) post(d delivery) (retry bool, err error) {
+	url, body := payload(d.rule, &d.event)
+	res, err := n.client.R().
+		SetContext(n.ctx).
+		SetHeaders(d.rule.Headers).
+		SetBody(body).
+		Post(url)
+	if err != nil {
+		return true, err
+	}
+	if !res.IsSuccess() {
+		code := res.StatusCode()
+		retry = code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout
+		return retry, fmt.Errorf("webhook returned %s", res.Status())
+	}
+	return false, nil
+}
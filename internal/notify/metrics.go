@@ -0,0 +1,27 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var deliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "solana_cluster",
+	Subsystem: "tracker",
+	Name:      "webhook_deliveries_total",
+	Help:      "Number of webhook events by rule and result (success, failure, dropped)",
+}, []string{"rule", "result"})
@@ -0,0 +1,223 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notify sends webhooks when snapshot and target conditions change.
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap"
+)
+
+// Notifier periodically evaluates the webhook rules of the config
+// and delivers an event whenever a condition starts or stops firing.
+//
+// Conditions that keep firing are not sent again, except new_best_full,
+// which fires once per newer full snapshot of a group.
+type Notifier struct {
+	DB          *index.DB
+	Targets     func() []types.TargetStatus
+	Log         *zap.Logger
+	Interval    time.Duration        // evaluation interval
+	MaxAttempts int                  // delivery attempts per event
+	RetryWait   time.Duration        // wait before the first retry, doubled after each attempt
+	QueueSize   int                  // max events queued per rule before dropping
+	Filter      func(id string) bool // sends only events passing the filter, e.g. owned by this replica
+
+	client *resty.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	lock    sync.Mutex
+	groups  []string
+	rules   []*ruleState
+	senders map[string]chan delivery // rule name => queue
+}
+
+// ruleState remembers what a rule fired.
+type ruleState struct {
+	rule   *types.WebhookRule
+	primed bool                          // evaluated at least once
+	active map[string]types.WebhookEvent // ID => firing event
+	best   map[string]uint64             // group => newest full slot seen
+}
+
+type delivery struct {
+	rule  *types.WebhookRule
+	event types.WebhookEvent
+}
+
+// NewNotifier creates a notifier evaluating the snapshots of db and the targets returned by the func.
+func NewNotifier(db *index.DB, targets func() []types.TargetStatus) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Notifier{
+		DB:          db,
+		Targets:     targets,
+		Log:         zap.NewNop(),
+		Interval:    15 * time.Second,
+		MaxAttempts: 5,
+		RetryWait:   time.Second,
+		QueueSize:   256,
+		client:      resty.New().SetTimeout(10 * time.Second),
+		ctx:         ctx,
+		cancel:      cancel,
+		senders:     make(map[string]chan delivery),
+	}
+}
+
+// Configure replaces the webhook rules.
+//
+// Rules keep their state unless their condition or group changed,
+// so reloading the config or editing thresholds and delivery settings does not repeat events.
+func (n *Notifier) Configure(conf *types.Config) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.groups = n.groups[:0]
+	for _, group := range conf.TargetGroups {
+		n.groups = append(n.groups, group.Group)
+	}
+	old := make(map[string]*ruleState, len(n.rules))
+	for _, state := range n.rules {
+		old[state.rule.Name] = state
+	}
+	n.rules = make([]*ruleState, 0, len(conf.Webhooks))
+	names := make(map[string]struct{}, len(conf.Webhooks))
+	for _, rule := range conf.Webhooks {
+		names[rule.Name] = struct{}{}
+		state, ok := old[rule.Name]
+		if !ok || state.rule.Condition != rule.Condition || state.rule.Group != rule.Group {
+			state = &ruleState{
+				active: make(map[string]types.WebhookEvent),
+				best:   make(map[string]uint64),
+			}
+		}
+		state.rule = rule
+		n.rules = append(n.rules, state)
+		if _, ok := n.senders[rule.Name]; !ok {
+			queue := make(chan delivery, n.QueueSize)
+			n.senders[rule.Name] = queue
+			n.wg.Add(1)
+			go n.send(queue)
+		}
+	}
+	for name, queue := range n.senders {
+		if _, ok := names[name]; !ok {
+			close(queue)
+			delete(n.senders, name)
+		}
+	}
+}
+
+// Start launches the evaluation loop.
+func (n *Notifier) Start() {
+	n.wg.Add(1)
+	go n.run()
+}
+
+// Close stops all goroutines, dropping queued events.
+func (n *Notifier) Close() {
+	n.cancel()
+	n.wg.Wait()
+}
+
+func (n *Notifier) run() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case now := <-ticker.C:
+			n.evaluate(now)
+		}
+	}
+}
+
+// evaluate checks all rules and enqueues events of changed conditions.
+func (n *Notifier) evaluate(now time.Time) {
+	entries := n.DB.GetAllSnapshots()
+	targets := n.Targets()
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	for _, state := range n.rules {
+		rule := state.rule
+		switch rule.Condition {
+		case types.ConditionNewBestFull:
+			for _, event := range newBestFull(rule, entries) {
+				prev, ok := state.best[event.Group]
+				if ok && event.Slot <= prev {
+					continue
+				}
+				state.best[event.Group] = event.Slot
+				if state.primed {
+					n.enqueue(rule, event, types.WebhookFiring, now)
+				}
+			}
+		case types.ConditionSnapshotAge:
+			n.transition(state, snapshotAge(rule, n.groups, entries, targets, now), now)
+		case types.ConditionHashConflict:
+			n.transition(state, hashConflicts(rule, entries), now)
+		case types.ConditionTargetFailing:
+			n.transition(state, targetFailing(rule, targets), now)
+		}
+		state.primed = true
+	}
+}
+
+// transition sends newly firing and resolved events of a state-based condition.
+func (n *Notifier) transition(state *ruleState, firing []types.WebhookEvent, now time.Time) {
+	current := make(map[string]types.WebhookEvent, len(firing))
+	for _, event := range firing {
+		current[event.ID] = event
+		if _, ok := state.active[event.ID]; !ok {
+			n.enqueue(state.rule, event, types.WebhookFiring, now)
+		}
+	}
+	for id, event := range state.active {
+		if _, ok := current[id]; !ok {
+			n.enqueue(state.rule, event, types.WebhookResolved, now)
+		}
+	}
+	state.active = current
+}
+
+func (n *Notifier) enqueue(rule *types.WebhookRule, event types.WebhookEvent, status string, now time.Time) {
+	event.Rule = rule.Name
+	event.Condition = rule.Condition
+	event.Status = status
+	event.Time = now
+	if n.Filter != nil && !n.Filter(event.ID) {
+		return
+	}
+	n.Log.Info("Webhook event",
+		zap.String("rule", rule.Name),
+		zap.String("id", event.ID),
+		zap.String("status", status),
+		zap.String("summary", event.Summary))
+	select {
+	case n.senders[rule.Name] <- delivery{rule: rule, event: event}:
+	default:
+		deliveries.WithLabelValues(rule.Name, "dropped").Inc()
+		n.Log.Warn("Webhook queue full, dropping event",
+			zap.String("rule", rule.Name), zap.String("id", event.ID))
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap/zaptest"
+)
+
+var dummyTime = time.Date(2022, 4, 27, 15, 33, 20, 0, time.UTC)
+
+// receiver records webhook bodies, failing the first failures requests.
+type receiver struct {
+	*httptest.Server
+	lock     sync.Mutex
+	failures int
+	bodies   chan []byte
+}
+
+func newReceiver(t *testing.T, failures int) *receiver {
+	r := &receiver{failures: failures, bodies: make(chan []byte, 16)}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "secret", req.Header.Get("X-Token"))
+		r.lock.Lock()
+		fail := r.failures > 0
+		r.failures--
+		r.lock.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		r.bodies <- body
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// next decodes the next webhook body into v.
+func (r *receiver) next(t *testing.T, v any) {
+	select {
+	case body := <-r.bodies:
+		require.NoError(t, json.Unmarshal(body, v))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no webhook received")
+	}
+}
+
+// none asserts that no further webhook is pending.
+func (r *receiver) none(t *testing.T) {
+	select {
+	case body := <-r.bodies:
+		t.Errorf("unexpected webhook: %s", body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newTestNotifier(t *testing.T, targets *[]types.TargetStatus, rules ...*types.WebhookRule) (*Notifier, *index.DB) {
+	db := index.NewDB()
+	n := NewNotifier(db, func() []types.TargetStatus { return *targets })
+	n.Log = zaptest.NewLogger(t)
+	n.RetryWait = time.Millisecond
+	n.Configure(&types.Config{
+		TargetGroups: []*types.TargetGroup{{Group: "test"}},
+		Webhooks:     rules,
+	})
+	t.Cleanup(n.Close)
+	return n, db
+}
+
+func entry(target string, slot, baseSlot uint64, hash byte) *index.SnapshotEntry {
+	return &index.SnapshotEntry{
+		SnapshotKey: index.NewSnapshotKey(target, slot),
+		Group:       "test",
+		UpdatedAt:   dummyTime,
+		Info: &types.SnapshotInfo{
+			Slot:  slot,
+			Hash:  solana.Hash{hash},
+			Files: []*types.SnapshotFile{{Slot: slot, BaseSlot: baseSlot}},
+		},
+	}
+}
+
+func TestNotifier_TargetFailing(t *testing.T) {
+	recv := newReceiver(t, 2)
+	targets := []types.TargetStatus{{Group: "test", Target: "host1", ConsecutiveFailures: 2}}
+	n, _ := newTestNotifier(t, &targets, &types.WebhookRule{
+		Name:      "failing",
+		Condition: types.ConditionTargetFailing,
+		URL:       recv.URL,
+		Headers:   map[string]string{"X-Token": "secret"},
+	})
+
+	n.evaluate(dummyTime)
+	recv.none(t)
+
+	// Fires once, retried until delivered.
+	targets[0].ConsecutiveFailures = 3
+	targets[0].LastError = "connection refused"
+	n.evaluate(dummyTime.Add(time.Minute))
+	n.evaluate(dummyTime.Add(2 * time.Minute))
+	var event types.WebhookEvent
+	recv.next(t, &event)
+	assert.Equal(t, types.WebhookEvent{
+		ID:        "failing/test/host1",
+		Rule:      "failing",
+		Condition: types.ConditionTargetFailing,
+		Status:    types.WebhookFiring,
+		Group:     "test",
+		Target:    "host1",
+		Summary:   "Target host1 of group test failed 3 scrapes in a row: connection refused",
+		Time:      dummyTime.Add(time.Minute),
+	}, event)
+	recv.none(t)
+
+	// Resolves with the same ID.
+	targets[0].ConsecutiveFailures = 0
+	n.evaluate(dummyTime.Add(3 * time.Minute))
+	recv.next(t, &event)
+	assert.Equal(t, "failing/test/host1", event.ID)
+	assert.Equal(t, types.WebhookResolved, event.Status)
+	recv.none(t)
+}
+
+func TestNotifier_NewBestFull(t *testing.T) {
+	recv := newReceiver(t, 0)
+	var targets []types.TargetStatus
+	n, db := newTestNotifier(t, &targets, &types.WebhookRule{
+		Name:      "mirror",
+		Condition: types.ConditionNewBestFull,
+		URL:       recv.URL,
+		Format:    types.WebhookFormatSlack,
+		Headers:   map[string]string{"X-Token": "secret"},
+	})
+
+	// The first evaluation only records the current state.
+	db.UpsertSnapshots(entry("host1", 100, 0, 1))
+	n.evaluate(dummyTime)
+	recv.none(t)
+
+	// Incremental and older snapshots do not fire.
+	db.UpsertSnapshots(entry("host1", 150, 100, 2), entry("host2", 90, 0, 3))
+	n.evaluate(dummyTime.Add(time.Minute))
+	recv.none(t)
+
+	db.UpsertSnapshots(entry("host2", 200, 0, 4))
+	n.evaluate(dummyTime.Add(2 * time.Minute))
+	n.evaluate(dummyTime.Add(3 * time.Minute))
+	var msg slackMessage
+	recv.next(t, &msg)
+	assert.Equal(t, "[FIRING] mirror: New full snapshot at slot 200 in group test", msg.Text)
+	recv.none(t)
+
+	// Unchanged rules keep their state across reloads.
+	n.Configure(&types.Config{
+		TargetGroups: []*types.TargetGroup{{Group: "test"}},
+		Webhooks:     []*types.WebhookRule{n.rules[0].rule},
+	})
+	n.evaluate(dummyTime.Add(4 * time.Minute))
+	recv.none(t)
+}
+
+func TestNotifier_HashConflict(t *testing.T) {
+	recv := newReceiver(t, 0)
+	var targets []types.TargetStatus
+	n, db := newTestNotifier(t, &targets, &types.WebhookRule{
+		Name:       "conflict",
+		Condition:  types.ConditionHashConflict,
+		URL:        recv.URL,
+		Format:     types.WebhookFormatPagerDuty,
+		RoutingKey: "key",
+		Headers:    map[string]string{"X-Token": "secret"},
+	})
+
+	db.UpsertSnapshots(entry("host1", 100, 0, 1), entry("host2", 100, 0, 2), entry("host3", 100, 0, 1))
+	n.evaluate(dummyTime)
+	var event pagerDutyEvent
+	recv.next(t, &event)
+	assert.Equal(t, "key", event.RoutingKey)
+	assert.Equal(t, "trigger", event.EventAction)
+	assert.Equal(t, "conflict/test/100/0", event.DedupKey)
+	require.NotNil(t, event.Payload)
+	assert.Equal(t, "Targets of group test report 2 different hashes for slot 100", event.Payload.Summary)
+	assert.Equal(t, "warning", event.Payload.Severity)
+	assert.Equal(t, "test", event.Payload.Source)
+	assert.Equal(t, []solana.Hash{{1}, {2}}, event.Payload.CustomDetails.Hashes)
+
+	db.DeleteSnapshotsByTarget("host2")
+	n.evaluate(dummyTime.Add(time.Minute))
+	recv.next(t, &event)
+	assert.Equal(t, "resolve", event.EventAction)
+	assert.Equal(t, "conflict/test/100/0", event.DedupKey)
+	recv.none(t)
+}
+
+func TestNotifier_SnapshotAge(t *testing.T) {
+	recv := newReceiver(t, 0)
+	var targets []types.TargetStatus
+	n, db := newTestNotifier(t, &targets, &types.WebhookRule{
+		Name:      "stale",
+		Condition: types.ConditionSnapshotAge,
+		Group:     "test",
+		MaxAge:    time.Hour,
+		URL:       recv.URL,
+		Headers:   map[string]string{"X-Token": "secret"},
+	})
+	// Only the replica owning an event sends it.
+	var owned bool
+	n.Filter = func(string) bool { return owned }
+
+	// Groups that have not been scraped yet do not fire.
+	targets = []types.TargetStatus{{Group: "test", Target: "host1"}}
+	n.evaluate(dummyTime)
+	assert.Empty(t, n.rules[0].active)
+
+	targets[0].LastScrape = dummyTime
+	n.evaluate(dummyTime)
+	assert.Len(t, n.rules[0].active, 1)
+	recv.none(t)
+
+	owned = true
+	db.UpsertSnapshots(entry("host1", 100, 0, 1))
+	n.evaluate(dummyTime.Add(30 * time.Minute))
+	var event types.WebhookEvent
+	recv.next(t, &event)
+	assert.Equal(t, types.WebhookResolved, event.Status)
+	assert.Equal(t, "Group test has no snapshots", event.Summary)
+
+	n.evaluate(dummyTime.Add(2 * time.Hour))
+	recv.next(t, &event)
+	assert.Equal(t, types.WebhookFiring, event.Status)
+	assert.Equal(t, uint64(100), event.Slot)
+	assert.Equal(t, "Newest snapshot of group test at slot 100 is 2h0m0s old (max 1h0m0s)", event.Summary)
+	recv.none(t)
+
+	// Editing the rule keeps firing events active.
+	edited := *n.rules[0].rule
+	edited.MaxAge = 90 * time.Minute
+	n.Configure(&types.Config{
+		TargetGroups: []*types.TargetGroup{{Group: "test"}},
+		Webhooks:     []*types.WebhookRule{&edited},
+	})
+	n.evaluate(dummyTime.Add(2*time.Hour + time.Minute))
+	recv.none(t)
+
+	// Changing its group starts over.
+	regrouped := edited
+	regrouped.Group = ""
+	n.Configure(&types.Config{
+		TargetGroups: []*types.TargetGroup{{Group: "test"}},
+		Webhooks:     []*types.WebhookRule{&regrouped},
+	})
+	n.evaluate(dummyTime.Add(2*time.Hour + 2*time.Minute))
+	recv.next(t, &event)
+	assert.Equal(t, types.WebhookFiring, event.Status)
+	recv.none(t)
+}
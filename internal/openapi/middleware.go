@@ -0,0 +1,88 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware checks all requests and responses passing through a Gin server against the spec.
+//
+// Violations are passed to report after the request completed.
+// Requests violating the spec are only reported if the server did not reject them.
+// Intended for tests, as bodies of JSON and event stream responses are buffered in memory.
+func Middleware(spec *Spec, report func(error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := c.Request
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			if err != nil {
+				c.AbortWithError(400, err)
+				return
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		reqErr := spec.ValidateRequest(req, body)
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		// Invalid requests are fine as long as the server rejects them.
+		if reqErr != nil && writer.Status() < 400 {
+			report(fmt.Errorf("%s %s: accepted invalid request: %w", req.Method, req.URL, reqErr))
+		}
+		if err := spec.ValidateResponse(req, writer.Status(), writer.Header(), writer.body.Bytes()); err != nil {
+			report(fmt.Errorf("%s %s: %w", req.Method, req.URL, err))
+		}
+	}
+}
+
+// recordingWriter keeps a copy of structured response bodies.
+// Other bodies, like snapshot downloads, are only recorded up to the first byte,
+// which is enough to check that a body was sent.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) record(data []byte) {
+	contentType, _, _ := mime.ParseMediaType(w.Header().Get("content-type"))
+	switch contentType {
+	case "application/json", "text/event-stream":
+		w.body.Write(data)
+	default:
+		if w.body.Len() == 0 && len(data) > 0 {
+			w.body.WriteByte(data[0])
+		}
+	}
+}
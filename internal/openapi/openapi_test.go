@@ -0,0 +1,149 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `{
+  "openapi": "3.0.3",
+  "paths": {
+    "/v1/items": {
+      "get": {
+        "operationId": "listItems",
+        "parameters": [{"name": "min", "in": "query", "schema": {"type": "integer", "format": "uint64"}}],
+        "responses": {
+          "200": {"content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Item"}}}}},
+          "400": {"content": {"text/plain": {"schema": {"type": "string"}}}}
+        }
+      }
+    },
+    "/v1/items/{id}": {
+      "get": {
+        "operationId": "getItem",
+        "responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}}}
+      }
+    },
+    "/v1/items/latest": {
+      "get": {
+        "operationId": "getLatestItem",
+        "responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Base": {
+        "type": "object",
+        "required": ["id"],
+        "properties": {"id": {"type": "integer", "format": "uint64"}}
+      },
+      "Item": {
+        "allOf": [
+          {"$ref": "#/components/schemas/Base"},
+          {"type": "object", "properties": {"name": {"type": "string", "nullable": true}}}
+        ]
+      }
+    }
+  }
+}`
+
+func TestLoad(t *testing.T) {
+	spec, err := Load([]byte(testSpec))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"GET /v1/items",
+		"GET /v1/items/latest",
+		"GET /v1/items/{id}",
+	}, spec.Routes())
+
+	_, err = Load([]byte(`{"openapi": "3.0.3", "paths": {"/": {"get": {"operationId": "x", "responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Missing"}}}}}}}}}`))
+	assert.EqualError(t, err, `GET /: unknown schema "Missing"`)
+}
+
+func TestSpec_Find(t *testing.T) {
+	spec, err := Load([]byte(testSpec))
+	require.NoError(t, err)
+
+	_, op, params, err := spec.Find(http.MethodGet, "/v1/items/latest")
+	require.NoError(t, err)
+	assert.Equal(t, "getLatestItem", op.OperationID)
+	assert.Empty(t, params)
+
+	_, op, params, err = spec.Find(http.MethodGet, "/v1/items/3")
+	require.NoError(t, err)
+	assert.Equal(t, "getItem", op.OperationID)
+	assert.Equal(t, map[string]string{"id": "3"}, params)
+
+	_, _, _, err = spec.Find(http.MethodPost, "/v1/items")
+	assert.EqualError(t, err, "undocumented method POST /v1/items")
+	_, _, _, err = spec.Find(http.MethodGet, "/v2/items")
+	assert.EqualError(t, err, "undocumented path /v2/items")
+}
+
+func TestSpec_ValidateRequest(t *testing.T) {
+	spec, err := Load([]byte(testSpec))
+	require.NoError(t, err)
+
+	for url, expected := range map[string]string{
+		"/v1/items?min=3":   "",
+		"/v1/items?min=-3":  `query parameter "min": $: expected unsigned integer, got -3`,
+		"/v1/items?max=3":   `undocumented query parameter "max"`,
+		"/v1/items/4?min=3": `undocumented query parameter "min"`,
+	} {
+		err := spec.ValidateRequest(httptest.NewRequest(http.MethodGet, url, nil), nil)
+		if expected == "" {
+			assert.NoError(t, err, url)
+		} else {
+			assert.EqualError(t, err, expected, url)
+		}
+	}
+}
+
+func TestSpec_ValidateResponse(t *testing.T) {
+	spec, err := Load([]byte(testSpec))
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/v1/items", nil)
+	jsonHeader := http.Header{"Content-Type": {"application/json; charset=utf-8"}}
+
+	for body, expected := range map[string]string{
+		`[]`:                          "",
+		`[{"id": 1, "name": "a"}]`:    "",
+		`[{"id": 1, "name": null}]`:   "",
+		`null`:                        "response 200: $: unexpected null",
+		`[{"name": "a"}]`:             `response 200: $[0]: missing property "id"`,
+		`[{"id": 1.5}]`:               "response 200: $[0].id: expected unsigned integer, got 1.5",
+		`[{"id": 1, "color": "red"}]`: `response 200: $[0]: undocumented property "color"`,
+	} {
+		err := spec.ValidateResponse(req, http.StatusOK, jsonHeader, []byte(body))
+		if expected == "" {
+			assert.NoError(t, err, body)
+		} else {
+			assert.EqualError(t, err, expected, body)
+		}
+	}
+
+	textHeader := http.Header{"Content-Type": {"text/plain; charset=utf-8"}}
+	assert.NoError(t, spec.ValidateResponse(req, http.StatusBadRequest, textHeader, []byte("bad")))
+	assert.EqualError(t, spec.ValidateResponse(req, http.StatusNotFound, textHeader, nil), "undocumented status 404")
+	assert.EqualError(t, spec.ValidateResponse(req, http.StatusOK, textHeader, []byte("ok")),
+		`response 200: undocumented content type "text/plain; charset=utf-8"`)
+}
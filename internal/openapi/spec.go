@@ -0,0 +1,317 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapi checks HTTP traffic against an OpenAPI 3 specification.
+//
+// Only the subset of OpenAPI used by the specs in the api directory is supported.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Spec is a parsed OpenAPI document.
+type Spec struct {
+	OpenAPI    string               `json:"openapi"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components struct {
+		Schemas    map[string]*Schema    `json:"schemas"`
+		Parameters map[string]*Parameter `json:"parameters"`
+		Responses  map[string]*Response  `json:"responses"`
+	} `json:"components"`
+}
+
+// PathItem describes the operations available on a path.
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters"`
+	Get        *Operation   `json:"get"`
+	Head       *Operation   `json:"head"`
+	Post       *Operation   `json:"post"`
+	Put        *Operation   `json:"put"`
+	Delete     *Operation   `json:"delete"`
+}
+
+// Operations returns the operations of the path item by HTTP method.
+func (p *PathItem) Operations() map[string]*Operation {
+	ops := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{
+		"GET":    p.Get,
+		"HEAD":   p.Head,
+		"POST":   p.Post,
+		"PUT":    p.Put,
+		"DELETE": p.Delete,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a path, query or header parameter.
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes the accepted request payloads.
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response of an operation.
+type Response struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// MediaType describes a payload format.
+//
+// Server-sent event streams list the schema of each event's data in the x-events extension.
+type MediaType struct {
+	Schema *Schema            `json:"schema"`
+	Events map[string]*Schema `json:"x-events"`
+}
+
+// Schema is a JSON schema object.
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Enum                 []any              `json:"enum"`
+	Nullable             bool               `json:"nullable"`
+	Minimum              *float64           `json:"minimum"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	AllOf                []*Schema          `json:"allOf"`
+}
+
+// Load parses an OpenAPI document and checks that all references resolve.
+func Load(data []byte) (*Spec, error) {
+	spec := new(Spec)
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", spec.OpenAPI)
+	}
+	for _, path := range spec.sortedPaths() {
+		item := spec.Paths[path]
+		for method, op := range item.Operations() {
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("%s %s: missing operationId", method, path)
+			}
+			if len(op.Responses) == 0 {
+				return nil, fmt.Errorf("%s %s: no responses", method, path)
+			}
+			if err := spec.checkOperation(item, op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+		}
+	}
+	return spec, nil
+}
+
+func (s *Spec) checkOperation(item *PathItem, op *Operation) error {
+	var schemas []*Schema
+	for _, param := range append(item.Parameters, op.Parameters...) {
+		param, err := s.parameter(param)
+		if err != nil {
+			return err
+		}
+		schemas = append(schemas, param.Schema)
+	}
+	var contents []map[string]*MediaType
+	if op.RequestBody != nil {
+		contents = append(contents, op.RequestBody.Content)
+	}
+	for _, res := range op.Responses {
+		res, err := s.response(res)
+		if err != nil {
+			return err
+		}
+		contents = append(contents, res.Content)
+	}
+	for _, content := range contents {
+		for _, media := range content {
+			schemas = append(schemas, media.Schema)
+			for _, event := range media.Events {
+				schemas = append(schemas, event)
+			}
+		}
+	}
+	for _, schema := range schemas {
+		if err := s.checkSchema(schema, map[*Schema]bool{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Spec) checkSchema(schema *Schema, seen map[*Schema]bool) error {
+	if schema == nil || seen[schema] {
+		return nil
+	}
+	seen[schema] = true
+	if schema.Ref != "" {
+		resolved, err := s.resolve(schema)
+		if err != nil {
+			return err
+		}
+		return s.checkSchema(resolved, seen)
+	}
+	children := append([]*Schema{schema.Items}, schema.AllOf...)
+	for _, prop := range schema.Properties {
+		children = append(children, prop)
+	}
+	for _, child := range children {
+		if err := s.checkSchema(child, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve follows a schema reference.
+func (s *Spec) resolve(schema *Schema) (*Schema, error) {
+	for schema.Ref != "" {
+		name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+		if !ok {
+			return nil, fmt.Errorf("unsupported reference %q", schema.Ref)
+		}
+		target := s.Components.Schemas[name]
+		if target == nil {
+			return nil, fmt.Errorf("unknown schema %q", name)
+		}
+		schema = target
+	}
+	return schema, nil
+}
+
+// parameter follows a parameter reference.
+func (s *Spec) parameter(param *Parameter) (*Parameter, error) {
+	if param.Ref == "" {
+		return param, nil
+	}
+	name, ok := strings.CutPrefix(param.Ref, "#/components/parameters/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported reference %q", param.Ref)
+	}
+	target := s.Components.Parameters[name]
+	if target == nil {
+		return nil, fmt.Errorf("unknown parameter %q", name)
+	}
+	return target, nil
+}
+
+// response follows a response reference.
+func (s *Spec) response(res *Response) (*Response, error) {
+	if res.Ref == "" {
+		return res, nil
+	}
+	name, ok := strings.CutPrefix(res.Ref, "#/components/responses/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported reference %q", res.Ref)
+	}
+	target := s.Components.Responses[name]
+	if target == nil {
+		return nil, fmt.Errorf("unknown response %q", name)
+	}
+	return target, nil
+}
+
+func (s *Spec) sortedPaths() []string {
+	paths := make([]string, 0, len(s.Paths))
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Routes lists all operations as "METHOD /path", in the path syntax of the spec.
+func (s *Spec) Routes() []string {
+	var routes []string
+	for _, path := range s.sortedPaths() {
+		for method := range s.Paths[path].Operations() {
+			routes = append(routes, method+" "+path)
+		}
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// Find returns the operation serving the given method and request path.
+// Also returns the path item and the values of path parameters.
+func (s *Spec) Find(method, path string) (*PathItem, *Operation, map[string]string, error) {
+	segments := strings.Split(path, "/")
+	var (
+		bestItem   *PathItem
+		bestParams map[string]string
+	)
+	for _, template := range s.sortedPaths() {
+		params, ok := matchPath(strings.Split(template, "/"), segments)
+		if !ok {
+			continue
+		}
+		// Prefer literal matches over templated ones.
+		if bestItem == nil || len(params) < len(bestParams) {
+			bestItem, bestParams = s.Paths[template], params
+		}
+	}
+	if bestItem == nil {
+		return nil, nil, nil, fmt.Errorf("undocumented path %s", path)
+	}
+	op := bestItem.Operations()[method]
+	if op == nil {
+		return nil, nil, nil, fmt.Errorf("undocumented method %s %s", method, path)
+	}
+	return bestItem, op, bestParams, nil
+}
+
+func matchPath(template, segments []string) (map[string]string, bool) {
+	if len(template) != len(segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, part := range template {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[part[1:len(part)-1]] = segments[i]
+		} else if part != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
@@ -0,0 +1,362 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
+)
+
+// ValidateRequest checks a request and its body against the spec.
+func (s *Spec) ValidateRequest(req *http.Request, body []byte) error {
+	item, op, pathParams, err := s.Find(req.Method, req.URL.Path)
+	if err != nil {
+		return err
+	}
+	query := req.URL.Query()
+	declared := make(map[string]bool)
+	for _, param := range append(item.Parameters, op.Parameters...) {
+		param, err := s.parameter(param)
+		if err != nil {
+			return err
+		}
+		var value string
+		var present bool
+		switch param.In {
+		case "path":
+			value, present = pathParams[param.Name]
+		case "query":
+			declared[param.Name] = true
+			present = query.Has(param.Name)
+			value = query.Get(param.Name)
+		case "header":
+			value = req.Header.Get(param.Name)
+			present = value != ""
+		default:
+			return fmt.Errorf("unsupported parameter location %q", param.In)
+		}
+		if !present {
+			if param.Required {
+				return fmt.Errorf("%s parameter %q missing", param.In, param.Name)
+			}
+			continue
+		}
+		if err := s.validateParam(param.Schema, value); err != nil {
+			return fmt.Errorf("%s parameter %q: %w", param.In, param.Name, err)
+		}
+	}
+	for name := range query {
+		if !declared[name] {
+			return fmt.Errorf("undocumented query parameter %q", name)
+		}
+	}
+
+	if op.RequestBody == nil {
+		if len(body) > 0 {
+			return errors.New("undocumented request body")
+		}
+		return nil
+	}
+	if len(body) == 0 {
+		if op.RequestBody.Required {
+			return errors.New("request body missing")
+		}
+		return nil
+	}
+	media, contentType, err := findMediaType(op.RequestBody.Content, req.Header.Get("content-type"))
+	if err != nil {
+		return fmt.Errorf("request body: %w", err)
+	}
+	return s.validateBody(media, contentType, body)
+}
+
+// ValidateResponse checks a response to the given request against the spec.
+func (s *Spec) ValidateResponse(req *http.Request, status int, header http.Header, body []byte) error {
+	_, op, _, err := s.Find(req.Method, req.URL.Path)
+	if err != nil {
+		return err
+	}
+	res := op.Responses[strconv.Itoa(status)]
+	if res == nil {
+		res = op.Responses["default"]
+	}
+	if res == nil {
+		return fmt.Errorf("undocumented status %d", status)
+	}
+	if res, err = s.response(res); err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	if len(res.Content) == 0 {
+		return fmt.Errorf("undocumented response body for status %d", status)
+	}
+	media, contentType, err := findMediaType(res.Content, header.Get("content-type"))
+	if err != nil {
+		return fmt.Errorf("response %d: %w", status, err)
+	}
+	if err := s.validateBody(media, contentType, body); err != nil {
+		return fmt.Errorf("response %d: %w", status, err)
+	}
+	return nil
+}
+
+// findMediaType returns the declared media type matching the given content type.
+// Media ranges like "*/*" match any content type.
+func findMediaType(content map[string]*MediaType, header string) (*MediaType, string, error) {
+	contentType, _, _ := mime.ParseMediaType(header)
+	if media := content[contentType]; media != nil {
+		return media, contentType, nil
+	}
+	if major, _, ok := strings.Cut(contentType, "/"); ok {
+		if media := content[major+"/*"]; media != nil {
+			return media, contentType, nil
+		}
+	}
+	if media := content["*/*"]; media != nil {
+		return media, contentType, nil
+	}
+	return nil, "", fmt.Errorf("undocumented content type %q", header)
+}
+
+// validateBody checks JSON documents and server-sent event streams against their schemas.
+// Other formats are not inspected.
+func (s *Spec) validateBody(media *MediaType, contentType string, body []byte) error {
+	switch contentType {
+	case "application/json":
+		return s.validateJSON(media.Schema, body)
+	case "text/event-stream":
+		return fetch.ReadServerSentEvents(bytes.NewReader(body), func(event *fetch.ServerSentEvent) error {
+			schema := media.Events[event.Event]
+			if schema == nil {
+				return fmt.Errorf("undocumented event %q", event.Event)
+			}
+			if err := s.validateJSON(schema, event.Data); err != nil {
+				return fmt.Errorf("event %q: %w", event.Event, err)
+			}
+			return nil
+		})
+	default:
+		return nil
+	}
+}
+
+func (s *Spec) validateJSON(schema *Schema, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return s.Validate(schema, value)
+}
+
+// validateParam checks the string representation of a parameter.
+func (s *Spec) validateParam(schema *Schema, value string) error {
+	schema, err := s.resolve(schema)
+	if err != nil {
+		return err
+	}
+	switch schema.Type {
+	case "integer":
+		return s.Validate(schema, json.Number(value))
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		return nil
+	default:
+		return s.Validate(schema, value)
+	}
+}
+
+// Validate checks a decoded JSON value against a schema.
+// Numbers must be decoded as json.Number.
+func (s *Spec) Validate(schema *Schema, value any) error {
+	return s.validate(schema, value, "$")
+}
+
+func (s *Spec) validate(schema *Schema, value any, path string) error {
+	schema, err := s.resolve(schema)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		if schema.Nullable {
+			return nil
+		}
+		return fmt.Errorf("%s: unexpected null", path)
+	}
+	if len(schema.AllOf) > 0 {
+		return s.validateAllOf(schema, value, path)
+	}
+	if len(schema.Enum) > 0 && !containsValue(schema.Enum, value) {
+		return fmt.Errorf("%s: %v not in enum %v", path, value, schema.Enum)
+	}
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object", path)
+		}
+		return s.validateObject([]*Schema{schema}, obj, path)
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array", path)
+		}
+		for i, item := range arr {
+			if err := s.validate(schema.Items, item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string", path)
+		}
+		return validateFormat(schema.Format, str, path)
+	case "integer":
+		num, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: expected integer", path)
+		}
+		if schema.Format == "uint64" || (schema.Minimum != nil && *schema.Minimum >= 0) {
+			if _, err := strconv.ParseUint(string(num), 10, 64); err != nil {
+				return fmt.Errorf("%s: expected unsigned integer, got %s", path, num)
+			}
+		} else if _, err := strconv.ParseInt(string(num), 10, 64); err != nil {
+			return fmt.Errorf("%s: expected integer, got %s", path, num)
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return fmt.Errorf("%s: expected number", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean", path)
+		}
+	case "":
+		// any value
+	default:
+		return fmt.Errorf("%s: unsupported type %q", path, schema.Type)
+	}
+	return nil
+}
+
+// validateAllOf checks a value against all sub-schemas.
+// Objects are checked against the union of all properties,
+// so that properties of one sub-schema are not rejected by another.
+func (s *Spec) validateAllOf(schema *Schema, value any, path string) error {
+	parts, err := s.flattenAllOf(schema)
+	if err != nil {
+		return err
+	}
+	if obj, ok := value.(map[string]any); ok {
+		return s.validateObject(parts, obj, path)
+	}
+	for _, part := range parts {
+		if err := s.validate(part, value, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Spec) flattenAllOf(schema *Schema) ([]*Schema, error) {
+	schema, err := s.resolve(schema)
+	if err != nil {
+		return nil, err
+	}
+	parts := []*Schema{schema}
+	for _, sub := range schema.AllOf {
+		subParts, err := s.flattenAllOf(sub)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, subParts...)
+	}
+	return parts, nil
+}
+
+func (s *Spec) validateObject(parts []*Schema, obj map[string]any, path string) error {
+	props := make(map[string]*Schema)
+	additional := false
+	for _, part := range parts {
+		for _, name := range part.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing property %q", path, name)
+			}
+		}
+		for name, prop := range part.Properties {
+			props[name] = prop
+		}
+		if part.AdditionalProperties != nil && *part.AdditionalProperties {
+			additional = true
+		}
+	}
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, ok := props[name]
+		if !ok {
+			if additional {
+				continue
+			}
+			return fmt.Errorf("%s: undocumented property %q", path, name)
+		}
+		if err := s.validate(prop, obj[name], path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateFormat(format, value, path string) error {
+	var err error
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339Nano, value)
+	case "duration":
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: invalid %s %q", path, format, value)
+	}
+	return nil
+}
+
+func containsValue(enum []any, value any) bool {
+	for _, option := range enum {
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -15,6 +15,8 @@
 package scraper
 
 import (
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -24,19 +26,50 @@ import (
 )
 
 // Collector streams probe results into the database.
+//
+// It also keeps track of the scrape health of each target,
+// and removes snapshots of targets that expired according to their group's policy.
 type Collector struct {
 	resChan chan ProbeResult
+	control chan func()
+	done    chan struct{}
 	DB      *index.DB
 	Log     *zap.Logger
 
+	SweepInterval time.Duration // how often to check for snapshots exceeding max age
+	HistorySize   int           // max snapshot sightings remembered per target
+
 	closed uint32
+
+	lock    sync.Mutex
+	targets map[targetKey]*types.TargetStatus
+	history map[targetKey]*targetHistory
+	expiry  map[string]*types.TargetExpiry // group => policy
+	cadence map[string]*types.Cadence      // group => policy
+	reset   map[string]struct{}            // groups whose config changed since the last discovery
+}
+
+type targetKey struct {
+	group  string
+	target string
 }
 
 func NewCollector(db *index.DB) *Collector {
 	this := &Collector{
 		resChan: make(chan ProbeResult),
+		control: make(chan func()),
+		done:    make(chan struct{}),
 		DB:      db,
 		Log:     zap.NewNop(),
+
+		SweepInterval: 30 * time.Second,
+		HistorySize:   32,
+
+		targets: make(map[targetKey]*types.TargetStatus),
+		history: make(map[targetKey]*targetHistory),
+		expiry:  make(map[string]*types.TargetExpiry),
+		cadence: make(map[string]*types.Cadence),
+		reset:   make(map[string]struct{}),
 	}
 	return this
 }
@@ -57,33 +90,348 @@ func (c *Collector) Close() {
 	}
 }
 
-func (c *Collector) run() {
-	for res := range c.resChan {
-		if res.Err != nil {
-			c.Log.Warn("Scrape failed",
-				zap.String("target", res.Target),
-				zap.Error(res.Err))
+// Configure applies the expiry and cadence policies of the given config.
+// Should be called before scrapers of new groups start.
+func (c *Collector) Configure(conf *types.Config) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.expiry = make(map[string]*types.TargetExpiry, len(conf.TargetGroups))
+	c.cadence = make(map[string]*types.Cadence, len(conf.TargetGroups))
+	for _, group := range conf.TargetGroups {
+		if group.Expiry != nil {
+			c.expiry[group.Group] = group.Expiry
+		}
+		if group.Cadence != nil {
+			c.cadence[group.Group] = group.Cadence
+		}
+	}
+}
+
+// Targets returns the scrape health of all known targets, ordered by group and target.
+func (c *Collector) Targets() []types.TargetStatus {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	targets := make([]types.TargetStatus, 0, len(c.targets))
+	for _, status := range c.targets {
+		targets = append(targets, *status)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Group != targets[j].Group {
+			return targets[i].Group < targets[j].Group
+		}
+		return targets[i].Target < targets[j].Target
+	})
+	return targets
+}
+
+// Known returns whether a target was discovered or scraped in the given group.
+func (c *Collector) Known(group, target string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.targets[targetKey{group, target}]
+	return ok
+}
+
+// ObserveTargets implements TargetObserver.
+//
+// Tracks newly discovered targets and forgets the health of vanished ones.
+// If the group's expiry policy says so, or the group's config changed,
+// vanished targets are also removed from the index.
+func (c *Collector) ObserveTargets(group string, _ *Prober, targets []string) {
+	vanished, reset := c.observeTargets(group, targets)
+	if len(vanished) == 0 && !reset {
+		return
+	}
+	c.exec(func() {
+		if reset {
+			vanished = c.undiscovered(group, targets, vanished)
+		}
+		for _, target := range vanished {
+			n := c.deleteTarget(target)
+			c.Log.Info("Target no longer discovered, removed from index",
+				zap.String("group", group),
+				zap.String("target", target),
+				zap.Int("num_snapshots", n))
+		}
+	})
+}
+
+// undiscovered adds the targets of the group's index entries missing from the discovered ones.
+// This includes entries restored from disk whose targets were never scraped.
+func (c *Collector) undiscovered(group string, discovered, vanished []string) []string {
+	skip := make(map[string]struct{}, len(discovered)+len(vanished))
+	for _, target := range discovered {
+		skip[target] = struct{}{}
+	}
+	for _, target := range vanished {
+		skip[target] = struct{}{}
+	}
+	for _, entry := range c.DB.GetAllSnapshots() {
+		target := entry.Target
+		if entry.Origin != "" {
+			target = entry.Origin
+		}
+		if _, ok := skip[target]; ok || entry.Group != group {
 			continue
 		}
-		c.Log.Debug("Scrape success",
+		skip[target] = struct{}{}
+		vanished = append(vanished, target)
+	}
+	return vanished
+}
+
+// observeTargets updates the target list of a group
+// and returns the vanished targets that should be removed from the index,
+// and whether the group's config changed since the last call.
+func (c *Collector) observeTargets(group string, targets []string) (drop []string, reset bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, reset = c.reset[group]
+	delete(c.reset, group)
+	dropMissing := reset || c.expiry[group] != nil && c.expiry[group].DropMissing
+	discovered := make(map[string]struct{}, len(targets))
+	for _, target := range targets {
+		discovered[target] = struct{}{}
+		key := targetKey{group, target}
+		if _, ok := c.targets[key]; !ok {
+			c.targets[key] = &types.TargetStatus{
+				Group:  group,
+				Target: target,
+				Health: types.TargetHealthUnknown,
+			}
+		}
+	}
+	for key := range c.targets {
+		if _, ok := discovered[key.target]; !ok && key.group == group {
+			delete(c.targets, key)
+			delete(c.history, key)
+			if dropMissing {
+				drop = append(drop, key.target)
+			}
+		}
+	}
+	return
+}
+
+// ResetGroup implements TargetObserver.
+//
+// Keeps the index entries of the group.
+// Those of targets missing from the next discovery run are removed.
+func (c *Collector) ResetGroup(group string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.reset[group] = struct{}{}
+}
+
+// RemoveGroup implements TargetObserver.
+//
+// Deletes all index entries of the group.
+// Runs after all probe results received so far have been indexed.
+func (c *Collector) RemoveGroup(group string) {
+	c.exec(func() {
+		n := c.DB.DeleteSnapshotsByGroup(group)
+		c.Log.Info("Removed group from index",
+			zap.String("group", group),
+			zap.Int("num_snapshots", n))
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		delete(c.reset, group)
+		for key := range c.targets {
+			if key.group == group {
+				delete(c.targets, key)
+				delete(c.history, key)
+			}
+		}
+	})
+}
+
+// exec runs fn on the collector goroutine, in order with probe results,
+// and waits for it to complete.
+func (c *Collector) exec(fn func()) {
+	finished := make(chan struct{})
+	select {
+	case c.control <- func() { fn(); close(finished) }:
+		<-finished
+	case <-c.done:
+	}
+}
+
+func (c *Collector) run() {
+	defer close(c.done)
+	sweep := time.NewTicker(c.SweepInterval)
+	defer sweep.Stop()
+	for {
+		select {
+		case now := <-sweep.C:
+			c.sweep(now)
+		case res, ok := <-c.resChan:
+			if !ok {
+				return
+			}
+			c.collect(res)
+		case fn := <-c.control:
+			fn()
+		}
+	}
+}
+
+// sweep removes snapshots that were not confirmed by a scrape within the max age of their group.
+func (c *Collector) sweep(now time.Time) {
+	c.lock.Lock()
+	maxAges := make(map[string]time.Duration, len(c.expiry))
+	for group, expiry := range c.expiry {
+		if expiry.MaxAge > 0 {
+			maxAges[group] = expiry.MaxAge
+		}
+	}
+	c.lock.Unlock()
+	for group, maxAge := range maxAges {
+		for _, entry := range c.DB.DeleteOldSnapshotsByGroup(group, now.Add(-maxAge)) {
+			c.Log.Info("Snapshot expired, removed from index",
+				zap.String("group", group),
+				zap.String("target", entry.Target),
+				zap.Uint64("slot", entry.Slot()),
+				zap.Time("updated_at", entry.UpdatedAt))
+		}
+	}
+}
+
+func (c *Collector) collect(res ProbeResult) {
+	failures, maxFailures := c.updateHealth(res)
+	if res.Err != nil {
+		c.Log.Warn("Scrape failed",
+			zap.String("group", res.Group),
 			zap.String("target", res.Target),
-			zap.Int("num_snapshots", len(res.Infos)))
-		c.DB.DeleteSnapshotsByTarget(res.Target)
-		entries := make([]*index.SnapshotEntry, len(res.Infos))
-		for i, info := range res.Infos {
-			entries[i] = &index.SnapshotEntry{
-				SnapshotKey: index.NewSnapshotKey(res.Target, info.Slot),
-				Info:        info,
-				UpdatedAt:   res.Time,
+			zap.Error(res.Err))
+		if maxFailures > 0 && failures >= maxFailures {
+			if n := c.deleteTarget(res.Target); n > 0 {
+				c.Log.Info("Target failing, removed from index",
+					zap.String("group", res.Group),
+					zap.String("target", res.Target),
+					zap.Int("consecutive_failures", failures),
+					zap.Int("num_snapshots", n))
 			}
 		}
-		c.DB.UpsertSnapshots(entries...)
+		return
+	}
+	c.Log.Debug("Scrape success",
+		zap.String("group", res.Group),
+		zap.String("target", res.Target),
+		zap.Int("num_snapshots", len(res.Infos)+len(res.Entries)))
+	if res.Federated {
+		c.collectFederated(res)
+		return
 	}
+	c.DB.DeleteSnapshotsByTarget(res.Target)
+	entries := make([]*index.SnapshotEntry, len(res.Infos))
+	for i, info := range res.Infos {
+		entries[i] = &index.SnapshotEntry{
+			SnapshotKey: index.NewSnapshotKey(res.Target, info.Slot),
+			Group:       res.Group,
+			Info:        info,
+			UpdatedAt:   res.Time,
+		}
+	}
+	c.DB.UpsertSnapshots(entries...)
+}
+
+// collectFederated replaces the sources learned from a federated tracker.
+// Entries are keyed by origin, so trackers reporting the same target do not replace each other's.
+// Targets also scraped by this tracker keep their local entries.
+func (c *Collector) collectFederated(res ProbeResult) {
+	c.DB.DeleteSnapshotsByOrigin(res.Target)
+	entries := make([]*index.SnapshotEntry, 0, len(res.Entries))
+	local := make(map[string]bool)
+	for _, remote := range res.Entries {
+		if remote == nil || remote.Info == nil {
+			continue
+		}
+		isLocal, ok := local[remote.Target]
+		if !ok {
+			for _, existing := range c.DB.GetSnapshotsByTarget(remote.Target) {
+				isLocal = isLocal || existing.Origin == ""
+			}
+			local[remote.Target] = isLocal
+		}
+		if isLocal {
+			continue
+		}
+		entries = append(entries, &index.SnapshotEntry{
+			SnapshotKey: index.NewSnapshotKey(remote.Target, remote.Info.Slot),
+			Group:       res.Group,
+			Info:        remote.Info,
+			UpdatedAt:   remote.UpdatedAt,
+			Unverified:  remote.Unverified,
+			Origin:      res.Target,
+		})
+	}
+	c.DB.UpsertSnapshots(entries...)
+}
+
+// deleteTarget deletes the snapshots of a target,
+// and those learned from it if it is a federated tracker.
+func (c *Collector) deleteTarget(target string) int {
+	return c.DB.DeleteSnapshotsByTarget(target) + c.DB.DeleteSnapshotsByOrigin(target)
+}
+
+// updateHealth records a probe result.
+// Returns the number of consecutive failures and the max allowed by the group's expiry policy.
+func (c *Collector) updateHealth(res ProbeResult) (failures, maxFailures int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if expiry := c.expiry[res.Group]; expiry != nil {
+		maxFailures = expiry.MaxFailures
+	}
+	key := targetKey{res.Group, res.Target}
+	status, ok := c.targets[key]
+	if !ok {
+		status = &types.TargetStatus{Group: res.Group, Target: res.Target}
+		c.targets[key] = status
+	}
+	if res.Source != "" {
+		status.Source = res.Source
+	}
+	status.LastScrape = res.Time
+	if res.Duration > 0 { // pushed results have no duration
+		status.LastDuration = res.Duration
+	}
+	status.TotalScrapes++
+	if res.Err != nil {
+		status.Health = types.TargetHealthDown
+		status.LastError = res.Err.Error()
+		status.ConsecutiveFailures++
+		status.TotalFailures++
+	} else {
+		status.Health = types.TargetHealthUp
+		status.LastError = ""
+		status.ConsecutiveFailures = 0
+		status.NumSnapshots = len(res.Infos) + len(res.Entries)
+		if !res.Federated {
+			history, ok := c.history[key]
+			if !ok {
+				history = new(targetHistory)
+				c.history[key] = history
+			}
+			history.observe(res.Infos, res.Time, c.HistorySize)
+		}
+	}
+	return status.ConsecutiveFailures, maxFailures
 }
 
 type ProbeResult struct {
-	Time   time.Time
-	Target string
-	Infos  []*types.SnapshotInfo
-	Err    error
+	Time     time.Time
+	Duration time.Duration
+	Group    string
+	Source   string // discovery mechanism
+	Target   string
+	Infos    []*types.SnapshotInfo
+	Err      error
+
+	// Federated results carry the sources scraped by another tracker instead of infos.
+	Federated bool
+	Entries   []*index.SnapshotEntry
+
+	// Announced results were pushed by the target instead of scraped.
+	// In HA mode, they may arrive at any replica, not only the owner of the target.
+	Announced bool
 }
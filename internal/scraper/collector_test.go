@@ -0,0 +1,220 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap/zaptest"
+)
+
+var dummyTime = time.Date(2022, 4, 27, 15, 33, 20, 0, time.UTC)
+
+func newTestCollector(t *testing.T) *Collector {
+	collector := NewCollector(index.NewDB())
+	collector.Log = zaptest.NewLogger(t)
+	collector.Start()
+	t.Cleanup(collector.Close)
+	return collector
+}
+
+// sync waits until all previously sent probe results have been processed.
+func (c *Collector) sync() {
+	c.exec(func() {})
+}
+
+func TestCollector_Targets(t *testing.T) {
+	collector := newTestCollector(t)
+	collector.ObserveTargets("test", nil, []string{"host1", "host2"})
+	assert.Equal(t, []types.TargetStatus{
+		{Group: "test", Target: "host1", Health: types.TargetHealthUnknown},
+		{Group: "test", Target: "host2", Health: types.TargetHealthUnknown},
+	}, collector.Targets())
+
+	probes := collector.Probes()
+	probes <- ProbeResult{
+		Time:     dummyTime,
+		Duration: time.Second,
+		Group:    "test",
+		Source:   "static",
+		Target:   "host1",
+		Infos:    []*types.SnapshotInfo{{Slot: 100}},
+	}
+	for i := 0; i < 2; i++ {
+		probes <- ProbeResult{
+			Time:     dummyTime,
+			Duration: 2 * time.Second,
+			Group:    "test",
+			Source:   "static",
+			Target:   "host2",
+			Err:      errors.New("connection refused"),
+		}
+	}
+	collector.sync()
+	assert.Equal(t, []types.TargetStatus{
+		{
+			Group:        "test",
+			Target:       "host1",
+			Source:       "static",
+			Health:       types.TargetHealthUp,
+			LastScrape:   dummyTime,
+			LastDuration: time.Second,
+			NumSnapshots: 1,
+			TotalScrapes: 1,
+		},
+		{
+			Group:               "test",
+			Target:              "host2",
+			Source:              "static",
+			Health:              types.TargetHealthDown,
+			LastScrape:          dummyTime,
+			LastDuration:        2 * time.Second,
+			LastError:           "connection refused",
+			ConsecutiveFailures: 2,
+			TotalScrapes:        2,
+			TotalFailures:       2,
+		},
+	}, collector.Targets())
+
+	// Vanished targets are forgotten.
+	collector.ObserveTargets("test", nil, []string{"host2"})
+	targets := collector.Targets()
+	assert.Len(t, targets, 1)
+	assert.Equal(t, "host2", targets[0].Target)
+
+	collector.RemoveGroup("test")
+	assert.Empty(t, collector.Targets())
+}
+
+func TestCollector_Expiry(t *testing.T) {
+	collector := newTestCollector(t)
+	db := collector.DB
+	collector.Configure(&types.Config{
+		TargetGroups: []*types.TargetGroup{
+			{
+				Group: "expiring",
+				Expiry: &types.TargetExpiry{
+					MaxFailures: 2,
+					MaxAge:      time.Hour,
+					DropMissing: true,
+				},
+			},
+			{Group: "sticky"},
+		},
+	})
+	success := func(group, target string, slot uint64, at time.Time) ProbeResult {
+		return ProbeResult{
+			Time:   at,
+			Group:  group,
+			Target: target,
+			Infos:  []*types.SnapshotInfo{{Slot: slot}},
+		}
+	}
+	failure := func(group, target string) ProbeResult {
+		return ProbeResult{
+			Time:   dummyTime,
+			Group:  group,
+			Target: target,
+			Err:    errors.New("connection refused"),
+		}
+	}
+	targets := func() (targets []string) {
+		for _, entry := range db.GetBestSnapshots(-1) {
+			targets = append(targets, entry.Target)
+		}
+		return
+	}
+
+	t.Run("MaxFailures", func(t *testing.T) {
+		probes := collector.Probes()
+		probes <- success("expiring", "host1", 100, dummyTime)
+		probes <- success("sticky", "host2", 99, dummyTime)
+		probes <- failure("expiring", "host1")
+		probes <- failure("sticky", "host2")
+		probes <- failure("sticky", "host2")
+		collector.sync()
+		assert.Equal(t, []string{"host1", "host2"}, targets(), "below threshold")
+
+		probes <- failure("expiring", "host1")
+		collector.sync()
+		assert.Equal(t, []string{"host2"}, targets())
+
+		probes <- success("expiring", "host1", 100, dummyTime)
+		collector.sync()
+		assert.Equal(t, []string{"host1", "host2"}, targets(), "recovered")
+	})
+
+	t.Run("MaxAge", func(t *testing.T) {
+		collector.Probes() <- success("expiring", "host3", 101, dummyTime.Add(time.Hour))
+		collector.exec(func() { collector.sweep(dummyTime.Add(90 * time.Minute)) })
+		assert.Equal(t, []string{"host3", "host2"}, targets())
+	})
+
+	t.Run("DropMissing", func(t *testing.T) {
+		collector.ObserveTargets("sticky", nil, []string{"host2"})
+		collector.ObserveTargets("expiring", nil, []string{"host3"})
+		collector.Probes() <- success("expiring", "host4", 102, dummyTime)
+		collector.sync()
+		assert.Equal(t, []string{"host4", "host3", "host2"}, targets())
+
+		collector.ObserveTargets("expiring", nil, []string{"host4"})
+		collector.ObserveTargets("sticky", nil, nil)
+		assert.Equal(t, []string{"host4", "host2"}, targets())
+	})
+}
+
+func TestCollector_Federated(t *testing.T) {
+	collector := newTestCollector(t)
+	db := collector.DB
+	federated := func(origin string, targets ...string) ProbeResult {
+		res := ProbeResult{Time: dummyTime, Group: "federated", Target: origin, Federated: true}
+		for _, target := range targets {
+			res.Entries = append(res.Entries, &index.SnapshotEntry{
+				SnapshotKey: index.NewSnapshotKey(target, 100),
+				Info:        &types.SnapshotInfo{Slot: 100},
+				UpdatedAt:   dummyTime,
+			})
+		}
+		return res
+	}
+	origins := func() (origins []string) {
+		for _, entry := range db.GetSnapshotsByTarget("shared") {
+			origins = append(origins, entry.Origin)
+		}
+		return
+	}
+
+	// Trackers reporting the same target keep separate entries.
+	probes := collector.Probes()
+	probes <- federated("tracker1", "shared")
+	probes <- federated("tracker2", "shared")
+	collector.sync()
+	assert.ElementsMatch(t, []string{"tracker1", "tracker2"}, origins())
+
+	probes <- federated("tracker1")
+	collector.sync()
+	assert.Equal(t, []string{"tracker2"}, origins())
+
+	// Local entries take precedence.
+	probes <- ProbeResult{Time: dummyTime, Group: "local", Target: "shared", Infos: []*types.SnapshotInfo{{Slot: 100}}}
+	probes <- federated("tracker1", "shared")
+	collector.sync()
+	assert.Equal(t, []string{""}, origins())
+}
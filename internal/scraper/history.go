@@ -0,0 +1,164 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"sort"
+	"time"
+
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+// defaultStallFactor is the multiple of the usual snapshot interval
+// after which a target without new snapshots counts as stalled.
+const defaultStallFactor = 3
+
+// targetHistory remembers the first sighting of the recent snapshots of a target.
+type targetHistory struct {
+	since     time.Time                // first successful scrape
+	sightings []types.SnapshotSighting // newest first
+}
+
+// observe records the snapshots seen by a successful scrape.
+// Keeps at most size sightings, dropping the oldest slots.
+func (h *targetHistory) observe(infos []*types.SnapshotInfo, now time.Time, size int) {
+	initial := h.since.IsZero()
+	if initial {
+		h.since = now
+	}
+	changed := false
+	for _, info := range infos {
+		if h.seen(info) {
+			continue
+		}
+		if len(h.sightings) >= size && info.Slot < h.sightings[len(h.sightings)-1].Slot {
+			continue // older than everything remembered
+		}
+		h.sightings = append(h.sightings, types.SnapshotSighting{
+			Slot:      info.Slot,
+			BaseSlot:  info.BaseSlot(),
+			Hash:      info.Hash,
+			FirstSeen: now,
+			Initial:   initial,
+		})
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	sort.SliceStable(h.sightings, func(i, j int) bool {
+		return h.sightings[i].Slot > h.sightings[j].Slot
+	})
+	if len(h.sightings) > size {
+		h.sightings = h.sightings[:size]
+	}
+}
+
+func (h *targetHistory) seen(info *types.SnapshotInfo) bool {
+	for _, sighting := range h.sightings {
+		if sighting.Slot == info.Slot && sighting.Hash == info.Hash && sighting.BaseSlot == info.BaseSlot() {
+			return true
+		}
+	}
+	return false
+}
+
+// summarize derives the cadence of the target and whether it stalled.
+func (h *targetHistory) summarize(status *types.TargetHistory, policy *types.Cadence, now time.Time) {
+	status.Snapshots = append(make([]types.SnapshotSighting, 0, len(h.sightings)), h.sightings...)
+	status.Full = cadence(h.sightings, true)
+	status.Incremental = cadence(h.sightings, false)
+	lastProduced := h.since
+	for _, sighting := range h.sightings {
+		if !sighting.Initial && (status.LastProduced == nil || sighting.FirstSeen.After(*status.LastProduced)) {
+			firstSeen := sighting.FirstSeen
+			status.LastProduced = &firstSeen
+			lastProduced = firstSeen
+		}
+	}
+
+	factor := float64(defaultStallFactor)
+	if policy != nil && policy.StallFactor > 0 {
+		factor = policy.StallFactor
+	}
+	switch {
+	case policy != nil && policy.StallAfter > 0:
+		status.StallAfter = policy.StallAfter
+	default:
+		// The most frequent kind of snapshot sets the pace.
+		for _, c := range []*types.SnapshotCadence{status.Full, status.Incremental} {
+			if c == nil || c.Intervals < 2 {
+				continue
+			}
+			stallAfter := time.Duration(factor * float64(c.MedianInterval))
+			if status.StallAfter == 0 || stallAfter < status.StallAfter {
+				status.StallAfter = stallAfter
+			}
+		}
+	}
+	status.Stalled = status.StallAfter > 0 && now.Sub(lastProduced) > status.StallAfter
+}
+
+// cadence measures the intervals between consecutive produced snapshots of a kind.
+// Returns nil if no interval was observed.
+func cadence(sightings []types.SnapshotSighting, full bool) *types.SnapshotCadence {
+	var (
+		intervals []time.Duration
+		slots     []uint64
+		newer     *types.SnapshotSighting
+	)
+	for i := range sightings {
+		sighting := &sightings[i]
+		if sighting.IsFull() != full {
+			continue
+		}
+		if newer != nil && !newer.Initial && !sighting.Initial {
+			intervals = append(intervals, newer.FirstSeen.Sub(sighting.FirstSeen))
+			slots = append(slots, newer.Slot-sighting.Slot)
+		}
+		newer = sighting
+	}
+	if len(intervals) == 0 {
+		return nil
+	}
+	res := &types.SnapshotCadence{
+		Intervals:    len(intervals),
+		LastInterval: intervals[0],
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+	sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+	res.MedianInterval = intervals[len(intervals)/2]
+	res.MedianSlots = slots[len(slots)/2]
+	return res
+}
+
+// History returns the snapshot production history of all scraped targets, ordered by group and target.
+func (c *Collector) History(now time.Time) []types.TargetHistory {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	histories := make([]types.TargetHistory, 0, len(c.history))
+	for key, history := range c.history {
+		status := types.TargetHistory{Group: key.group, Target: key.target}
+		history.summarize(&status, c.cadence[key.group], now)
+		histories = append(histories, status)
+	}
+	sort.Slice(histories, func(i, j int) bool {
+		if histories[i].Group != histories[j].Group {
+			return histories[i].Group < histories[j].Group
+		}
+		return histories[i].Target < histories[j].Target
+	})
+	return histories
+}
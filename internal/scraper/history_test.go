@@ -0,0 +1,87 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+func TestCollector_History(t *testing.T) {
+	collector := newTestCollector(t)
+	collector.HistorySize = 4
+	full := func(slot uint64) *types.SnapshotInfo {
+		return &types.SnapshotInfo{Slot: slot, Files: []*types.SnapshotFile{{Slot: slot}}}
+	}
+	incremental := func(slot, base uint64) *types.SnapshotInfo {
+		return &types.SnapshotInfo{Slot: slot, Files: []*types.SnapshotFile{{Slot: slot, BaseSlot: base}}}
+	}
+	scrape := func(at time.Duration, infos ...*types.SnapshotInfo) {
+		collector.Probes() <- ProbeResult{
+			Time:   dummyTime.Add(at),
+			Group:  "test",
+			Target: "host1",
+			Infos:  infos,
+		}
+		collector.sync()
+	}
+
+	// Snapshots present on the first scrape have no known production time.
+	scrape(0, full(1000))
+	// A new incremental snapshot every minute, a new full snapshot after 5 minutes.
+	scrape(1*time.Minute, full(1000), incremental(1100, 1000))
+	scrape(2*time.Minute, full(1000), incremental(1100, 1000), incremental(1200, 1000))
+	scrape(3*time.Minute, full(1000), incremental(1200, 1000), incremental(1300, 1000))
+	scrape(4*time.Minute, full(1000), incremental(1300, 1000), incremental(1400, 1000))
+
+	history := collector.History(dummyTime.Add(5 * time.Minute))
+	require.Len(t, history, 1)
+	h := history[0]
+	assert.Equal(t, []uint64{1400, 1300, 1200, 1100}, sightingSlots(h.Snapshots), "oldest sightings dropped")
+	assert.Nil(t, h.Full)
+	assert.Equal(t, &types.SnapshotCadence{
+		Intervals:      3,
+		LastInterval:   time.Minute,
+		MedianInterval: time.Minute,
+		MedianSlots:    100,
+	}, h.Incremental)
+	require.NotNil(t, h.LastProduced)
+	assert.Equal(t, dummyTime.Add(4*time.Minute), *h.LastProduced)
+	assert.Equal(t, 3*time.Minute, h.StallAfter)
+	assert.False(t, h.Stalled)
+
+	// No new snapshot for longer than three intervals.
+	h = collector.History(dummyTime.Add(8 * time.Minute))[0]
+	assert.True(t, h.Stalled)
+
+	// A fixed threshold overrides the derived one.
+	collector.Configure(&types.Config{TargetGroups: []*types.TargetGroup{
+		{Group: "test", Cadence: &types.Cadence{StallAfter: 10 * time.Minute}},
+	}})
+	h = collector.History(dummyTime.Add(8 * time.Minute))[0]
+	assert.Equal(t, 10*time.Minute, h.StallAfter)
+	assert.False(t, h.Stalled)
+}
+
+func sightingSlots(sightings []types.SnapshotSighting) (slots []uint64) {
+	for _, sighting := range sightings {
+		slots = append(slots, sighting.Slot)
+	}
+	return
+}
@@ -15,7 +15,12 @@
 package scraper
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
 	"sync"
+	"time"
 
 	"go.blockdaemon.com/solana/cluster-manager/internal/discovery"
 	"go.blockdaemon.com/solana/cluster-manager/types"
@@ -24,15 +29,33 @@ import (
 
 // Manager maintains a group of scrapers.
 type Manager struct {
-	res      chan<- ProbeResult
-	scrapers []*Scraper
+	res    chan<- ProbeResult
+	groups map[string]*managedGroup
+
+	Observers []TargetObserver
+	Filter    func(group, target string) bool // selects targets to probe, nil probes all
 
 	Log *zap.Logger
 }
 
+type managedGroup struct {
+	config   *types.TargetGroup
+	interval time.Duration
+	scraper  *Scraper
+}
+
+// UpdateResult lists the target groups affected by a config update.
+type UpdateResult struct {
+	Added     []string `json:"added"`
+	Changed   []string `json:"changed"`
+	Removed   []string `json:"removed"`
+	Unchanged []string `json:"unchanged"`
+}
+
 func NewManager(results chan<- ProbeResult) *Manager {
 	return &Manager{
-		res: results,
+		res:    results,
+		groups: make(map[string]*managedGroup),
 
 		Log: zap.NewNop(),
 	}
@@ -41,44 +64,149 @@ func NewManager(results chan<- ProbeResult) *Manager {
 // Reset shuts down all scrapers.
 func (m *Manager) Reset() {
 	var wg sync.WaitGroup
-	wg.Add(len(m.scrapers))
-	for _, scraper := range m.scrapers {
+	wg.Add(len(m.groups))
+	for _, group := range m.groups {
 		go func(scraper *Scraper) {
 			defer wg.Done()
 			scraper.Close()
-		}(scraper)
+		}(group.scraper)
 	}
-	m.scrapers = nil
+	wg.Wait()
+	m.groups = make(map[string]*managedGroup)
 }
 
-// Update shuts down and reloads all scrapers from config.
-func (m *Manager) Update(conf *types.Config) {
-	m.Reset()
+// Plan is a validated config update, ready to be applied.
+type Plan struct {
+	Result   UpdateResult
+	conf     *types.Config
+	scrapers map[string]*Scraper // added and changed groups
+}
+
+// Prepare compares a new config to the running one
+// and creates the scrapers of added and changed groups without starting them.
+// Fails if any group fails to load.
+func (m *Manager) Prepare(conf *types.Config) (*Plan, error) {
+	plan := &Plan{
+		conf:     conf,
+		scrapers: make(map[string]*Scraper),
+	}
+	result := &plan.Result
+	newGroups := make(map[string]struct{}, len(conf.TargetGroups))
+	var errs []error
 	for _, group := range conf.TargetGroups {
-		log := m.Log.With(zap.String("group", group.Group))
-		if err := m.loadGroup(group, log); err != nil {
-			log.Error("Failed to load group", zap.Error(err))
+		newGroups[group.Group] = struct{}{}
+		old, ok := m.groups[group.Group]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, group.Group)
+		case old.interval != group.Interval(conf.ScrapeInterval) || !reflect.DeepEqual(old.config, group):
+			result.Changed = append(result.Changed, group.Group)
+		default:
+			result.Unchanged = append(result.Unchanged, group.Group)
+			continue
+		}
+		scraper, err := m.newScraper(group, m.Log.With(zap.String("group", group.Group)))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("group %s: %w", group.Group, err))
+			continue
 		}
+		plan.scrapers[group.Group] = scraper
 	}
-	for _, scraper := range m.scrapers {
-		scraper.Start(m.res, conf.ScrapeInterval)
+	for name := range m.groups {
+		if _, ok := newGroups[name]; !ok {
+			result.Removed = append(result.Removed, name)
+		}
+	}
+	sort.Strings(result.Removed)
+	if err := errors.Join(errs...); err != nil {
+		plan.Discard()
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Discard releases the scrapers of a plan that is not applied.
+func (p *Plan) Discard() {
+	for _, scraper := range p.scrapers {
+		scraper.Close()
+	}
+}
+
+// Apply restarts the scrapers of changed groups, stops removed ones and starts added ones.
+//
+// Removed groups are reported to the observers, so that they can drop stale state.
+// Changed groups keep their scraped state.
+func (m *Manager) Apply(plan *Plan) UpdateResult {
+	// Stop outdated scrapers.
+	stop := append(append([]string(nil), plan.Result.Changed...), plan.Result.Removed...)
+	var wg sync.WaitGroup
+	wg.Add(len(stop))
+	for _, name := range stop {
+		go func(scraper *Scraper) {
+			defer wg.Done()
+			scraper.Close()
+		}(m.groups[name].scraper)
+	}
+	wg.Wait()
+	for _, name := range plan.Result.Removed {
+		delete(m.groups, name)
+		deleteGroupMetrics(name)
+		for _, observer := range m.Observers {
+			observer.RemoveGroup(name)
+		}
+	}
+	for _, name := range plan.Result.Changed {
+		for _, observer := range m.Observers {
+			observer.ResetGroup(name)
+		}
+	}
+
+	// Start new scrapers.
+	for _, group := range plan.conf.TargetGroups {
+		scraper, ok := plan.scrapers[group.Group]
+		if !ok {
+			continue
+		}
+		interval := group.Interval(plan.conf.ScrapeInterval)
+		m.groups[group.Group] = &managedGroup{
+			config:   group,
+			interval: interval,
+			scraper:  scraper,
+		}
+		scraper.Start(m.res, interval)
+	}
+	return plan.Result
+}
+
+// Update applies a new config.
+//
+// Only scrapers of groups whose config changed get restarted.
+// Nothing is changed if any group fails to load.
+func (m *Manager) Update(conf *types.Config) (UpdateResult, error) {
+	plan, err := m.Prepare(conf)
+	if err != nil {
+		return UpdateResult{}, err
 	}
+	return m.Apply(plan), nil
 }
 
-func (m *Manager) loadGroup(group *types.TargetGroup, log *zap.Logger) error {
+func (m *Manager) newScraper(group *types.TargetGroup, log *zap.Logger) (*Scraper, error) {
 	disc, err := discovery.NewFromConfig(group)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	prober, err := NewProber(group)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	scraper := NewScraper(prober, disc)
+	scraper.Group = group.Group
+	scraper.Source = discovery.SourceName(group)
+	scraper.Observers = m.Observers
+	scraper.Filter = m.Filter
 	scraper.Log = log
-	m.scrapers = append(m.scrapers, scraper)
 
-	return nil
+	return scraper, nil
 }
@@ -0,0 +1,143 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeObserver struct {
+	lock    sync.Mutex
+	removed []string
+	reset   []string
+}
+
+func (f *fakeObserver) ObserveTargets(_ string, _ *Prober, _ []string) {}
+
+func (f *fakeObserver) RemoveGroup(group string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.removed = append(f.removed, group)
+}
+
+func (f *fakeObserver) ResetGroup(group string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.reset = append(f.reset, group)
+}
+
+func staticGroup(name string, targets ...string) *types.TargetGroup {
+	return &types.TargetGroup{
+		Group:         name,
+		Scheme:        "http",
+		StaticTargets: &types.StaticTargets{Targets: targets},
+	}
+}
+
+func TestManager_Update(t *testing.T) {
+	db := index.NewDB()
+	collector := NewCollector(db)
+	collector.Log = zaptest.NewLogger(t)
+	collector.Start()
+	defer collector.Close()
+
+	observer := new(fakeObserver)
+	manager := NewManager(collector.Probes())
+	manager.Log = zaptest.NewLogger(t)
+	manager.Observers = []TargetObserver{observer, collector}
+	defer manager.Reset()
+
+	result, err := manager.Update(&types.Config{
+		ScrapeInterval: time.Hour,
+		TargetGroups: []*types.TargetGroup{
+			staticGroup("a", "127.0.0.1:1"),
+			staticGroup("b", "127.0.0.1:1"),
+			staticGroup("c", "127.0.0.1:1"),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, result.Added)
+
+	// Pretend group "b" and "c" have been scraped.
+	db.UpsertSnapshots(
+		&index.SnapshotEntry{SnapshotKey: index.NewSnapshotKey("127.0.0.1:1", 1), Group: "b", Info: &types.SnapshotInfo{Slot: 1}},
+		&index.SnapshotEntry{SnapshotKey: index.NewSnapshotKey("127.0.0.1:3", 1), Group: "b", Info: &types.SnapshotInfo{Slot: 1}},
+		&index.SnapshotEntry{SnapshotKey: index.NewSnapshotKey("host-c", 1), Group: "c", Info: &types.SnapshotInfo{Slot: 1}},
+	)
+
+	// Group "b" keeps the entries of targets still discovered.
+	result, err = manager.Update(&types.Config{
+		ScrapeInterval: time.Hour,
+		TargetGroups: []*types.TargetGroup{
+			staticGroup("a", "127.0.0.1:1"),
+			staticGroup("b", "127.0.0.1:1", "127.0.0.1:2"),
+			staticGroup("d", "127.0.0.1:1"),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, UpdateResult{
+		Added:     []string{"d"},
+		Changed:   []string{"b"},
+		Removed:   []string{"c"},
+		Unchanged: []string{"a"},
+	}, result)
+	assert.Equal(t, []string{"c"}, observer.removed)
+	assert.Equal(t, []string{"b"}, observer.reset)
+	assert.Empty(t, db.GetSnapshotsByTarget("host-c"))
+	assert.Len(t, db.GetSnapshotsByTarget("127.0.0.1:1"), 1)
+	assert.Eventually(t, func() bool {
+		return len(db.GetSnapshotsByTarget("127.0.0.1:3")) == 0
+	}, 5*time.Second, 10*time.Millisecond, "vanished target of changed group dropped")
+
+	// A broken group leaves the running config untouched.
+	result, err = manager.Update(&types.Config{
+		ScrapeInterval: time.Hour,
+		TargetGroups: []*types.TargetGroup{
+			{Group: "e", Scheme: "http"},
+		},
+	})
+	assert.EqualError(t, err, "group e: missing config")
+	assert.Equal(t, UpdateResult{}, result)
+	assert.Equal(t, []string{"c"}, observer.removed)
+	assert.Len(t, manager.groups, 3)
+	assert.Len(t, db.GetSnapshotsByTarget("127.0.0.1:1"), 1)
+}
+
+func TestManager_Prepare(t *testing.T) {
+	manager := NewManager(make(chan ProbeResult))
+	manager.Log = zaptest.NewLogger(t)
+	defer manager.Reset()
+
+	conf := &types.Config{
+		ScrapeInterval: time.Hour,
+		TargetGroups:   []*types.TargetGroup{staticGroup("a", "127.0.0.1:1")},
+	}
+	plan, err := manager.Prepare(conf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, plan.Result.Added)
+
+	// A discarded plan releases its scrapers and leaves the running config untouched.
+	plan.Discard()
+	assert.Error(t, plan.scrapers["a"].rootCtx.Err())
+	assert.Empty(t, manager.groups)
+}
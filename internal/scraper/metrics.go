@@ -0,0 +1,132 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+var (
+	discoveryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "solana_cluster",
+		Subsystem: "tracker",
+		Name:      "discovery_duration_seconds",
+		Help:      "Duration of service discovery runs",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10},
+	}, []string{"group"})
+	discoveryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "solana_cluster",
+		Subsystem: "tracker",
+		Name:      "discovery_errors_total",
+		Help:      "Number of failed service discovery runs",
+	}, []string{"group"})
+	scrapeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "solana_cluster",
+		Subsystem: "tracker",
+		Name:      "scrape_duration_seconds",
+		Help:      "Duration of scrapes of individual targets",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10},
+	}, []string{"group"})
+)
+
+var (
+	targetUpDesc = prometheus.NewDesc(
+		"solana_cluster_tracker_target_up",
+		"Whether the last scrape of a target succeeded",
+		[]string{"group", "target"}, nil)
+	targetScrapesDesc = prometheus.NewDesc(
+		"solana_cluster_tracker_target_scrapes_total",
+		"Number of scrapes of a target",
+		[]string{"group", "target"}, nil)
+	targetFailuresDesc = prometheus.NewDesc(
+		"solana_cluster_tracker_target_scrape_failures_total",
+		"Number of failed scrapes of a target",
+		[]string{"group", "target"}, nil)
+	targetDurationDesc = prometheus.NewDesc(
+		"solana_cluster_tracker_target_last_scrape_duration_seconds",
+		"Duration of the last scrape of a target",
+		[]string{"group", "target"}, nil)
+	targetLastProducedDesc = prometheus.NewDesc(
+		"solana_cluster_tracker_target_last_snapshot_produced_timestamp_seconds",
+		"Time the newest snapshot of a target was first seen",
+		[]string{"group", "target"}, nil)
+	targetSnapshotIntervalDesc = prometheus.NewDesc(
+		"solana_cluster_tracker_target_snapshot_interval_seconds",
+		"Median interval between consecutive snapshots of a target by kind (full, incremental)",
+		[]string{"group", "target", "kind"}, nil)
+	targetStalledDesc = prometheus.NewDesc(
+		"solana_cluster_tracker_target_stalled",
+		"Whether a target has not produced a snapshot for longer than its cadence allows",
+		[]string{"group", "target"}, nil)
+)
+
+// deleteGroupMetrics drops the metrics of a removed target group.
+func deleteGroupMetrics(group string) {
+	discoveryDuration.DeleteLabelValues(group)
+	discoveryErrors.DeleteLabelValues(group)
+	scrapeDuration.DeleteLabelValues(group)
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- targetUpDesc
+	descs <- targetScrapesDesc
+	descs <- targetFailuresDesc
+	descs <- targetDurationDesc
+	descs <- targetLastProducedDesc
+	descs <- targetSnapshotIntervalDesc
+	descs <- targetStalledDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
+	for _, t := range c.Targets() {
+		if t.Health == types.TargetHealthUnknown {
+			continue
+		}
+		up := 0.0
+		if t.Health == types.TargetHealthUp {
+			up = 1.0
+		}
+		metrics <- prometheus.MustNewConstMetric(targetUpDesc, prometheus.GaugeValue, up, t.Group, t.Target)
+		metrics <- prometheus.MustNewConstMetric(targetScrapesDesc, prometheus.CounterValue, float64(t.TotalScrapes), t.Group, t.Target)
+		metrics <- prometheus.MustNewConstMetric(targetFailuresDesc, prometheus.CounterValue, float64(t.TotalFailures), t.Group, t.Target)
+		metrics <- prometheus.MustNewConstMetric(targetDurationDesc, prometheus.GaugeValue, t.LastDuration.Seconds(), t.Group, t.Target)
+	}
+	for _, h := range c.History(time.Now()) {
+		if h.LastProduced != nil {
+			metrics <- prometheus.MustNewConstMetric(targetLastProducedDesc, prometheus.GaugeValue,
+				float64(h.LastProduced.UnixNano())/1e9, h.Group, h.Target)
+		}
+		for kind, cadence := range map[string]*types.SnapshotCadence{
+			types.SnapshotKindFull:        h.Full,
+			types.SnapshotKindIncremental: h.Incremental,
+		} {
+			if cadence != nil {
+				metrics <- prometheus.MustNewConstMetric(targetSnapshotIntervalDesc, prometheus.GaugeValue,
+					cadence.MedianInterval.Seconds(), h.Group, h.Target, kind)
+			}
+		}
+		stalled := 0.0
+		if h.Stalled {
+			stalled = 1.0
+		}
+		metrics <- prometheus.MustNewConstMetric(targetStalledDesc, prometheus.GaugeValue, stalled, h.Group, h.Target)
+	}
+}
@@ -17,23 +17,35 @@ package scraper
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
 	"time"
 
+	"github.com/go-resty/resty/v2"
 	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
 	"go.blockdaemon.com/solana/cluster-manager/types"
 )
 
 // Prober checks snapshot info from Solana nodes.
 type Prober struct {
-	client  *http.Client
-	scheme  string
-	apiPath string
-	header  http.Header
+	client       *http.Client
+	streamClient *http.Client
+	scheme       string
+	apiPath      string
+	header       http.Header
+	federated    bool // targets are trackers
 }
 
+// Default scrape settings of target groups.
+const (
+	defaultScrapeTimeout = 10 * time.Second
+	defaultMaxRedirects  = 1
+	maxRedirects         = 10
+)
+
 func NewProber(group *types.TargetGroup) (*Prober, error) {
 	var tlsConfig *tls.Config
 	if group.TLSConfig != nil {
@@ -43,8 +55,17 @@ func NewProber(group *types.TargetGroup) (*Prober, error) {
 			return nil, err
 		}
 	}
+	if group.TLSServerName != "" {
+		if tlsConfig == nil {
+			tlsConfig = new(tls.Config)
+		}
+		tlsConfig.ServerName = group.TLSServerName
+	}
 
 	header := make(http.Header)
+	for name, value := range group.Headers {
+		header.Set(name, value)
+	}
 	if group.BasicAuth != nil {
 		group.BasicAuth.Apply(header)
 	}
@@ -52,44 +73,101 @@ func NewProber(group *types.TargetGroup) (*Prober, error) {
 		group.BearerAuth.Apply(header)
 	}
 
+	proxy := http.ProxyFromEnvironment
+	if group.ProxyURL != "" {
+		proxyURL, err := url.Parse(group.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 5 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		MaxIdleConnsPerHost:   1,
+		MaxConnsPerHost:       3,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+	redirects := defaultMaxRedirects
+	if group.FollowRedirects != nil {
+		redirects = 0
+		if *group.FollowRedirects {
+			redirects = maxRedirects
+		}
+	}
+	checkRedirect := func(req *http.Request, via []*http.Request) error {
+		if len(via) <= redirects {
+			return nil
+		}
+		return http.ErrUseLastResponse
+	}
+	timeout := group.ScrapeTimeout
+	if timeout == 0 {
+		timeout = defaultScrapeTimeout
+	}
 	client := &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout:   5 * time.Second,
-				KeepAlive: 5 * time.Second,
-			}).DialContext,
-			TLSClientConfig:       tlsConfig,
-			MaxIdleConnsPerHost:   1,
-			MaxConnsPerHost:       3,
-			IdleConnTimeout:       90 * time.Second,
-			TLSHandshakeTimeout:   5 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-			ForceAttemptHTTP2:     true,
-		},
-		Timeout: 10 * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) == 1 {
-				return nil
-			}
-			return http.ErrUseLastResponse
-		},
+		Transport:     transport,
+		Timeout:       timeout,
+		CheckRedirect: checkRedirect,
+	}
+	// Long-lived streams must not be subject to the request timeout.
+	streamClient := &http.Client{
+		Transport:     transport,
+		CheckRedirect: checkRedirect,
 	}
 
 	return &Prober{
-		client:  client,
-		scheme:  group.Scheme,
-		apiPath: group.APIPath,
-		header:  header,
+		client:       client,
+		streamClient: streamClient,
+		scheme:       group.Scheme,
+		apiPath:      group.APIPath,
+		header:       header,
+		federated:    group.Federation != nil,
 	}, nil
 }
 
 // Probe fetches the snapshots of a single target.
 func (p *Prober) Probe(ctx context.Context, target string) ([]*types.SnapshotInfo, error) {
+	return p.newSidecarClient(target, p.client).ListSnapshots(ctx)
+}
+
+// ProbeTracker fetches the local snapshot sources of a federated tracker.
+func (p *Prober) ProbeTracker(ctx context.Context, target string) ([]*index.SnapshotEntry, error) {
+	entries, _, err := fetch.NewTrackerClientWithResty(p.newResty(target, p.client)).
+		ListSnapshots(ctx, &types.SnapshotQuery{LocalOnly: true})
+	return entries, err
+}
+
+// Federated returns whether the targets are trackers rather than sidecars.
+func (p *Prober) Federated() bool {
+	return p.federated
+}
+
+// StreamClient returns a sidecar client for long-lived streams from a single target.
+func (p *Prober) StreamClient(target string) *fetch.SidecarClient {
+	return p.newSidecarClient(target, p.streamClient)
+}
+
+func (p *Prober) newSidecarClient(target string, client *http.Client) *fetch.SidecarClient {
+	restyClient := p.newResty(target, client)
+	return fetch.NewSidecarClientWithOpts(restyClient.HostURL, fetch.SidecarClientOpts{Resty: restyClient})
+}
+
+func (p *Prober) newResty(target string, client *http.Client) *resty.Client {
 	u := url.URL{
 		Scheme: p.scheme,
 		Host:   target,
 		Path:   p.apiPath,
 	}
-	return fetch.NewSidecarClient(u.String()).ListSnapshots(ctx)
+	restyClient := resty.NewWithClient(client).SetHostURL(u.String())
+	restyClient.Header = p.header.Clone()
+	return restyClient
 }
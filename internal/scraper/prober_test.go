@@ -0,0 +1,91 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+func TestProber(t *testing.T) {
+	// Redirects /hop/<n>/v1/snapshots down to /v1/snapshots.
+	var hops int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/hop/") {
+			hops++
+			http.Redirect(w, r, "/v1/snapshots", http.StatusFound)
+			return
+		}
+		if r.Header.Get("X-Cluster") != "mainnet" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("content-type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	target := strings.TrimPrefix(server.URL, "http://")
+	follow, noFollow := true, false
+
+	cases := []struct {
+		name    string
+		group   types.TargetGroup
+		wantErr bool
+	}{
+		{
+			name:  "Headers",
+			group: types.TargetGroup{Headers: map[string]string{"x-cluster": "mainnet"}},
+		},
+		{
+			name:    "MissingHeaders",
+			group:   types.TargetGroup{},
+			wantErr: true,
+		},
+		{
+			name:  "DefaultRedirects",
+			group: types.TargetGroup{APIPath: "/hop/1", Headers: map[string]string{"x-cluster": "mainnet"}},
+		},
+		{
+			name:  "FollowRedirects",
+			group: types.TargetGroup{APIPath: "/hop/1", FollowRedirects: &follow, Headers: map[string]string{"x-cluster": "mainnet"}},
+		},
+		{
+			name:    "NoFollowRedirects",
+			group:   types.TargetGroup{APIPath: "/hop/1", FollowRedirects: &noFollow, Headers: map[string]string{"x-cluster": "mainnet"}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.group.Scheme = "http"
+			prober, err := NewProber(&tc.group)
+			require.NoError(t, err)
+			_, err = prober.Probe(context.TODO(), target)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+	assert.Equal(t, 3, hops)
+}
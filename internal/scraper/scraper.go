@@ -20,10 +20,16 @@ import (
 	"time"
 
 	"go.blockdaemon.com/solana/cluster-manager/internal/discovery"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
 	"go.uber.org/zap"
 )
 
 type Scraper struct {
+	Group      string
+	Source     string // discovery mechanism
+	Observers  []TargetObserver
+	Filter     func(group, target string) bool // selects targets to probe, nil probes all
 	prober     *Prober
 	discoverer discovery.Discoverer
 	rootCtx    context.Context
@@ -33,6 +39,16 @@ type Scraper struct {
 	Log *zap.Logger
 }
 
+// TargetObserver gets notified of the targets found by each service discovery run.
+type TargetObserver interface {
+	ObserveTargets(group string, prober *Prober, targets []string)
+	// RemoveGroup is called after a group has stopped scraping due to a config change.
+	RemoveGroup(group string)
+	// ResetGroup is called after the scraper of a group was replaced due to a config change.
+	// Scraped state should be kept, except for targets the new config no longer discovers.
+	ResetGroup(group string)
+}
+
 func NewScraper(prober *Prober, discoverer discovery.Discoverer) *Scraper {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Scraper{
@@ -63,7 +79,11 @@ func (s *Scraper) run(results chan<- ProbeResult, interval time.Duration) {
 	defer ticker.Stop()
 	for {
 		ctx, cancel := context.WithCancel(s.rootCtx)
-		go s.scrape(ctx, results)
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.scrape(ctx, results)
+		}()
 
 		select {
 		case <-s.rootCtx.Done():
@@ -79,9 +99,24 @@ func (s *Scraper) scrape(ctx context.Context, results chan<- ProbeResult) {
 	discoveryStart := time.Now()
 	targets, err := s.discoverer.DiscoverTargets(ctx)
 	if err != nil {
+		discoveryErrors.WithLabelValues(s.Group).Inc()
 		s.Log.Error("Service discovery failed", zap.Error(err))
 		return
 	}
+	discoveryDuration.WithLabelValues(s.Group).Observe(time.Since(discoveryStart).Seconds())
+
+	for _, observer := range s.Observers {
+		observer.ObserveTargets(s.Group, s.prober, targets)
+	}
+	if s.Filter != nil {
+		owned := targets[:0:0]
+		for _, target := range targets {
+			if s.Filter(s.Group, target) {
+				owned = append(owned, target)
+			}
+		}
+		targets = owned
+	}
 
 	scrapeStart := time.Now()
 	s.Log.Debug("Scrape starting",
@@ -93,12 +128,28 @@ func (s *Scraper) scrape(ctx context.Context, results chan<- ProbeResult) {
 	for _, target := range targets {
 		go func(target string) {
 			defer wg.Done()
-			infos, err := s.prober.Probe(ctx, target)
+			start := time.Now()
+			var (
+				infos   []*types.SnapshotInfo
+				entries []*index.SnapshotEntry
+				err     error
+			)
+			if s.prober.Federated() {
+				entries, err = s.prober.ProbeTracker(ctx, target)
+			} else {
+				infos, err = s.prober.Probe(ctx, target)
+			}
+			scrapeDuration.WithLabelValues(s.Group).Observe(time.Since(start).Seconds())
 			results <- ProbeResult{
-				Time:   time.Now(),
-				Target: target,
-				Infos:  infos,
-				Err:    err,
+				Time:      time.Now(),
+				Duration:  time.Since(start),
+				Group:     s.Group,
+				Source:    s.Source,
+				Target:    target,
+				Infos:     infos,
+				Entries:   entries,
+				Federated: s.prober.Federated(),
+				Err:       err,
 			}
 		}(target)
 	}
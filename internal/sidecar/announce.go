@@ -0,0 +1,110 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap"
+)
+
+// Announcer pushes the snapshot list to trackers whenever it changes.
+//
+// The ledger dir is polled at a short interval.
+// Trackers that fail to accept an announcement are retried on the next poll.
+// Trackers keep scraping periodically, so lost announcements only delay discovery.
+type Announcer struct {
+	Snapshots func() ([]*types.SnapshotInfo, error)
+	Target    string
+	Trackers  map[string]*fetch.TrackerClient // URL => client
+	Interval  time.Duration
+	Log       *zap.Logger
+
+	sent map[string][sha256.Size]byte // URL => fingerprint of last accepted announcement
+}
+
+// NewAnnouncer creates an announcer reporting the snapshots of the given handler.
+func NewAnnouncer(snapshots *SnapshotHandler, target string, trackers map[string]*fetch.TrackerClient, log *zap.Logger) *Announcer {
+	return &Announcer{
+		Snapshots: snapshots.Snapshots,
+		Target:    target,
+		Trackers:  trackers,
+		Interval:  2 * time.Second,
+		Log:       log,
+		sent:      make(map[string][sha256.Size]byte),
+	}
+}
+
+// Run announces snapshot changes until the context is cancelled.
+func (a *Announcer) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+	for {
+		a.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Announcer) poll(ctx context.Context) {
+	infos, err := a.Snapshots()
+	if err != nil {
+		a.Log.Warn("Failed to list snapshots", zap.Error(err))
+		return
+	}
+	fingerprint := snapshotsFingerprint(infos)
+	announcement := &types.SnapshotAnnouncement{Snapshots: infos}
+	for url, client := range a.Trackers {
+		if sent, ok := a.sent[url]; ok && sent == fingerprint {
+			continue
+		}
+		if err := client.Announce(ctx, a.Target, announcement); err != nil {
+			a.Log.Warn("Failed to announce snapshots", zap.String("tracker", url), zap.Error(err))
+			continue
+		}
+		a.Log.Debug("Announced snapshots", zap.String("tracker", url), zap.Int("num_snapshots", len(infos)))
+		a.sent[url] = fingerprint
+	}
+}
+
+// snapshotsFingerprint summarizes the parts of a snapshot list trackers care about.
+func snapshotsFingerprint(infos []*types.SnapshotInfo) (sum [sha256.Size]byte) {
+	h := sha256.New()
+	var buf [8]byte
+	for _, info := range infos {
+		for _, file := range info.Files {
+			_, _ = h.Write([]byte(file.FileName))
+			binary.LittleEndian.PutUint64(buf[:], file.Size)
+			_, _ = h.Write(buf[:])
+		}
+		if info.Draining {
+			_, _ = h.Write([]byte{1})
+		}
+		if info.SnapshotMetadata != nil {
+			_, _ = h.Write([]byte{2})
+		}
+		_, _ = h.Write([]byte{0})
+	}
+	h.Sum(sum[:0])
+	return
+}
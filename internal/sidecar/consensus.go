@@ -17,23 +17,26 @@ package sidecar
 import (
 	"io"
 	"net/http"
+	"strconv"
 
-	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 // ConsensusHandler implements the consensus-related sidecar API methods.
 type ConsensusHandler struct {
-	RpcWsUrl string
-	Log      *zap.Logger
+	Hub *SlotUpdateHub
+	Log *zap.Logger
 }
 
 // NewConsensusHandler creates a new sidecar consensus API handler using the provided WS RPC and logger.
+//
+// The caller is responsible for running the returned handler's Hub.
 func NewConsensusHandler(rpcWsUrl string, log *zap.Logger) *ConsensusHandler {
 	return &ConsensusHandler{
-		RpcWsUrl: rpcWsUrl,
-		Log:      log,
+		Hub: NewSlotUpdateHub(rpcWsUrl, log.Named("slot_updates")),
+		Log: log,
 	}
 }
 
@@ -43,37 +46,45 @@ func (h *ConsensusHandler) RegisterHandlers(group gin.IRoutes) {
 }
 
 // GetSlotUpdates streams RPC "slotsUpdatesSubscribe" events via SSE.
+//
+// Clients may resume a stream by passing the last seen event ID
+// in the "Last-Event-ID" header.
 func (h *ConsensusHandler) GetSlotUpdates(c *gin.Context) {
-	ctx := c.Request.Context()
-
-	conn, err := ws.Connect(ctx, h.RpcWsUrl)
-	if err != nil {
-		h.Log.Error("Failed to connect to Solana RPC WebSocket", zap.Error(err))
-		c.AbortWithStatus(http.StatusBadGateway)
-		return
+	var lastID uint64
+	if lastIDStr := c.GetHeader("Last-Event-ID"); lastIDStr != "" {
+		var err error
+		lastID, err = strconv.ParseUint(lastIDStr, 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
 	}
-	defer conn.Close()
 
-	go func() {
-		<-ctx.Done()
-		conn.Close()
-	}()
+	ctx := c.Request.Context()
+	backlog, sub := h.Hub.Subscribe(lastID)
+	defer sub.Close()
 
-	slotUpdates, err := conn.SlotsUpdatesSubscribe()
-	if err != nil {
-		h.Log.Error("Failed to connect to subscribe to slot updates", zap.Error(err))
-		c.AbortWithStatus(http.StatusServiceUnavailable)
-		return
+	for _, event := range backlog {
+		renderSlotUpdate(c, event)
 	}
-	defer slotUpdates.Unsubscribe()
-
-	c.Stream(func(w io.Writer) bool {
-		update, err := slotUpdates.Recv(c)
-		if err != nil {
-			h.Log.Error("Failed to receive slot update event", zap.Error(err))
+	c.Stream(func(_ io.Writer) bool {
+		select {
+		case <-ctx.Done():
 			return false
+		case event, ok := <-sub.Events():
+			if !ok {
+				return false
+			}
+			renderSlotUpdate(c, event)
+			return true
 		}
-		c.SSEvent("slot_update", update)
-		return true
+	})
+}
+
+func renderSlotUpdate(c *gin.Context, event SlotUpdateEvent) {
+	c.Render(-1, sse.Event{
+		Event: "slot_update",
+		Id:    strconv.FormatUint(event.ID, 10),
+		Data:  event.Update,
 	})
 }
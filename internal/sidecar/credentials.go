@@ -0,0 +1,131 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/atomic"
+)
+
+// Credentials holds the TLS certificate and client auth settings of the sidecar.
+// All of them can be reloaded from disk while the server is running.
+type Credentials struct {
+	CertFile       string // TLS disabled if empty
+	KeyFile        string
+	AuthFile       string // client auth disabled if empty
+	AdminTokenFile string // admin endpoints disabled if empty
+
+	cert  atomic.Pointer[tls.Certificate]
+	auth  atomic.Pointer[types.ServerAuth]
+	admin atomic.Pointer[string]
+}
+
+// Reload reads all configured files.
+// If any file fails to load, the previous settings remain in effect.
+func (c *Credentials) Reload() error {
+	var cert *tls.Certificate
+	if c.CertFile != "" || c.KeyFile != "" {
+		loaded, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS cert and key: %w", err)
+		}
+		cert = &loaded
+	}
+	auth := new(types.ServerAuth)
+	if c.AuthFile != "" {
+		var err error
+		auth, err = types.LoadServerAuth(c.AuthFile)
+		if err != nil {
+			return fmt.Errorf("failed to load auth file: %w", err)
+		}
+	}
+	var admin string
+	if c.AdminTokenFile != "" {
+		buf, err := os.ReadFile(c.AdminTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to load admin token: %w", err)
+		}
+		if admin = strings.TrimSpace(string(buf)); admin == "" {
+			return fmt.Errorf("admin token file is empty")
+		}
+	}
+	c.cert.Store(cert)
+	c.auth.Store(auth)
+	c.admin.Store(&admin)
+	return nil
+}
+
+// TLSConfig returns a server TLS config always serving the latest certificate.
+// Returns nil if TLS is disabled.
+func (c *Credentials) TLSConfig() *tls.Config {
+	if c.CertFile == "" {
+		return nil
+	}
+	return &tls.Config{
+		GetCertificate: func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return c.cert.Load(), nil
+		},
+	}
+}
+
+// Middleware rejects requests lacking valid credentials.
+// The admin token is accepted as well.
+func (c *Credentials) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		auth := c.auth.Load()
+		if auth != nil && !auth.Check(ctx.Request) && !c.isAdmin(ctx.Request) {
+			ctx.Header("www-authenticate", `Basic realm="sidecar"`)
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequireAdmin rejects requests lacking the admin token.
+// Admin endpoints are forbidden if no admin token is configured.
+func (c *Credentials) RequireAdmin() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if admin := c.admin.Load(); admin == nil || *admin == "" {
+			ctx.String(http.StatusForbidden, "admin endpoints disabled")
+			ctx.Abort()
+			return
+		}
+		if !c.isAdmin(ctx.Request) {
+			ctx.Header("www-authenticate", `Bearer realm="sidecar"`)
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// isAdmin returns whether the request carries the admin token as bearer token.
+func (c *Credentials) isAdmin(req *http.Request) bool {
+	admin := c.admin.Load()
+	if admin == nil || *admin == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(req.Header.Get("authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(*admin)) == 1
+}
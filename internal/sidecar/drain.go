@@ -0,0 +1,92 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"context"
+	"sync"
+)
+
+// Drainer tracks in-flight downloads.
+//
+// Once draining, new downloads are rejected,
+// allowing active ones to complete before the sidecar shuts down.
+type Drainer struct {
+	lock     sync.Mutex
+	draining bool
+	active   int
+	idle     chan struct{} // closed when draining and no downloads are active
+}
+
+// NewDrainer creates a new drainer that accepts downloads.
+func NewDrainer() *Drainer {
+	return &Drainer{idle: make(chan struct{})}
+}
+
+// Acquire registers a new download.
+// Returns false if the sidecar is draining, in which case the download must be rejected.
+func (d *Drainer) Acquire() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.draining {
+		return false
+	}
+	d.active++
+	return true
+}
+
+// Release marks a download acquired earlier as finished.
+func (d *Drainer) Release() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.active--
+	if d.draining && d.active == 0 {
+		close(d.idle)
+	}
+}
+
+// Drain stops accepting new downloads and waits until active ones finish.
+// Returns the context error if the deadline hits first.
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.lock.Lock()
+	if !d.draining {
+		d.draining = true
+		if d.active == 0 {
+			close(d.idle)
+		}
+	}
+	d.lock.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-d.idle:
+		return nil
+	}
+}
+
+// Draining returns whether the sidecar stopped accepting new downloads.
+func (d *Drainer) Draining() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.draining
+}
+
+// Active returns the number of in-flight downloads.
+func (d *Drainer) Active() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.active
+}
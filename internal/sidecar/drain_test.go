@@ -0,0 +1,70 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/internal/ledgertest"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestDrainer(t *testing.T) {
+	d := NewDrainer()
+	require.True(t, d.Acquire())
+	require.True(t, d.Acquire())
+	assert.Equal(t, 2, d.Active())
+
+	// Drain times out while downloads are active.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, d.Drain(ctx), context.DeadlineExceeded)
+	assert.True(t, d.Draining())
+	assert.False(t, d.Acquire())
+
+	d.Release()
+	d.Release()
+	assert.NoError(t, d.Drain(context.Background()))
+}
+
+func TestHandler_Draining(t *testing.T) {
+	const name = "snapshot-100-7jMmeXZSNcWPrB2RsTdeXfXrsyW5c1BfPjqoLW2X5T7V.tar.bz2"
+	root := ledgertest.NewFS(t)
+	root.AddFakeFile(t, name)
+	h := &SnapshotHandler{
+		LedgerDir: root.GetLedgerDir(t),
+		Log:       zaptest.NewLogger(t),
+		Drainer:   NewDrainer(),
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/snapshot/"+name, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, testRequest(h, req).Code)
+
+	require.NoError(t, h.Drainer.Drain(context.Background()))
+	assert.Equal(t, http.StatusServiceUnavailable, testRequest(h, req).Code)
+
+	req, err = http.NewRequest(http.MethodGet, "/snapshots", nil)
+	require.NoError(t, err)
+	res := testRequest(h, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "draining", res.Header().Get(StatusHeader))
+	assert.Contains(t, res.Body.String(), `"draining":true`)
+}
@@ -0,0 +1,232 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HoldManager protects snapshots from being deleted during downloads.
+//
+// The validator prunes old snapshot archives on its own schedule.
+// While a snapshot is held, a hardlink to it is kept in a private hold dir,
+// so resumed and ranged requests keep working after the original is deleted.
+// Holds are released after a grace period once the last download finishes.
+type HoldManager struct {
+	LedgerPath string
+	HoldPath   string // private subdirectory of the hold dir, on the same file system as LedgerPath
+	Grace      time.Duration
+	Log        *zap.Logger
+
+	lock  sync.Mutex
+	holds map[string]*hold
+}
+
+type hold struct {
+	refs   int
+	pinned bool
+	expiry *time.Timer
+}
+
+// HoldInfo describes an active hold.
+type HoldInfo struct {
+	FileName string `json:"file_name"`
+	Active   int    `json:"active_downloads"`
+	Pinned   bool   `json:"pinned"`
+}
+
+// holdSubdir is created inside the hold dir and owned exclusively by the sidecar.
+const holdSubdir = "solana-sidecar-holds"
+
+// NewHoldManager creates a private subdirectory in the hold dir and removes leftover holds of previous runs.
+// Nothing else in the hold dir is touched.
+// Fails if snapshots cannot be hardlinked into the hold dir, e.g. because it is on another file system.
+func NewHoldManager(ledgerPath, holdDir string, grace time.Duration, log *zap.Logger) (*HoldManager, error) {
+	holdPath := filepath.Join(holdDir, holdSubdir)
+	if err := os.RemoveAll(holdPath); err != nil {
+		return nil, fmt.Errorf("failed to clean hold dir: %w", err)
+	}
+	if err := os.MkdirAll(holdPath, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create hold dir: %w", err)
+	}
+	if err := probeLink(ledgerPath, holdPath); err != nil {
+		return nil, fmt.Errorf("cannot hold snapshots in %s (must be on the same file system as the ledger dir): %w", holdDir, err)
+	}
+	return &HoldManager{
+		LedgerPath: ledgerPath,
+		HoldPath:   holdPath,
+		Grace:      grace,
+		Log:        log,
+		holds:      make(map[string]*hold),
+	}, nil
+}
+
+// probeLink hardlinks a file of the ledger dir into the hold path and removes the link again.
+// A temporary file is created in the ledger dir if it holds no regular files yet.
+func probeLink(ledgerPath, holdPath string) error {
+	var source string
+	entries, err := os.ReadDir(ledgerPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			source = filepath.Join(ledgerPath, entry.Name())
+			break
+		}
+	}
+	if source == "" {
+		f, err := os.CreateTemp(ledgerPath, ".hold-probe-*")
+		if err != nil {
+			return err
+		}
+		source = f.Name()
+		f.Close()
+		defer os.Remove(source)
+	}
+	target := filepath.Join(holdPath, ".probe")
+	if err := os.Link(source, target); err != nil {
+		return err
+	}
+	return os.Remove(target)
+}
+
+// Acquire holds a snapshot for the duration of a download.
+// The returned function must be called once the download finishes.
+// Returns fs.ErrNotExist if the snapshot is neither in the ledger dir nor held.
+func (h *HoldManager) Acquire(name string) (release func(), err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	entry, err := h.ensureHold(name)
+	if err != nil {
+		return nil, err
+	}
+	entry.refs++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			h.lock.Lock()
+			defer h.lock.Unlock()
+			entry.refs--
+			h.scheduleExpiry(name, entry)
+		})
+	}, nil
+}
+
+// Open opens the held copy of a snapshot.
+func (h *HoldManager) Open(name string) (*os.File, error) {
+	return os.Open(filepath.Join(h.HoldPath, name))
+}
+
+// Pin holds a snapshot until unpinned.
+func (h *HoldManager) Pin(name string) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	entry, err := h.ensureHold(name)
+	if err != nil {
+		return err
+	}
+	entry.pinned = true
+	h.Log.Info("Pinned snapshot", zap.String("snapshot", name))
+	return nil
+}
+
+// Unpin removes an explicit pin. The hold expires after the grace period once downloads finish.
+// Returns fs.ErrNotExist if the snapshot was not pinned.
+func (h *HoldManager) Unpin(name string) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	entry, ok := h.holds[name]
+	if !ok || !entry.pinned {
+		return fs.ErrNotExist
+	}
+	entry.pinned = false
+	h.scheduleExpiry(name, entry)
+	h.Log.Info("Unpinned snapshot", zap.String("snapshot", name))
+	return nil
+}
+
+// List returns all active holds sorted by name.
+func (h *HoldManager) List() []HoldInfo {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	infos := make([]HoldInfo, 0, len(h.holds))
+	for name, entry := range h.holds {
+		infos = append(infos, HoldInfo{
+			FileName: name,
+			Active:   entry.refs,
+			Pinned:   entry.pinned,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].FileName < infos[j].FileName
+	})
+	return infos
+}
+
+// ensureHold returns the hold of a snapshot, creating it if necessary. Requires lock.
+func (h *HoldManager) ensureHold(name string) (*hold, error) {
+	if entry, ok := h.holds[name]; ok {
+		if entry.expiry != nil {
+			entry.expiry.Stop()
+			entry.expiry = nil
+		}
+		return entry, nil
+	}
+	err := os.Link(filepath.Join(h.LedgerPath, name), filepath.Join(h.HoldPath, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fs.ErrNotExist
+	} else if err != nil && !errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("failed to hold snapshot: %w", err)
+	}
+	h.Log.Debug("Holding snapshot", zap.String("snapshot", name))
+	entry := new(hold)
+	h.holds[name] = entry
+	return entry, nil
+}
+
+// scheduleExpiry releases an unused hold after the grace period. Requires lock.
+func (h *HoldManager) scheduleExpiry(name string, entry *hold) {
+	if entry.refs > 0 || entry.pinned {
+		return
+	}
+	if entry.expiry != nil {
+		entry.expiry.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(h.Grace, func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		if h.holds[name] != entry || entry.expiry != timer {
+			return // hold was reacquired in the meantime
+		}
+		delete(h.holds, name)
+		if err := os.Remove(filepath.Join(h.HoldPath, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			h.Log.Warn("Failed to release snapshot hold", zap.String("snapshot", name), zap.Error(err))
+			return
+		}
+		h.Log.Debug("Released snapshot hold", zap.String("snapshot", name))
+	})
+	entry.expiry = timer
+}
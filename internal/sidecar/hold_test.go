@@ -0,0 +1,198 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const holdTestSnapshot = "snapshot-100-7jMmeXZSNcWPrB2RsTdeXfXrsyW5c1BfPjqoLW2X5T7V.tar.zst"
+
+func newHoldTest(t *testing.T, grace time.Duration) (ledgerPath string, h *HoldManager) {
+	ledgerPath = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(ledgerPath, holdTestSnapshot), []byte("snapshot"), 0o644))
+	h, err := NewHoldManager(ledgerPath, filepath.Join(ledgerPath, ".sidecar-hold"), grace, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return
+}
+
+func TestHoldManager_Acquire(t *testing.T) {
+	ledgerPath, h := newHoldTest(t, 20*time.Millisecond)
+
+	_, err := h.Acquire("snapshot-404.tar.zst")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	release, err := h.Acquire(holdTestSnapshot)
+	require.NoError(t, err)
+
+	// Validator prunes the snapshot mid-download.
+	require.NoError(t, os.Remove(filepath.Join(ledgerPath, holdTestSnapshot)))
+	f, err := h.Open(holdTestSnapshot)
+	require.NoError(t, err)
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "snapshot", string(content))
+
+	// Resumed download within grace period.
+	release()
+	release2, err := h.Acquire(holdTestSnapshot)
+	require.NoError(t, err)
+	release2()
+	assert.Equal(t, []HoldInfo{{FileName: holdTestSnapshot}}, h.List())
+
+	// Hold expires after grace period.
+	assert.Eventually(t, func() bool {
+		return len(h.List()) == 0
+	}, time.Second, 5*time.Millisecond)
+	_, err = h.Open(holdTestSnapshot)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestHoldManager_Pin(t *testing.T) {
+	ledgerPath, h := newHoldTest(t, time.Millisecond)
+
+	assert.ErrorIs(t, h.Unpin(holdTestSnapshot), fs.ErrNotExist)
+	require.NoError(t, h.Pin(holdTestSnapshot))
+	require.NoError(t, os.Remove(filepath.Join(ledgerPath, holdTestSnapshot)))
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, []HoldInfo{{FileName: holdTestSnapshot, Pinned: true}}, h.List())
+
+	// Pinned snapshots can still be downloaded after pruning.
+	handler := &SnapshotHandler{
+		LedgerDir: os.DirFS(ledgerPath),
+		Log:       zaptest.NewLogger(t),
+		Holds:     h,
+	}
+	req, err := http.NewRequest(http.MethodGet, "/snapshot/"+holdTestSnapshot, nil)
+	require.NoError(t, err)
+	req.Header.Set("range", "bytes=4-")
+	res := testRequest(handler, req)
+	assert.Equal(t, http.StatusPartialContent, res.Code)
+	assert.Equal(t, "shot", res.Body.String())
+
+	// Unpinning requires the admin token.
+	tokenFile := filepath.Join(t.TempDir(), "admin-token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("admin-token\n"), 0o600))
+	creds := &Credentials{AdminTokenFile: tokenFile}
+	require.NoError(t, creds.Reload())
+	router := gin.New()
+	handler.RegisterAdminHandlers(router.Group("", creds.RequireAdmin()))
+	unpin := func(token string) int {
+		req, err := http.NewRequest(http.MethodDelete, "/pins/"+holdTestSnapshot, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, unpin(""))
+	assert.Equal(t, http.StatusUnauthorized, unpin("wrong"))
+	assert.Equal(t, []HoldInfo{{FileName: holdTestSnapshot, Pinned: true}}, h.List())
+	assert.Equal(t, http.StatusNoContent, unpin("admin-token"))
+	assert.Eventually(t, func() bool {
+		return len(h.List()) == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestNewHoldManager_KeepsHoldDir(t *testing.T) {
+	holdDir := t.TempDir()
+	unrelated := filepath.Join(holdDir, "unrelated.txt")
+	require.NoError(t, os.WriteFile(unrelated, []byte("keep me"), 0o644))
+
+	h, err := NewHoldManager(t.TempDir(), holdDir, time.Minute, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(holdDir, holdSubdir), h.HoldPath)
+	leftover := filepath.Join(h.HoldPath, holdTestSnapshot)
+	require.NoError(t, os.WriteFile(leftover, []byte("snapshot"), 0o644))
+
+	// Restarting clears only leftover holds.
+	_, err = NewHoldManager(t.TempDir(), holdDir, time.Minute, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	assert.FileExists(t, unrelated)
+	assert.NoFileExists(t, leftover)
+}
+
+func TestNewHoldManager_OtherFileSystem(t *testing.T) {
+	holdDir, err := os.MkdirTemp("/dev/shm", "hold-test-")
+	if err != nil {
+		t.Skip("no /dev/shm:", err)
+	}
+	defer os.RemoveAll(holdDir)
+	ledgerPath := t.TempDir()
+	ledgerInfo, err := os.Stat(ledgerPath)
+	require.NoError(t, err)
+	holdInfo, err := os.Stat(holdDir)
+	require.NoError(t, err)
+	if ledgerInfo.Sys().(*syscall.Stat_t).Dev == holdInfo.Sys().(*syscall.Stat_t).Dev {
+		t.Skip("/dev/shm is on the same file system as the temp dir")
+	}
+
+	_, err = NewHoldManager(ledgerPath, holdDir, time.Minute, zaptest.NewLogger(t))
+	assert.ErrorIs(t, err, syscall.EXDEV)
+	entries, err := os.ReadDir(ledgerPath)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "probe file is removed")
+}
+
+func TestSnapshotHandler_HoldFailure(t *testing.T) {
+	ledgerPath, h := newHoldTest(t, time.Minute)
+	// Break holds after startup.
+	h.HoldPath = filepath.Join(ledgerPath, holdTestSnapshot)
+	handler := &SnapshotHandler{
+		LedgerDir: os.DirFS(ledgerPath),
+		Log:       zaptest.NewLogger(t),
+		Holds:     h,
+	}
+
+	// Downloads are served from the ledger dir instead.
+	req, err := http.NewRequest(http.MethodGet, "/snapshot/"+holdTestSnapshot, nil)
+	require.NoError(t, err)
+	res := testRequest(handler, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "snapshot", res.Body.String())
+	assert.Empty(t, h.List())
+
+	req, err = http.NewRequest(http.MethodGet, "/snapshot/snapshot-404-7jMmeXZSNcWPrB2RsTdeXfXrsyW5c1BfPjqoLW2X5T7V.tar.zst", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, testRequest(handler, req).Code)
+}
+
+func TestCredentials_RequireAdmin_Disabled(t *testing.T) {
+	creds := new(Credentials)
+	require.NoError(t, creds.Reload())
+	router := gin.New()
+	router.PUT("/pins/:name", creds.RequireAdmin(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	req, err := http.NewRequest(http.MethodPut, "/pins/"+holdTestSnapshot, nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"io/fs"
+	"sync"
+	"time"
+
+	"go.blockdaemon.com/solana/cluster-manager/internal/ledger"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap"
+)
+
+// MetadataCache remembers metadata read from snapshot archives.
+//
+// Reading metadata requires decompressing the start of an archive,
+// so lookups never block: Missing entries are read in the background.
+// Failed reads are retried with exponential backoff.
+type MetadataCache struct {
+	LedgerDir  fs.FS
+	Log        *zap.Logger
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	lock    sync.Mutex
+	entries map[string]*metadataEntry
+	queue   chan *types.SnapshotFile
+	read    func(fs.FS, *types.SnapshotFile) (*types.SnapshotMetadata, error)
+}
+
+type metadataEntry struct {
+	size     uint64
+	modTime  time.Time
+	meta     *types.SnapshotMetadata // nil if pending or failed
+	failures int
+	retryAt  time.Time // zero unless failed
+}
+
+// NewMetadataCache creates an empty cache and starts its background reader.
+func NewMetadataCache(ledgerDir fs.FS, log *zap.Logger) *MetadataCache {
+	c := &MetadataCache{
+		LedgerDir:  ledgerDir,
+		Log:        log,
+		MinBackoff: 10 * time.Second,
+		MaxBackoff: 10 * time.Minute,
+		entries:    make(map[string]*metadataEntry),
+		queue:      make(chan *types.SnapshotFile, 64),
+		read:       ledger.ReadSnapshotMetadata,
+	}
+	go c.run()
+	return c
+}
+
+// Fill attaches cached metadata to the given snapshots.
+// Unknown snapshot files, and failed ones whose backoff expired, are queued for reading.
+// Cache entries of files that are no longer listed are evicted.
+func (c *MetadataCache) Fill(infos []*types.SnapshotInfo) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	seen := make(map[string]struct{}, len(infos))
+	for _, info := range infos {
+		if len(info.Files) == 0 {
+			continue
+		}
+		file := info.Files[0] // top of snapshot chain
+		seen[file.FileName] = struct{}{}
+		entry, ok := c.entries[file.FileName]
+		if ok && entry.size == file.Size && (file.ModTime == nil || entry.modTime.Equal(*file.ModTime)) {
+			info.SnapshotMetadata = entry.meta
+			if entry.retryAt.IsZero() || now.Before(entry.retryAt) {
+				continue
+			}
+		} else {
+			entry = &metadataEntry{size: file.Size}
+			if file.ModTime != nil {
+				entry.modTime = *file.ModTime
+			}
+		}
+		select {
+		case c.queue <- file:
+			entry.retryAt = time.Time{}
+			c.entries[file.FileName] = entry
+		default:
+			// Queue full, retry on next call.
+		}
+	}
+	for name := range c.entries {
+		if _, ok := seen[name]; !ok {
+			delete(c.entries, name)
+		}
+	}
+}
+
+func (c *MetadataCache) run() {
+	for file := range c.queue {
+		log := c.Log.With(zap.String("snapshot", file.FileName))
+		start := time.Now()
+		meta, err := c.read(c.LedgerDir, file)
+		if err != nil {
+			c.lock.Lock()
+			if entry, ok := c.entries[file.FileName]; ok {
+				entry.failures++
+				entry.retryAt = time.Now().Add(c.backoff(entry.failures))
+			}
+			c.lock.Unlock()
+			log.Warn("Failed to read snapshot metadata", zap.Error(err))
+			continue
+		}
+		log.Debug("Read snapshot metadata", zap.Duration("duration", time.Since(start)))
+
+		c.lock.Lock()
+		if entry, ok := c.entries[file.FileName]; ok {
+			entry.meta = meta
+		}
+		c.lock.Unlock()
+	}
+}
+
+// backoff returns the wait before retrying a file after the given number of failed reads.
+func (c *MetadataCache) backoff(failures int) time.Duration {
+	wait := c.MinBackoff
+	for i := 1; i < failures && wait < c.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > c.MaxBackoff {
+		wait = c.MaxBackoff
+	}
+	return wait
+}
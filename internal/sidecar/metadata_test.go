@@ -0,0 +1,65 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/atomic"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMetadataCache_Retry(t *testing.T) {
+	var reads atomic.Int32
+	c := NewMetadataCache(nil, zaptest.NewLogger(t))
+	c.MinBackoff = 10 * time.Millisecond
+	c.read = func(_ fs.FS, file *types.SnapshotFile) (*types.SnapshotMetadata, error) {
+		if reads.Inc() == 1 {
+			return nil, errors.New("truncated archive")
+		}
+		return &types.SnapshotMetadata{Version: "1.2.0"}, nil
+	}
+	fill := func() *types.SnapshotMetadata {
+		info := &types.SnapshotInfo{
+			Files: []*types.SnapshotFile{{FileName: "snapshot-100-abc.tar.zst", Size: 1}},
+		}
+		c.Fill([]*types.SnapshotInfo{info})
+		return info.SnapshotMetadata
+	}
+
+	assert.Nil(t, fill(), "read in the background")
+	assert.Eventually(t, func() bool {
+		return fill() != nil
+	}, 5*time.Second, time.Millisecond, "failed read is retried")
+	assert.Equal(t, int32(2), reads.Load())
+	assert.Equal(t, "1.2.0", fill().Version)
+	assert.Equal(t, int32(2), reads.Load(), "metadata stays cached")
+}
+
+func TestMetadataCache_Backoff(t *testing.T) {
+	c := NewMetadataCache(nil, zaptest.NewLogger(t))
+	c.MinBackoff = time.Second
+	c.MaxBackoff = 5 * time.Second
+	assert.Equal(t, time.Second, c.backoff(1))
+	assert.Equal(t, 2*time.Second, c.backoff(2))
+	assert.Equal(t, 4*time.Second, c.backoff(3))
+	assert.Equal(t, 5*time.Second, c.backoff(4))
+	assert.Equal(t, 5*time.Second, c.backoff(100))
+}
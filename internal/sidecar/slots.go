@@ -0,0 +1,221 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"go.uber.org/zap"
+)
+
+// SlotUpdateHub maintains a single "slotsUpdatesSubscribe" RPC subscription
+// and fans out its events to any number of subscribers.
+//
+// Each event gets a monotonically increasing ID, which keeps increasing across sidecar restarts.
+// The most recent events are kept in a replay buffer,
+// allowing clients to resume streams after reconnecting.
+type SlotUpdateHub struct {
+	RpcWsUrl string
+	Log      *zap.Logger
+
+	ClientBuffer int           // max queued events per subscriber before it gets dropped
+	ReplaySize   int           // number of recent events kept for resuming
+	MinBackoff   time.Duration // initial reconnect delay
+	MaxBackoff   time.Duration // max reconnect delay
+
+	subscribe func(ctx context.Context, handle func(*ws.SlotsUpdatesResult)) error
+
+	lock   sync.Mutex
+	nextID uint64
+	replay []SlotUpdateEvent // ring buffer
+	head   int               // index of oldest event in replay
+	subs   map[*SlotUpdateSub]struct{}
+}
+
+// SlotUpdateEvent is a slot update tagged with a stream-unique ID.
+type SlotUpdateEvent struct {
+	ID     uint64
+	Update *ws.SlotsUpdatesResult
+}
+
+// SlotUpdateSub receives events from a SlotUpdateHub.
+type SlotUpdateSub struct {
+	hub    *SlotUpdateHub
+	events chan SlotUpdateEvent
+}
+
+// NewSlotUpdateHub creates a new hub for the given Solana RPC PubSub endpoint.
+func NewSlotUpdateHub(rpcWsUrl string, log *zap.Logger) *SlotUpdateHub {
+	h := &SlotUpdateHub{
+		RpcWsUrl:     rpcWsUrl,
+		Log:          log,
+		ClientBuffer: 256,
+		ReplaySize:   1024,
+		MinBackoff:   500 * time.Millisecond,
+		MaxBackoff:   30 * time.Second,
+		nextID:       firstEventID(time.Now()),
+		subs:         make(map[*SlotUpdateSub]struct{}),
+	}
+	h.subscribe = h.subscribeRPC
+	return h
+}
+
+// firstEventID seeds event IDs with the process start time in the upper 32 bits.
+// IDs of a restarted sidecar thus exceed all IDs it handed out before,
+// as long as it publishes fewer than 2^32 events per second,
+// so a client resuming with an ID of the old process gets all buffered events instead of none.
+func firstEventID(start time.Time) uint64 {
+	return uint64(start.Unix())<<32 + 1
+}
+
+// Run keeps the RPC subscription alive until the context is cancelled.
+// Reconnects are delayed using exponential backoff.
+func (h *SlotUpdateHub) Run(ctx context.Context) {
+	backoff := h.MinBackoff
+	for {
+		start := time.Now()
+		err := h.subscribe(ctx, h.publish)
+		if ctx.Err() != nil {
+			h.closeAll()
+			return
+		}
+		// Reset backoff if the subscription was healthy for a while.
+		if time.Since(start) > h.MaxBackoff {
+			backoff = h.MinBackoff
+		}
+		h.Log.Warn("Slot update subscription failed, reconnecting",
+			zap.Error(err), zap.Duration("backoff", backoff))
+		select {
+		case <-ctx.Done():
+			h.closeAll()
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > h.MaxBackoff {
+			backoff = h.MaxBackoff
+		}
+	}
+}
+
+func (h *SlotUpdateHub) subscribeRPC(ctx context.Context, handle func(*ws.SlotsUpdatesResult)) error {
+	conn, err := ws.Connect(ctx, h.RpcWsUrl)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	sub, err := conn.SlotsUpdatesSubscribe()
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	h.Log.Info("Subscribed to slot updates")
+
+	for {
+		update, err := sub.Recv(ctx)
+		if err != nil {
+			return err
+		}
+		handle(update)
+	}
+}
+
+// Subscribe registers a new subscriber.
+//
+// If lastID is non-zero, the returned backlog contains all buffered events newer than lastID.
+// The backlog is consistent with the subscription, i.e. no events are skipped or duplicated.
+func (h *SlotUpdateHub) Subscribe(lastID uint64) (backlog []SlotUpdateEvent, sub *SlotUpdateSub) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if lastID != 0 {
+		for i := 0; i < len(h.replay); i++ {
+			event := h.replay[(h.head+i)%len(h.replay)]
+			if event.ID > lastID {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+	sub = &SlotUpdateSub{
+		hub:    h,
+		events: make(chan SlotUpdateEvent, h.ClientBuffer),
+	}
+	h.subs[sub] = struct{}{}
+	return
+}
+
+// Events returns the event channel. It gets closed when the subscriber is dropped.
+func (s *SlotUpdateSub) Events() <-chan SlotUpdateEvent {
+	return s.events
+}
+
+// Close unregisters the subscriber.
+func (s *SlotUpdateSub) Close() {
+	s.hub.lock.Lock()
+	defer s.hub.lock.Unlock()
+	s.hub.drop(s)
+}
+
+// NumSubscribers returns the number of active subscribers.
+func (h *SlotUpdateHub) NumSubscribers() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return len(h.subs)
+}
+
+func (h *SlotUpdateHub) publish(update *ws.SlotsUpdatesResult) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	event := SlotUpdateEvent{ID: h.nextID, Update: update}
+	h.nextID++
+
+	if h.ReplaySize > 0 {
+		if len(h.replay) < h.ReplaySize {
+			h.replay = append(h.replay, event)
+		} else {
+			h.replay[h.head] = event
+			h.head = (h.head + 1) % len(h.replay)
+		}
+	}
+
+	for sub := range h.subs {
+		select {
+		case sub.events <- event:
+		default:
+			h.Log.Info("Dropping slow slot update subscriber")
+			h.drop(sub)
+		}
+	}
+}
+
+// drop removes a subscriber. Requires lock.
+func (h *SlotUpdateHub) drop(sub *SlotUpdateSub) {
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.events)
+	}
+}
+
+func (h *SlotUpdateHub) closeAll() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for sub := range h.subs {
+		h.drop(sub)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSlotUpdateHub_FanOut(t *testing.T) {
+	hub := NewSlotUpdateHub("", zaptest.NewLogger(t))
+	hub.ReplaySize = 3
+	hub.ClientBuffer = 2
+
+	first := hub.nextID
+	_, sub1 := hub.Subscribe(0)
+	_, sub2 := hub.Subscribe(0)
+	assert.Equal(t, 2, hub.NumSubscribers())
+
+	hub.publish(&ws.SlotsUpdatesResult{Slot: 1})
+	hub.publish(&ws.SlotsUpdatesResult{Slot: 2})
+	assert.Equal(t, uint64(1), (<-sub1.Events()).Update.Slot)
+	assert.Equal(t, uint64(2), (<-sub1.Events()).Update.Slot)
+
+	// sub2 did not consume its buffer and gets dropped.
+	hub.publish(&ws.SlotsUpdatesResult{Slot: 3})
+	assert.Equal(t, 1, hub.NumSubscribers())
+	assert.Len(t, sub2.Events(), 2)
+
+	event := <-sub1.Events()
+	assert.Equal(t, first+2, event.ID)
+	sub1.Close()
+	sub2.Close()
+	assert.Equal(t, 0, hub.NumSubscribers())
+}
+
+func TestSlotUpdateHub_Replay(t *testing.T) {
+	hub := NewSlotUpdateHub("", zaptest.NewLogger(t))
+	hub.ReplaySize = 3
+	first := hub.nextID
+	for slot := uint64(1); slot <= 5; slot++ {
+		hub.publish(&ws.SlotsUpdatesResult{Slot: slot})
+	}
+
+	backlog, sub := hub.Subscribe(0)
+	assert.Empty(t, backlog)
+	sub.Close()
+
+	backlog, sub = hub.Subscribe(first + 2)
+	require.Len(t, backlog, 2)
+	assert.Equal(t, first+3, backlog[0].ID)
+	assert.Equal(t, first+4, backlog[1].ID)
+	sub.Close()
+
+	// Gap larger than replay buffer returns everything available.
+	backlog, sub = hub.Subscribe(first)
+	require.Len(t, backlog, 3)
+	assert.Equal(t, first+2, backlog[0].ID)
+	sub.Close()
+
+	// So does an ID handed out before a restart.
+	backlog, sub = hub.Subscribe(firstEventID(time.Now().Add(-time.Hour)) + 1000)
+	require.Len(t, backlog, 3)
+	sub.Close()
+}
+
+func TestFirstEventID(t *testing.T) {
+	start := time.Date(2022, 4, 27, 15, 33, 20, 0, time.UTC)
+	// A day of events at a high rate before restarting a second later.
+	last := firstEventID(start) + 86400*100_000
+	assert.Greater(t, firstEventID(start.Add(24*time.Hour+time.Second)), last)
+}
+
+func TestSlotUpdateHub_Reconnect(t *testing.T) {
+	hub := NewSlotUpdateHub("", zaptest.NewLogger(t))
+	hub.MinBackoff = time.Millisecond
+	hub.MaxBackoff = 5 * time.Millisecond
+
+	var attempts atomic.Int32
+	hub.subscribe = func(ctx context.Context, handle func(*ws.SlotsUpdatesResult)) error {
+		handle(&ws.SlotsUpdatesResult{Slot: uint64(attempts.Inc())})
+		return errors.New("connection reset")
+	}
+	_, sub := hub.Subscribe(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hub.Run(ctx)
+	}()
+
+	for slot := uint64(1); slot <= 3; slot++ {
+		event := <-sub.Events()
+		assert.Equal(t, slot, event.Update.Slot)
+	}
+	cancel()
+	<-done
+	assert.Equal(t, 0, hub.NumSubscribers())
+}
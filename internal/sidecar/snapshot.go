@@ -28,17 +28,26 @@ import (
 	"go.uber.org/zap"
 )
 
+// StatusHeader reports the sidecar status ("draining") on snapshot list responses.
+const StatusHeader = "X-Sidecar-Status"
+
 // SnapshotHandler implements the snapshot-related sidecar API methods.
 type SnapshotHandler struct {
 	LedgerDir fs.FS
 	Log       *zap.Logger
+	Metadata  *MetadataCache // optional
+	Drainer   *Drainer       // optional
+	Holds     *HoldManager   // optional
 }
 
 // NewSnapshotHandler creates a new sidecar snapshot API handler using the provided ledger dir and logger.
 func NewSnapshotHandler(ledgerDir string, log *zap.Logger) *SnapshotHandler {
+	ledgerFS := os.DirFS(ledgerDir)
 	return &SnapshotHandler{
-		LedgerDir: os.DirFS(ledgerDir),
+		LedgerDir: ledgerFS,
 		Log:       log,
+		Metadata:  NewMetadataCache(ledgerFS, log.Named("metadata")),
+		Drainer:   NewDrainer(),
 	}
 }
 
@@ -51,20 +60,48 @@ func (s *SnapshotHandler) RegisterHandlers(group gin.IRoutes) {
 	group.GET("/snapshot.tar.zst", s.DownloadBestSnapshot)
 	group.HEAD("/snapshot/:name", s.DownloadSnapshot)
 	group.GET("/snapshot/:name", s.DownloadSnapshot)
+	group.GET("/pins", s.ListPins)
+}
+
+// RegisterAdminHandlers registers the endpoints changing sidecar state.
+// The given group should require admin credentials.
+func (s *SnapshotHandler) RegisterAdminHandlers(group gin.IRoutes) {
+	group.PUT("/pins/:name", s.PinSnapshot)
+	group.DELETE("/pins/:name", s.UnpinSnapshot)
 }
 
 // ListSnapshots is an API handler listing available snapshots on the node.
 func (s *SnapshotHandler) ListSnapshots(c *gin.Context) {
-	infos, err := ledger.ListSnapshots(s.LedgerDir)
+	infos, err := s.Snapshots()
 	if err != nil {
 		s.Log.Error("Failed to list snapshots", zap.Error(err))
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
+	if s.Drainer != nil && s.Drainer.Draining() {
+		c.Header(StatusHeader, "draining")
+	}
+	c.JSON(http.StatusOK, infos)
+}
+
+// Snapshots returns the available snapshots as reported to the tracker.
+func (s *SnapshotHandler) Snapshots() ([]*types.SnapshotInfo, error) {
+	infos, err := ledger.ListSnapshots(s.LedgerDir)
+	if err != nil {
+		return nil, err
+	}
 	if infos == nil {
 		infos = make([]*types.SnapshotInfo, 0)
 	}
-	c.JSON(http.StatusOK, infos)
+	if s.Metadata != nil {
+		s.Metadata.Fill(infos)
+	}
+	if s.Drainer != nil && s.Drainer.Draining() {
+		for _, info := range infos {
+			info.Draining = true
+		}
+	}
+	return infos, nil
 }
 
 // DownloadBestSnapshot selects the best full snapshot and sends it to the client.
@@ -106,11 +143,81 @@ func (s *SnapshotHandler) DownloadSnapshot(c *gin.Context) {
 	s.serveSnapshot(c, name)
 }
 
+// ListPins lists snapshots currently held for downloads or pinned explicitly.
+func (s *SnapshotHandler) ListPins(c *gin.Context) {
+	if s.Holds == nil {
+		c.JSON(http.StatusOK, []HoldInfo{})
+		return
+	}
+	c.JSON(http.StatusOK, s.Holds.List())
+}
+
+// PinSnapshot protects a snapshot from pruning until unpinned.
+func (s *SnapshotHandler) PinSnapshot(c *gin.Context) {
+	name := c.Param("name")
+	if s.Holds == nil {
+		c.String(http.StatusNotImplemented, "snapshot holds disabled")
+		return
+	}
+	if ledger.ParseSnapshotFileName(name) == nil {
+		returnSnapshotNotFound(c)
+		return
+	}
+	err := s.Holds.Pin(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		returnSnapshotNotFound(c)
+		return
+	} else if err != nil {
+		s.Log.Error("Failed to pin snapshot", zap.String("snapshot", name), zap.Error(err))
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UnpinSnapshot removes an explicit pin.
+func (s *SnapshotHandler) UnpinSnapshot(c *gin.Context) {
+	if s.Holds == nil {
+		c.String(http.StatusNotImplemented, "snapshot holds disabled")
+		return
+	}
+	if err := s.Holds.Unpin(c.Param("name")); err != nil {
+		c.String(http.StatusNotFound, "snapshot not pinned")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
 func (s *SnapshotHandler) serveSnapshot(c *gin.Context, name string) {
 	log := s.Log.With(zap.String("snapshot", name))
 
+	if s.Drainer != nil {
+		if !s.Drainer.Acquire() {
+			log.Info("Rejecting snapshot download while draining")
+			c.Header("retry-after", "60")
+			c.String(http.StatusServiceUnavailable, "sidecar is draining")
+			return
+		}
+		defer s.Drainer.Release()
+	}
+
 	// Open file.
-	baseFile, err := s.LedgerDir.Open(name)
+	var baseFile fs.File
+	var err error
+	if s.Holds != nil {
+		var release func()
+		release, err = s.Holds.Acquire(name)
+		if err == nil {
+			defer release()
+			baseFile, err = s.Holds.Open(name)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			// Serving without a hold beats not serving at all.
+			log.Warn("Failed to hold snapshot, serving from ledger dir", zap.Error(err))
+			baseFile, err = s.LedgerDir.Open(name)
+		}
+	} else {
+		baseFile, err = s.LedgerDir.Open(name)
+	}
 	if errors.Is(err, fs.ErrNotExist) {
 		log.Info("Requested snapshot not found")
 		returnSnapshotNotFound(c)
@@ -0,0 +1,66 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slotmon
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	nodeSlotDesc = prometheus.NewDesc(
+		"solana_cluster_node_slot",
+		"Latest slot seen by a node",
+		[]string{"group", "target", "commitment"}, nil)
+	nodeSlotLagDesc = prometheus.NewDesc(
+		"solana_cluster_node_slot_lag",
+		"Number of slots a node is behind the highest slot seen in its group",
+		[]string{"group", "target", "commitment"}, nil)
+	nodeConnectedDesc = prometheus.NewDesc(
+		"solana_cluster_node_slot_stream_connected",
+		"Whether the slot update stream of a node is connected",
+		[]string{"group", "target"}, nil)
+)
+
+// Describe implements prometheus.Collector.
+func (m *Monitor) Describe(descs chan<- *prometheus.Desc) {
+	descs <- nodeSlotDesc
+	descs <- nodeSlotLagDesc
+	descs <- nodeConnectedDesc
+}
+
+// Collect implements prometheus.Collector.
+func (m *Monitor) Collect(metrics chan<- prometheus.Metric) {
+	for _, group := range m.Status().Groups {
+		for _, n := range group.Nodes {
+			connected := 0.0
+			if n.Connected {
+				connected = 1.0
+			}
+			metrics <- prometheus.MustNewConstMetric(nodeConnectedDesc, prometheus.GaugeValue, connected, group.Group, n.Target)
+			if n.UpdatedAt == nil {
+				continue
+			}
+			for _, c := range []struct {
+				commitment string
+				slot, lag  uint64
+			}{
+				{"processed", n.Processed, n.ProcessedLag},
+				{"confirmed", n.Confirmed, n.ConfirmedLag},
+				{"rooted", n.Rooted, n.RootedLag},
+			} {
+				metrics <- prometheus.MustNewConstMetric(nodeSlotDesc, prometheus.GaugeValue, float64(c.slot), group.Group, n.Target, c.commitment)
+				metrics <- prometheus.MustNewConstMetric(nodeSlotLagDesc, prometheus.GaugeValue, float64(c.lag), group.Group, n.Target, c.commitment)
+			}
+		}
+	}
+}
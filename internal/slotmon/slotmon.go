@@ -0,0 +1,253 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slotmon follows the slot progress of all nodes in the cluster.
+//
+// It subscribes to the slot update streams of sidecars,
+// as discovered by the scraper.
+package slotmon
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap"
+)
+
+// Monitor tracks the latest processed, confirmed and rooted slots of each node.
+type Monitor struct {
+	Log        *zap.Logger
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	Filter     func(group, target string) bool // selects nodes to follow, e.g. owned by this replica
+
+	rootCtx context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	lock   sync.Mutex
+	groups map[string]map[string]*node // group => target => node
+}
+
+type node struct {
+	cancel context.CancelFunc
+
+	connected bool
+	processed uint64
+	confirmed uint64
+	rooted    uint64
+	updatedAt time.Time
+}
+
+// NewMonitor creates a new monitor without any nodes.
+func NewMonitor() *Monitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Monitor{
+		Log:        zap.NewNop(),
+		MinBackoff: time.Second,
+		MaxBackoff: 30 * time.Second,
+		rootCtx:    ctx,
+		cancel:     cancel,
+		groups:     make(map[string]map[string]*node),
+	}
+}
+
+// Close stops following all nodes.
+func (m *Monitor) Close() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// ObserveTargets implements scraper.TargetObserver.
+//
+// Starts following newly discovered targets and stops following vanished ones.
+// Targets of federated groups are trackers without slot updates and are not followed.
+// Targets rejected by the filter are not followed either.
+func (m *Monitor) ObserveTargets(group string, prober *scraper.Prober, targets []string) {
+	if prober.Federated() {
+		targets = nil
+	}
+	if m.Filter != nil {
+		owned := targets[:0:0]
+		for _, target := range targets {
+			if m.Filter(group, target) {
+				owned = append(owned, target)
+			}
+		}
+		targets = owned
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.rootCtx.Err() != nil {
+		return
+	}
+
+	nodes := m.groups[group]
+	if nodes == nil {
+		nodes = make(map[string]*node)
+		m.groups[group] = nodes
+	}
+	seen := make(map[string]struct{}, len(targets))
+	for _, target := range targets {
+		seen[target] = struct{}{}
+		if _, ok := nodes[target]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(m.rootCtx)
+		n := &node{cancel: cancel}
+		nodes[target] = n
+		m.wg.Add(1)
+		go m.follow(ctx, n, prober.StreamClient(target), m.Log.With(
+			zap.String("group", group),
+			zap.String("target", target)))
+	}
+	for target, n := range nodes {
+		if _, ok := seen[target]; !ok {
+			n.cancel()
+			delete(nodes, target)
+		}
+	}
+}
+
+// ResetGroup stops following all nodes of a group,
+// so that the next discovery run follows them with the new prober.
+func (m *Monitor) ResetGroup(group string) {
+	m.RemoveGroup(group)
+}
+
+// RemoveGroup stops following all nodes of a group.
+func (m *Monitor) RemoveGroup(group string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, n := range m.groups[group] {
+		n.cancel()
+	}
+	delete(m.groups, group)
+}
+
+func (m *Monitor) follow(ctx context.Context, n *node, client *fetch.SidecarClient, log *zap.Logger) {
+	defer m.wg.Done()
+	log.Debug("Following slot updates")
+	defer log.Debug("Stopped following slot updates")
+
+	var lastID string
+	backoff := m.MinBackoff
+	for {
+		err := client.StreamSlotUpdates(ctx, lastID, func(id string, update *ws.SlotsUpdatesResult) error {
+			lastID = id
+			backoff = m.MinBackoff
+			m.update(n, update)
+			return nil
+		})
+		m.lock.Lock()
+		n.connected = false
+		m.lock.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		log.Debug("Slot update stream interrupted", zap.Error(err), zap.Duration("backoff", backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > m.MaxBackoff {
+			backoff = m.MaxBackoff
+		}
+	}
+}
+
+func (m *Monitor) update(n *node, update *ws.SlotsUpdatesResult) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	n.connected = true
+	n.updatedAt = time.Now()
+	switch update.Type {
+	case ws.SlotsUpdatesFrozen:
+		n.processed = max(n.processed, update.Slot)
+	case ws.SlotsUpdatesOptimisticConfirmation:
+		n.confirmed = max(n.confirmed, update.Slot)
+	case ws.SlotsUpdatesRoot:
+		n.rooted = max(n.rooted, update.Slot)
+	}
+}
+
+// Status returns the current slot progress of all nodes.
+// Groups and nodes are sorted by name.
+func (m *Monitor) Status() *types.ClusterStatus {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	status := &types.ClusterStatus{Groups: make([]*types.GroupSlotStatus, 0, len(m.groups))}
+	for group, nodes := range m.groups {
+		groupStatus := &types.GroupSlotStatus{
+			Group: group,
+			Nodes: make([]*types.NodeSlotStatus, 0, len(nodes)),
+		}
+		for target, n := range nodes {
+			nodeStatus := &types.NodeSlotStatus{
+				Target:    target,
+				Connected: n.connected,
+				Processed: n.processed,
+				Confirmed: n.confirmed,
+				Rooted:    n.rooted,
+			}
+			if !n.updatedAt.IsZero() {
+				updatedAt := n.updatedAt
+				nodeStatus.UpdatedAt = &updatedAt
+			}
+			groupStatus.MaxProcessed = max(groupStatus.MaxProcessed, n.processed)
+			groupStatus.MaxConfirmed = max(groupStatus.MaxConfirmed, n.confirmed)
+			groupStatus.MaxRooted = max(groupStatus.MaxRooted, n.rooted)
+			groupStatus.Nodes = append(groupStatus.Nodes, nodeStatus)
+		}
+		for _, nodeStatus := range groupStatus.Nodes {
+			nodeStatus.ProcessedLag = groupStatus.MaxProcessed - nodeStatus.Processed
+			nodeStatus.ConfirmedLag = groupStatus.MaxConfirmed - nodeStatus.Confirmed
+			nodeStatus.RootedLag = groupStatus.MaxRooted - nodeStatus.Rooted
+		}
+		sort.Slice(groupStatus.Nodes, func(i, j int) bool {
+			return groupStatus.Nodes[i].Target < groupStatus.Nodes[j].Target
+		})
+		status.Groups = append(status.Groups, groupStatus)
+	}
+	sort.Slice(status.Groups, func(i, j int) bool {
+		return status.Groups[i].Group < status.Groups[j].Group
+	})
+	return status
+}
+
+// Lag returns the processed slot lag of a target versus the max of its group.
+// Returns false if the target's slot progress is unknown.
+func (m *Monitor) Lag(group, target string) (lag uint64, ok bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nodes := m.groups[group]
+	n, found := nodes[target]
+	if !found || n.updatedAt.IsZero() {
+		return 0, false
+	}
+	var maxProcessed uint64
+	for _, other := range nodes {
+		maxProcessed = max(maxProcessed, other.processed)
+	}
+	return maxProcessed - n.processed, true
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slotmon
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap/zaptest"
+)
+
+// newFakeSidecar serves a fixed slot update stream.
+func newFakeSidecar(t *testing.T, processed, confirmed, rooted uint64) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/slot_updates", r.URL.Path)
+		w.Header().Set("content-type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for i, update := range []struct {
+			slot uint64
+			kind string
+		}{
+			{processed, "frozen"},
+			{confirmed, "optimisticConfirmation"},
+			{rooted, "root"},
+		} {
+			_, _ = fmt.Fprintf(w, "id:%d\nevent:slot_update\ndata:{\"slot\":%d,\"type\":%q}\n\n", i+1, update.slot, update.kind)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return u.Host
+}
+
+func TestMonitor(t *testing.T) {
+	target1 := newFakeSidecar(t, 110, 105, 100)
+	target2 := newFakeSidecar(t, 100, 98, 90)
+
+	prober, err := scraper.NewProber(&types.TargetGroup{Scheme: "http"})
+	require.NoError(t, err)
+
+	monitor := NewMonitor()
+	monitor.Log = zaptest.NewLogger(t)
+	defer monitor.Close()
+	monitor.ObserveTargets("test", prober, []string{target1, target2})
+
+	require.Eventually(t, func() bool {
+		status := monitor.Status()
+		for _, n := range status.Groups[0].Nodes {
+			if n.Rooted == 0 {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	status := monitor.Status()
+	require.Len(t, status.Groups, 1)
+	group := status.Groups[0]
+	assert.Equal(t, "test", group.Group)
+	assert.Equal(t, uint64(110), group.MaxProcessed)
+	assert.Equal(t, uint64(105), group.MaxConfirmed)
+	assert.Equal(t, uint64(100), group.MaxRooted)
+	for _, n := range group.Nodes {
+		assert.True(t, n.Connected)
+		if n.Target == target2 {
+			assert.Equal(t, uint64(10), n.ProcessedLag)
+			assert.Equal(t, uint64(7), n.ConfirmedLag)
+			assert.Equal(t, uint64(10), n.RootedLag)
+		} else {
+			assert.Zero(t, n.ProcessedLag)
+		}
+	}
+	lag, ok := monitor.Lag("test", target2)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(10), lag)
+	_, ok = monitor.Lag("test", "unknown:8899")
+	assert.False(t, ok)
+	_, ok = monitor.Lag("other", target2)
+	assert.False(t, ok)
+
+	// Vanished targets are no longer followed.
+	monitor.ObserveTargets("test", prober, []string{target1})
+	assert.Len(t, monitor.Status().Groups[0].Nodes, 1)
+}
@@ -0,0 +1,213 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.blockdaemon.com/solana/cluster-manager/internal/fetch"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/atomic"
+)
+
+// ClusterLabel is the target group label matched by the cluster of advice requests.
+const ClusterLabel = "cluster"
+
+// AdviceHandler decides on behalf of clients whether and what to download.
+//
+// It applies the same policy as the fetch command,
+// so clients need nothing more than an HTTP client.
+type AdviceHandler struct {
+	Best *Handler // ranks candidate sources like best_snapshots
+
+	DefaultMinSlots   uint64
+	DefaultMaxSlots   uint64
+	DefaultMaxSources int
+
+	groups atomic.Pointer[map[string]*types.TargetGroup]
+}
+
+// NewAdviceHandler creates a new advice API ranking sources with the given tracker API.
+func NewAdviceHandler(best *Handler) *AdviceHandler {
+	h := &AdviceHandler{
+		Best:              best,
+		DefaultMinSlots:   500,
+		DefaultMaxSlots:   10000,
+		DefaultMaxSources: 3,
+	}
+	h.groups.Store(new(map[string]*types.TargetGroup))
+	return h
+}
+
+// Configure applies the target groups of the given config.
+func (h *AdviceHandler) Configure(conf *types.Config) {
+	groups := make(map[string]*types.TargetGroup, len(conf.TargetGroups))
+	for _, group := range conf.TargetGroups {
+		groups[group.Group] = group
+	}
+	h.groups.Store(&groups)
+}
+
+// RegisterHandlers registers this API with Gin web framework.
+func (h *AdviceHandler) RegisterHandlers(group gin.IRoutes) {
+	group.POST("/advice", h.PostAdvice)
+}
+
+// PostAdvice compares the local snapshots of a client with the best snapshots,
+// and lists the files to download with candidate sources for each.
+func (h *AdviceHandler) PostAdvice(c *gin.Context) {
+	var req types.AdviceRequest
+	if err := c.BindJSON(&req); err != nil {
+		return
+	}
+	if req.MaxSources < 0 {
+		c.String(http.StatusBadRequest, "invalid max_sources")
+		return
+	}
+	for _, info := range req.Snapshots {
+		if info == nil {
+			c.String(http.StatusBadRequest, "invalid snapshots")
+			return
+		}
+	}
+	c.JSON(http.StatusOK, h.advise(&req, time.Now()))
+}
+
+func (h *AdviceHandler) advise(req *types.AdviceRequest, now time.Time) *types.AdviceResponse {
+	groups := *h.groups.Load()
+	var keep func(*index.SnapshotEntry) bool
+	if req.Cluster != "" {
+		inCluster := make(map[string]bool)
+		for name, group := range groups {
+			if group.Labels[ClusterLabel] == req.Cluster {
+				inCluster[name] = true
+			}
+		}
+		if len(inCluster) == 0 {
+			return &types.AdviceResponse{
+				Advice:  types.AdviceNothingFound,
+				Reasons: []string{fmt.Sprintf("no target group is labeled %s=%s", ClusterLabel, req.Cluster)},
+			}
+		}
+		keep = func(entry *index.SnapshotEntry) bool {
+			return inCluster[entry.Group]
+		}
+	}
+	params := bestSnapshotsParams{
+		Max:           h.Best.MaxBestSnapshots,
+		SnapshotQuery: types.SnapshotQuery{Group: req.Group},
+	}
+	entries, _ := h.Best.bestEntries(params, keep, now)
+	remote := make([]types.SnapshotSource, len(entries))
+	for i, entry := range entries {
+		remote[i] = types.SnapshotSource{SnapshotInfo: *entry.Info, Target: entry.Target}
+	}
+
+	local := make([]*types.SnapshotInfo, len(req.Snapshots))
+	copy(local, req.Snapshots)
+	sort.SliceStable(local, func(i, j int) bool {
+		return local[i].Slot > local[j].Slot
+	})
+	minSlots, maxSlots := h.DefaultMinSlots, h.DefaultMaxSlots
+	if req.MinSlots != nil {
+		minSlots = *req.MinSlots
+	}
+	if req.MaxSlots != nil {
+		maxSlots = *req.MaxSlots
+	}
+
+	minSlot, advice := fetch.ShouldFetchSnapshot(local, remote, minSlots, maxSlots)
+	switch advice {
+	case fetch.AdviceNothingFound:
+		return &types.AdviceResponse{
+			Advice:  types.AdviceNothingFound,
+			Reasons: []string{"no remote snapshot matches the request"},
+		}
+	case fetch.AdviceUpToDate:
+		return &types.AdviceResponse{
+			Advice: types.AdviceUpToDate,
+			Reasons: []string{fmt.Sprintf("local snapshot at slot %d is less than %d slots behind the best remote snapshot at slot %d",
+				local[0].Slot, minSlots, remote[0].Slot)},
+		}
+	}
+
+	best := &remote[0]
+	res := &types.AdviceResponse{
+		Advice:  types.AdviceFetch,
+		Slot:    best.Slot,
+		Hash:    &best.Hash,
+		MinSlot: minSlot,
+	}
+	if len(local) == 0 {
+		res.Reasons = append(res.Reasons, fmt.Sprintf("no local snapshot, best remote snapshot is at slot %d", best.Slot))
+	} else {
+		res.Reasons = append(res.Reasons, fmt.Sprintf("best remote snapshot at slot %d is %d slots newer than local snapshot at slot %d",
+			best.Slot, best.Slot-local[0].Slot, local[0].Slot))
+	}
+	present := make(map[string]bool)
+	for _, info := range local {
+		for _, file := range info.Files {
+			present[file.FileName] = true
+		}
+	}
+	maxSources := req.MaxSources
+	if maxSources == 0 {
+		maxSources = h.DefaultMaxSources
+	}
+	for _, file := range best.Files {
+		if present[file.FileName] {
+			res.Reasons = append(res.Reasons, fmt.Sprintf("%s is present locally", file.FileName))
+			continue
+		}
+		res.Files = append(res.Files, &types.AdviceFile{
+			SnapshotFile: *file,
+			Sources:      fileSources(groups, entries, file.FileName, maxSources),
+		})
+	}
+	return res
+}
+
+// fileSources returns the first sources of the ranking serving the given file.
+func fileSources(groups map[string]*types.TargetGroup, entries []*index.SnapshotEntry, fileName string, limit int) []types.AdviceSource {
+	sources := make([]types.AdviceSource, 0, limit)
+	for _, entry := range entries {
+		if len(sources) >= limit {
+			break
+		}
+		for _, file := range entry.Info.Files {
+			if file.FileName != fileName {
+				continue
+			}
+			// Sidecars of federated trackers are assumed to use the defaults.
+			u := url.URL{Scheme: "http", Host: entry.Target}
+			if group, ok := groups[entry.Group]; ok && entry.Origin == "" {
+				u.Scheme, u.Path = group.Scheme, group.APIPath
+			}
+			sources = append(sources, types.AdviceSource{
+				Target: entry.Target,
+				URL:    u.JoinPath("v1", "snapshot", fileName).String(),
+				Origin: entry.Origin,
+			})
+			break
+		}
+	}
+	return sources
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+// AnnounceHandler accepts snapshot lists pushed by sidecars.
+//
+// Announcements are fed into the same path as scrape results.
+// A request is accepted for the first target group which already knows the announced target
+// from service discovery and whose announce token for that target matches.
+// Tokens are bound to the target, so a sidecar cannot announce snapshots on behalf of another.
+type AnnounceHandler struct {
+	Results   chan<- scraper.ProbeResult
+	Collector *scraper.Collector
+	Log       *zap.Logger
+
+	groups atomic.Pointer[[]announceGroup]
+}
+
+type announceGroup struct {
+	name string
+	auth *types.AnnounceAuth
+}
+
+// NewAnnounceHandler creates a new announcement API forwarding results into the given channel.
+func NewAnnounceHandler(results chan<- scraper.ProbeResult, collector *scraper.Collector, log *zap.Logger) *AnnounceHandler {
+	h := &AnnounceHandler{
+		Results:   results,
+		Collector: collector,
+		Log:       log,
+	}
+	h.groups.Store(new([]announceGroup))
+	return h
+}
+
+// Configure applies the announce credentials of the given config.
+func (h *AnnounceHandler) Configure(conf *types.Config) {
+	var groups []announceGroup
+	for _, group := range conf.TargetGroups {
+		if group.AnnounceAuth != nil {
+			groups = append(groups, announceGroup{name: group.Group, auth: group.AnnounceAuth})
+		}
+	}
+	h.groups.Store(&groups)
+}
+
+// RegisterHandlers registers this API with Gin web framework.
+func (h *AnnounceHandler) RegisterHandlers(group gin.IRoutes) {
+	group.POST("/announce", h.Announce)
+}
+
+// maxAnnouncementSize limits the body of an announcement.
+const maxAnnouncementSize = 1 << 20
+
+// Announce accepts the snapshot list of a sidecar.
+//
+// The token is checked against the target in the query before the body is read.
+func (h *AnnounceHandler) Announce(c *gin.Context) {
+	target := c.Query("target")
+	if target == "" {
+		c.String(http.StatusBadRequest, "missing target")
+		return
+	}
+	var groups []string
+	for _, group := range *h.groups.Load() {
+		if group.auth.Check(c.Request, target) {
+			groups = append(groups, group.name)
+		}
+	}
+	if len(groups) == 0 {
+		c.String(http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAnnouncementSize)
+	var announcement types.SnapshotAnnouncement
+	if err := c.ShouldBindJSON(&announcement); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.String(http.StatusRequestEntityTooLarge, "announcement too large")
+			return
+		}
+		_ = c.AbortWithError(http.StatusBadRequest, err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	for _, group := range groups {
+		if !h.Collector.Known(group, target) {
+			continue
+		}
+		res := scraper.ProbeResult{
+			Time:   time.Now(),
+			Group:  group,
+			Target: target,
+			Infos:  announcement.Snapshots,
+
+			Announced: true,
+		}
+		select {
+		case h.Results <- res:
+		case <-c.Request.Context().Done():
+			return
+		}
+		h.Log.Debug("Accepted announcement",
+			zap.String("group", group),
+			zap.String("target", target),
+			zap.Int("num_snapshots", len(announcement.Snapshots)))
+		c.Status(http.StatusNoContent)
+		return
+	}
+	h.Log.Warn("Rejected announcement from unknown target", zap.String("target", target))
+	c.String(http.StatusForbidden, "unknown target")
+}
@@ -0,0 +1,299 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/zap"
+)
+
+// Leases grants, renews and releases download leases on snapshot sources.
+//
+// Returned booleans report whether a source was leased or the lease was found.
+type Leases interface {
+	Acquire(sources []types.SnapshotSource, now time.Time) (types.Assignment, bool)
+	Renew(id string, now time.Time) (types.Assignment, bool)
+	Release(id string, result string, now time.Time) (types.Assignment, bool)
+}
+
+// Assignments is a table of download leases on snapshot sources.
+//
+// Leases expire after TTL unless renewed, so crashed fetchers
+// do not hold on to capacity of a source forever.
+// The table is kept in memory of a single tracker replica;
+// in HA mode, ha.Leases routes all lease calls to the replica holding it.
+type Assignments struct {
+	TTL time.Duration
+
+	lock   sync.Mutex
+	leases map[string]*types.Assignment
+}
+
+// NewAssignments creates an empty lease table.
+func NewAssignments(ttl time.Duration) *Assignments {
+	return &Assignments{
+		TTL:    ttl,
+		leases: make(map[string]*types.Assignment),
+	}
+}
+
+// Active returns the number of unexpired leases per target.
+func (a *Assignments) Active(now time.Time) map[string]int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.expire(now)
+	return a.active()
+}
+
+// Acquire leases the least loaded of the given sources.
+// Ties go to the source listed first.
+// Returns false if no sources are given.
+func (a *Assignments) Acquire(sources []types.SnapshotSource, now time.Time) (types.Assignment, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.expire(now)
+	if len(sources) == 0 {
+		return types.Assignment{}, false
+	}
+	active := a.active()
+	best := 0
+	for i := range sources {
+		if active[sources[i].Target] < active[sources[best].Target] {
+			best = i
+		}
+	}
+	assignment := types.Assignment{
+		ID:        newLeaseID(),
+		Source:    sources[best],
+		ExpiresAt: now.Add(a.TTL),
+		Active:    active[sources[best].Target] + 1,
+	}
+	a.leases[assignment.ID] = &assignment
+	assignmentEvents.WithLabelValues("granted").Inc()
+	return assignment, true
+}
+
+// Renew extends an unexpired lease by TTL.
+// Returns false if the lease is unknown or has expired.
+func (a *Assignments) Renew(id string, now time.Time) (types.Assignment, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.expire(now)
+	lease, ok := a.leases[id]
+	if !ok {
+		return types.Assignment{}, false
+	}
+	lease.ExpiresAt = now.Add(a.TTL)
+	assignment := *lease
+	assignment.Active = a.active()[assignment.Source.Target]
+	return assignment, true
+}
+
+// Release drops a lease with the given result.
+// Returns false if the lease is unknown or has expired.
+func (a *Assignments) Release(id string, result string, now time.Time) (types.Assignment, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.expire(now)
+	lease, ok := a.leases[id]
+	if !ok {
+		return types.Assignment{}, false
+	}
+	delete(a.leases, id)
+	assignmentEvents.WithLabelValues(result).Inc()
+	return *lease, true
+}
+
+func (a *Assignments) active() map[string]int {
+	active := make(map[string]int)
+	for _, lease := range a.leases {
+		active[lease.Source.Target]++
+	}
+	return active
+}
+
+func (a *Assignments) expire(now time.Time) {
+	for id, lease := range a.leases {
+		if !now.Before(lease.ExpiresAt) {
+			delete(a.leases, id)
+			assignmentEvents.WithLabelValues("expired").Inc()
+		}
+	}
+}
+
+func newLeaseID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic("failed to generate lease ID: " + err.Error()) // unreachable
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// AssignHandler implements the assignment API methods.
+//
+// Instead of all fetchers picking the first of the best snapshots,
+// a fetcher asks for a source of the snapshot it wants to download
+// and gets the least loaded sidecar serving it.
+type AssignHandler struct {
+	DB          *index.DB
+	Cordons     *Cordons // cordoned targets are never assigned, optional
+	Assignments Leases
+	Log         *zap.Logger
+}
+
+// NewAssignHandler creates a new assignment API using the provided database and lease table.
+func NewAssignHandler(db *index.DB, assignments Leases, log *zap.Logger) *AssignHandler {
+	return &AssignHandler{
+		DB:          db,
+		Assignments: assignments,
+		Log:         log,
+	}
+}
+
+// RegisterHandlers registers this API with Gin web framework.
+func (h *AssignHandler) RegisterHandlers(group gin.IRoutes) {
+	group.POST("/assignments", h.Assign)
+	group.POST("/assignments/:id/renew", h.Renew)
+	group.DELETE("/assignments/:id", h.Release)
+}
+
+// Assign leases a source of the requested snapshot.
+//
+// Draining sources are only assigned if no other source serves the snapshot.
+func (h *AssignHandler) Assign(c *gin.Context) {
+	var req types.AssignmentRequest
+	if err := c.BindJSON(&req); err != nil {
+		return
+	}
+	if req.Slot == 0 || req.Hash.IsZero() {
+		c.String(http.StatusBadRequest, "slot and hash are required")
+		return
+	}
+	if req.Client == "" {
+		req.Client = c.GetString(principalKey)
+	}
+	now := time.Now()
+	sources, err := h.sources(&req, now)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	assignment, ok := h.Assignments.Acquire(sources, now)
+	if !ok {
+		c.String(http.StatusNotFound, "no source serves the snapshot")
+		return
+	}
+	h.Log.Info("Assigned snapshot source",
+		zap.String("id", assignment.ID),
+		zap.String("client", req.Client),
+		zap.String("target", assignment.Source.Target),
+		zap.Uint64("slot", req.Slot),
+		zap.Int("active", assignment.Active))
+	c.JSON(http.StatusOK, &assignment)
+}
+
+// sources returns the assignable sources of a snapshot in order of preference.
+func (h *AssignHandler) sources(req *types.AssignmentRequest, now time.Time) ([]types.SnapshotSource, error) {
+	query, err := index.NewQuery(types.SnapshotQuery{
+		Group:   req.Group,
+		MinSlot: req.Slot,
+		MaxSlot: req.Slot,
+		Hash:    req.Hash.String(),
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	entries, _ := h.DB.FindSnapshots(query)
+	var sources, draining []types.SnapshotSource
+	for _, entry := range entries {
+		if h.Cordons != nil && h.Cordons.Cordoned(entry.Target) {
+			continue
+		}
+		source := types.SnapshotSource{
+			SnapshotInfo: *entry.Info,
+			Target:       entry.Target,
+			UpdatedAt:    entry.UpdatedAt,
+			Unverified:   entry.Unverified,
+			Origin:       entry.Origin,
+		}
+		if entry.Info.Draining {
+			draining = append(draining, source)
+		} else {
+			sources = append(sources, source)
+		}
+	}
+	if len(sources) == 0 {
+		sources = draining
+	}
+	// Prefer local, verified and recently scraped sources on equal load.
+	sort.SliceStable(sources, func(i, j int) bool {
+		if (sources[i].Origin == "") != (sources[j].Origin == "") {
+			return sources[i].Origin == ""
+		}
+		if sources[i].Unverified != sources[j].Unverified {
+			return sources[j].Unverified
+		}
+		return sources[i].UpdatedAt.After(sources[j].UpdatedAt)
+	})
+	return sources, nil
+}
+
+// Renew extends a lease while the download is still running.
+func (h *AssignHandler) Renew(c *gin.Context) {
+	assignment, ok := h.Assignments.Renew(c.Param("id"), time.Now())
+	if !ok {
+		c.String(http.StatusNotFound, "assignment not found")
+		return
+	}
+	c.JSON(http.StatusOK, &assignment)
+}
+
+// Release drops a lease once the download has completed or failed.
+// The outcome is passed in the "result" query parameter and defaults to completed.
+func (h *AssignHandler) Release(c *gin.Context) {
+	var query struct {
+		Result string `form:"result"`
+	}
+	if err := c.BindQuery(&query); err != nil {
+		return
+	}
+	switch query.Result {
+	case "":
+		query.Result = types.AssignmentCompleted
+	case types.AssignmentCompleted, types.AssignmentFailed:
+	default:
+		c.String(http.StatusBadRequest, "invalid result: "+query.Result)
+		return
+	}
+	assignment, ok := h.Assignments.Release(c.Param("id"), query.Result, time.Now())
+	if !ok {
+		c.String(http.StatusNotFound, "assignment not found")
+		return
+	}
+	h.Log.Info("Released snapshot source",
+		zap.String("id", assignment.ID),
+		zap.String("target", assignment.Source.Target),
+		zap.String("result", query.Result))
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,229 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// principalKey is the Gin context key holding the name of the authenticated client.
+const principalKey = "principal"
+
+// Auth enforces client roles on tracker endpoints and audit-logs admin actions.
+//
+// Credentials can be reloaded from disk while the server is running.
+type Auth struct {
+	File       string      // auth disabled if empty
+	PeerSecret string      // bearer token granting the peer role, e.g. the HA cluster secret
+	Log        *zap.Logger // audit log
+
+	auth atomic.Pointer[types.APIAuth]
+}
+
+// NewAuth creates a new auth enforcer reading credentials from the given file.
+func NewAuth(file string, log *zap.Logger) *Auth {
+	a := &Auth{File: file, Log: log}
+	a.auth.Store(new(types.APIAuth))
+	return a
+}
+
+// Reload reads the credentials file.
+// If the file fails to load, the previous credentials remain in effect.
+func (a *Auth) Reload() error {
+	commit, err := a.Load()
+	if err != nil {
+		return err
+	}
+	commit()
+	return nil
+}
+
+// Load reads the credentials file without applying it.
+// The returned function puts the credentials into effect.
+func (a *Auth) Load() (commit func(), err error) {
+	if a.File == "" {
+		return func() {}, nil
+	}
+	auth, err := types.LoadAPIAuth(a.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load auth file: %w", err)
+	}
+	return func() { a.auth.Store(auth) }, nil
+}
+
+// authenticate identifies the client and checks its role.
+// Returns the HTTP status to reject the request with, or zero.
+func (a *Auth) authenticate(req *http.Request, required string) (name string, status int) {
+	auth := a.auth.Load()
+	if auth.IsEmpty() {
+		return "anonymous", 0
+	}
+	name, role, ok := auth.Authenticate(req)
+	if !ok && a.isPeer(req) {
+		name, role, ok = "peer", types.RolePeer, true
+	}
+	if !ok {
+		return "", http.StatusUnauthorized
+	}
+	if !types.RoleAllows(role, required) {
+		return name, http.StatusForbidden
+	}
+	return name, 0
+}
+
+// isPeer returns whether the request carries the peer secret.
+func (a *Auth) isPeer(req *http.Request) bool {
+	token, ok := strings.CutPrefix(req.Header.Get("authorization"), "Bearer ")
+	return ok && a.PeerSecret != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.PeerSecret)) == 1
+}
+
+// Require rejects requests of clients lacking the given role.
+// Rejected admin requests and authenticated clients lacking privileges are logged.
+func (a *Auth) Require(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, status := a.authenticate(c.Request, role)
+		if status != 0 {
+			if status == http.StatusForbidden || role == types.RoleAdmin {
+				a.Log.Warn("Denied request",
+					zap.String("principal", name),
+					zap.String("method", c.Request.Method),
+					zap.String("path", c.Request.URL.Path))
+			}
+			c.Header("www-authenticate", `Bearer realm="tracker"`)
+			c.AbortWithStatus(status)
+			return
+		}
+		c.Set(principalKey, name)
+		c.Next()
+	}
+}
+
+// Audit logs requests passing through after they completed.
+// Should be installed after Require.
+func (a *Auth) Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		a.Log.Info("Admin action",
+			zap.String("principal", c.GetString(principalKey)),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("remote_addr", c.ClientIP()),
+			zap.Int("status", c.Writer.Status()))
+	}
+}
+
+// RequireHTTP is like Require, but for plain HTTP handlers.
+// Requests requiring the admin role are audit-logged.
+func (a *Auth) RequireHTTP(role string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+		name, status := a.authenticate(req, role)
+		if status != 0 {
+			if status == http.StatusForbidden || role == types.RoleAdmin {
+				a.Log.Warn("Denied request",
+					zap.String("principal", name),
+					zap.String("method", req.Method),
+					zap.String("path", req.URL.Path))
+			}
+			wr.Header().Set("www-authenticate", `Bearer realm="tracker"`)
+			http.Error(wr, http.StatusText(status), status)
+			return
+		}
+		if role != types.RoleAdmin {
+			next.ServeHTTP(wr, req)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: wr, status: http.StatusOK}
+		next.ServeHTTP(rec, req)
+		a.Log.Info("Admin action",
+			zap.String("principal", name),
+			zap.String("method", req.Method),
+			zap.String("path", req.URL.Path),
+			zap.String("remote_addr", req.RemoteAddr),
+			zap.Int("status", rec.status))
+	})
+}
+
+// UnaryInterceptor rejects gRPC calls of clients lacking the given role.
+func (a *Auth) UnaryInterceptor(role string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := a.authenticateGRPC(ctx, info.FullMethod, role); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor is like UnaryInterceptor, but for streaming calls.
+func (a *Auth) StreamInterceptor(role string) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.authenticateGRPC(stream.Context(), info.FullMethod, role); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// authenticateGRPC checks the credentials of a gRPC call like those of an HTTP request.
+// The bearer token is read from the "authorization" metadata, client certificates from the connection.
+func (a *Auth) authenticateGRPC(ctx context.Context, method string, role string) error {
+	req := &http.Request{Header: make(http.Header)}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, value := range md.Get("authorization") {
+			req.Header.Add("authorization", value)
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			req.TLS = &info.State
+		}
+	}
+	name, code := a.authenticate(req, role)
+	switch code {
+	case 0:
+		return nil
+	case http.StatusForbidden:
+		a.Log.Warn("Denied request",
+			zap.String("principal", name),
+			zap.String("method", method))
+		return status.Error(codes.PermissionDenied, "permission denied")
+	default:
+		return status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
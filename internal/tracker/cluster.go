@@ -0,0 +1,42 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.blockdaemon.com/solana/cluster-manager/internal/slotmon"
+)
+
+// ClusterHandler implements the cluster status API methods.
+type ClusterHandler struct {
+	Monitor *slotmon.Monitor
+}
+
+// NewClusterHandler creates a new cluster status API using the provided slot monitor.
+func NewClusterHandler(monitor *slotmon.Monitor) *ClusterHandler {
+	return &ClusterHandler{Monitor: monitor}
+}
+
+// RegisterHandlers registers this API with Gin web framework.
+func (h *ClusterHandler) RegisterHandlers(group gin.IRoutes) {
+	group.GET("/cluster_status", h.GetClusterStatus)
+}
+
+// GetClusterStatus returns the slot progress of all nodes.
+func (h *ClusterHandler) GetClusterStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Monitor.Status())
+}
@@ -0,0 +1,147 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+// Cordons is the set of targets excluded from best snapshot results.
+//
+// Cordoned targets are still scraped and listed by /v1/snapshots,
+// so that they can be inspected and uncordoned once healthy.
+type Cordons struct {
+	lock    sync.Mutex
+	targets map[string]types.Cordon
+	watch   chan struct{}
+}
+
+// NewCordons creates an empty cordon set.
+func NewCordons() *Cordons {
+	return &Cordons{
+		targets: make(map[string]types.Cordon),
+		watch:   make(chan struct{}),
+	}
+}
+
+// Cordoned returns whether the given target is cordoned.
+func (c *Cordons) Cordoned(target string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.targets[target]
+	return ok
+}
+
+// List returns all cordons ordered by target.
+func (c *Cordons) List() []types.Cordon {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	cordons := make([]types.Cordon, 0, len(c.targets))
+	for _, cordon := range c.targets {
+		cordons = append(cordons, cordon)
+	}
+	sort.Slice(cordons, func(i, j int) bool {
+		return cordons[i].Target < cordons[j].Target
+	})
+	return cordons
+}
+
+// Add cordons a target, replacing any previous cordon.
+func (c *Cordons) Add(cordon types.Cordon) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.targets[cordon.Target] = cordon
+	c.notify()
+}
+
+// Remove uncordons a target.
+// Returns false if the target was not cordoned.
+func (c *Cordons) Remove(target string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.targets[target]; !ok {
+		return false
+	}
+	delete(c.targets, target)
+	c.notify()
+	return true
+}
+
+// WatchCh returns a channel that is closed on the next change to the cordon set.
+func (c *Cordons) WatchCh() <-chan struct{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.watch
+}
+
+func (c *Cordons) notify() {
+	close(c.watch)
+	c.watch = make(chan struct{})
+}
+
+// CordonHandler implements the cordon API methods.
+type CordonHandler struct {
+	Cordons *Cordons
+}
+
+// NewCordonHandler creates a new cordon API managing the given set.
+func NewCordonHandler(cordons *Cordons) *CordonHandler {
+	return &CordonHandler{Cordons: cordons}
+}
+
+// RegisterHandlers registers this API with Gin web framework.
+func (h *CordonHandler) RegisterHandlers(group gin.IRoutes) {
+	group.GET("/cordons", h.ListCordons)
+	group.PUT("/cordons/:target", h.Cordon)
+	group.DELETE("/cordons/:target", h.Uncordon)
+}
+
+// ListCordons returns all cordoned targets.
+func (h *CordonHandler) ListCordons(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Cordons.List())
+}
+
+// Cordon excludes a target from best snapshot results.
+// An optional reason can be passed in the "reason" query parameter.
+func (h *CordonHandler) Cordon(c *gin.Context) {
+	var query struct {
+		Reason string `form:"reason"`
+	}
+	if err := c.BindQuery(&query); err != nil {
+		return
+	}
+	h.Cordons.Add(types.Cordon{
+		Target:    c.Param("target"),
+		Reason:    query.Reason,
+		CreatedBy: c.GetString(principalKey),
+		CreatedAt: time.Now().UTC(),
+	})
+	c.Status(http.StatusNoContent)
+}
+
+// Uncordon includes a target in best snapshot results again.
+func (h *CordonHandler) Uncordon(c *gin.Context) {
+	if !h.Cordons.Remove(c.Param("target")) {
+		c.String(http.StatusNotFound, "target not cordoned")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,313 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/atomic"
+)
+
+// DashboardHandler renders a read-only overview of the cluster snapshot state.
+//
+// The page is self-contained and does not load any external assets.
+type DashboardHandler struct {
+	DB         *index.DB
+	Collector  *scraper.Collector
+	TrackerURL string // public tracker URL shown in fetch commands
+	MaxSlots   int    // number of most recent snapshots per group shown in the matrix
+
+	groups atomic.Pointer[map[string]*types.TargetGroup]
+}
+
+// NewDashboardHandler creates a new dashboard using the provided index and collector.
+func NewDashboardHandler(db *index.DB, collector *scraper.Collector) *DashboardHandler {
+	h := &DashboardHandler{
+		DB:         db,
+		Collector:  collector,
+		TrackerURL: "http://localhost:8458",
+		MaxSlots:   8,
+	}
+	h.groups.Store(new(map[string]*types.TargetGroup))
+	return h
+}
+
+// Configure applies the target groups of the given config.
+func (h *DashboardHandler) Configure(conf *types.Config) {
+	groups := make(map[string]*types.TargetGroup, len(conf.TargetGroups))
+	for _, group := range conf.TargetGroups {
+		groups[group.Group] = group
+	}
+	h.groups.Store(&groups)
+}
+
+type dashboardData struct {
+	TrackerURL string
+	Groups     []*dashboardGroup
+}
+
+type dashboardGroup struct {
+	Name        string
+	Up          int
+	Down        int
+	Unknown     int
+	Targets     []types.TargetStatus
+	Best        []dashboardBest
+	Columns     []dashboardColumn
+	Rows        []dashboardRow
+	Conflicts   []dashboardConflict
+	NumEntries  int
+	urlTemplate url.URL // scheme and API path of sidecars
+}
+
+// dashboardBest is the best snapshot of a kind within a group.
+type dashboardBest struct {
+	Kind     string
+	Source   types.SnapshotSource
+	Sources  int
+	Commands []string
+}
+
+// dashboardColumn is a snapshot (slot and base slot) in the matrix.
+type dashboardColumn struct {
+	Slot     uint64
+	BaseSlot uint64
+	Conflict bool // targets disagree on the hash
+}
+
+type dashboardRow struct {
+	Target string
+	Health string
+	Cells  []string // abbreviated hash per column, empty if not served
+}
+
+// dashboardConflict lists the targets serving each hash of a contested snapshot.
+type dashboardConflict struct {
+	Slot     uint64
+	BaseSlot uint64
+	Hashes   []dashboardHash
+}
+
+type dashboardHash struct {
+	Hash    string
+	Targets []string
+}
+
+type columnKey struct {
+	slot     uint64
+	baseSlot uint64
+}
+
+//go:embed dashboard.html
+var dashboardPageSource string
+
+var dashboardPage = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"since": func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return time.Since(t).Truncate(time.Second).String() + " ago"
+	},
+	"bytes": func(n uint64) string {
+		const unit = 1024
+		if n < unit {
+			return fmt.Sprintf("%d B", n)
+		}
+		div, exp := uint64(unit), 0
+		for m := n / unit; m >= unit; m /= unit {
+			div *= unit
+			exp++
+		}
+		return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+	},
+}).Parse(dashboardPageSource))
+
+// ServeHTTP renders the dashboard as an HTML page.
+func (h *DashboardHandler) ServeHTTP(wr http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" && req.URL.Path != "" {
+		http.NotFound(wr, req)
+		return
+	}
+	data := dashboardData{
+		TrackerURL: h.TrackerURL,
+		Groups:     h.collect(),
+	}
+	wr.Header().Set("content-type", "text/html; charset=utf-8")
+	_ = dashboardPage.Execute(wr, &data)
+}
+
+// collect gathers the state of all groups, ordered by name.
+func (h *DashboardHandler) collect() []*dashboardGroup {
+	groups := make(map[string]*dashboardGroup)
+	getGroup := func(name string) *dashboardGroup {
+		group, ok := groups[name]
+		if !ok {
+			group = &dashboardGroup{Name: name, urlTemplate: url.URL{Scheme: "http"}}
+			groups[name] = group
+		}
+		return group
+	}
+	for name, conf := range *h.groups.Load() {
+		group := getGroup(name)
+		group.urlTemplate = url.URL{Scheme: conf.Scheme, Path: conf.APIPath}
+	}
+	for _, target := range h.Collector.Targets() {
+		group := getGroup(target.Group)
+		group.Targets = append(group.Targets, target)
+		switch target.Health {
+		case types.TargetHealthUp:
+			group.Up++
+		case types.TargetHealthDown:
+			group.Down++
+		default:
+			group.Unknown++
+		}
+	}
+	entries := make(map[string][]*index.SnapshotEntry)
+	for _, entry := range h.DB.GetBestSnapshots(-1) {
+		entries[entry.Group] = append(entries[entry.Group], entry)
+		getGroup(entry.Group)
+	}
+
+	sorted := make([]*dashboardGroup, 0, len(groups))
+	for name, group := range groups {
+		group.NumEntries = len(entries[name])
+		group.best(entries[name])
+		group.matrix(entries[name], h.MaxSlots)
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// best finds the newest full and incremental snapshot, given entries ordered best first.
+func (g *dashboardGroup) best(entries []*index.SnapshotEntry) {
+	for _, kind := range []string{types.SnapshotKindFull, types.SnapshotKindIncremental} {
+		var best dashboardBest
+		for _, entry := range entries {
+			if entry.Info.IsFull() != (kind == types.SnapshotKindFull) {
+				continue
+			}
+			if best.Sources == 0 {
+				best = dashboardBest{
+					Kind: kind,
+					Source: types.SnapshotSource{
+						SnapshotInfo: *entry.Info,
+						Target:       entry.Target,
+						UpdatedAt:    entry.UpdatedAt,
+						Unverified:   entry.Unverified,
+						Origin:       entry.Origin,
+					},
+				}
+			} else if entry.Info.Slot != best.Source.Slot || entry.Info.Hash != best.Source.Hash {
+				continue
+			}
+			best.Sources++
+		}
+		if best.Sources == 0 {
+			continue
+		}
+		for _, file := range best.Source.Files {
+			u := g.urlTemplate
+			u.Host = best.Source.Target
+			u = *u.JoinPath("v1", "snapshot", file.FileName)
+			best.Commands = append(best.Commands, "curl -fO "+u.String())
+		}
+		g.Best = append(g.Best, best)
+	}
+}
+
+// matrix lays out which target serves which of the most recent snapshots,
+// and detects targets disagreeing on the hash of a snapshot.
+func (g *dashboardGroup) matrix(entries []*index.SnapshotEntry, maxColumns int) {
+	hashes := make(map[columnKey]map[string][]string) // => hash => targets
+	var keys []columnKey
+	for _, entry := range entries {
+		key := columnKey{slot: entry.Info.Slot, baseSlot: entry.Info.BaseSlot()}
+		if _, ok := hashes[key]; !ok {
+			hashes[key] = make(map[string][]string)
+			keys = append(keys, key)
+		}
+		hash := entry.Info.Hash.String()
+		hashes[key][hash] = append(hashes[key][hash], entry.Target)
+	}
+
+	// Conflicts are reported across all snapshots, not just the visible ones.
+	for _, key := range keys {
+		if len(hashes[key]) < 2 {
+			continue
+		}
+		conflict := dashboardConflict{Slot: key.slot, BaseSlot: key.baseSlot}
+		for hash, targets := range hashes[key] {
+			sort.Strings(targets)
+			conflict.Hashes = append(conflict.Hashes, dashboardHash{Hash: hash, Targets: targets})
+		}
+		sort.Slice(conflict.Hashes, func(i, j int) bool {
+			return len(conflict.Hashes[i].Targets) > len(conflict.Hashes[j].Targets)
+		})
+		g.Conflicts = append(g.Conflicts, conflict)
+	}
+
+	if maxColumns >= 0 && len(keys) > maxColumns {
+		keys = keys[:maxColumns]
+	}
+	column := make(map[columnKey]int, len(keys))
+	for i, key := range keys {
+		column[key] = i
+		g.Columns = append(g.Columns, dashboardColumn{
+			Slot:     key.slot,
+			BaseSlot: key.baseSlot,
+			Conflict: len(hashes[key]) > 1,
+		})
+	}
+	rows := make(map[string]*dashboardRow)
+	for _, target := range g.Targets {
+		rows[target.Target] = &dashboardRow{Target: target.Target, Health: target.Health}
+	}
+	for _, entry := range entries {
+		row, ok := rows[entry.Target]
+		if !ok {
+			row = &dashboardRow{Target: entry.Target, Health: types.TargetHealthUnknown}
+			rows[entry.Target] = row
+		}
+		if row.Cells == nil {
+			row.Cells = make([]string, len(keys))
+		}
+		i, ok := column[columnKey{slot: entry.Info.Slot, baseSlot: entry.Info.BaseSlot()}]
+		if ok {
+			row.Cells[i] = entry.Info.Hash.String()[:8]
+		}
+	}
+	for _, row := range rows {
+		if row.Cells == nil {
+			row.Cells = make([]string, len(keys))
+		}
+		g.Rows = append(g.Rows, *row)
+	}
+	sort.Slice(g.Rows, func(i, j int) bool {
+		return g.Rows[i].Target < g.Rows[j].Target
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+func TestDashboardHandler(t *testing.T) {
+	db := index.NewDB()
+	collector := scraper.NewCollector(db)
+	collector.ObserveTargets("mainnet", nil, []string{"host1", "host2", "host3"})
+
+	newEntry := func(target string, slot, baseSlot uint64, hash byte) *index.SnapshotEntry {
+		return &index.SnapshotEntry{
+			SnapshotKey: index.NewSnapshotKey(target, slot),
+			Group:       "mainnet",
+			UpdatedAt:   time.Now(),
+			Info: &types.SnapshotInfo{
+				Slot: slot,
+				Hash: solana.Hash{hash},
+				Files: []*types.SnapshotFile{{
+					FileName: "snapshot.tar.zst",
+					Slot:     slot,
+					BaseSlot: baseSlot,
+					Hash:     solana.Hash{hash},
+				}},
+			},
+		}
+	}
+	db.UpsertSnapshots(
+		newEntry("host1", 100, 0, 1),
+		newEntry("host2", 100, 0, 1),
+		newEntry("host3", 100, 0, 2),
+		newEntry("host1", 150, 100, 3),
+	)
+
+	h := NewDashboardHandler(db, collector)
+	h.Configure(&types.Config{
+		TargetGroups: []*types.TargetGroup{
+			{Group: "mainnet", Scheme: "https", APIPath: "/sidecar"},
+			{Group: "testnet", Scheme: "http"},
+		},
+	})
+	groups := h.collect()
+	require.Len(t, groups, 2)
+	assert.Equal(t, "testnet", groups[1].Name)
+	assert.Empty(t, groups[1].Best)
+
+	group := groups[0]
+	assert.Equal(t, 3, group.Unknown)
+	assert.Equal(t, 4, group.NumEntries)
+	require.Len(t, group.Best, 2)
+	assert.Equal(t, uint64(100), group.Best[0].Source.Slot)
+	assert.Equal(t, 2, group.Best[0].Sources)
+	assert.Equal(t, []string{"curl -fO https://host1/sidecar/v1/snapshot/snapshot.tar.zst"}, group.Best[0].Commands)
+	assert.Equal(t, uint64(150), group.Best[1].Source.Slot)
+
+	assert.Equal(t, []dashboardColumn{
+		{Slot: 150, BaseSlot: 100},
+		{Slot: 100, Conflict: true},
+	}, group.Columns)
+	require.Len(t, group.Rows, 3)
+	assert.Equal(t, "host3", group.Rows[2].Target)
+	assert.Equal(t, []string{"", solana.Hash{2}.String()[:8]}, group.Rows[2].Cells)
+
+	require.Len(t, group.Conflicts, 1)
+	assert.Equal(t, []dashboardHash{
+		{Hash: solana.Hash{1}.String(), Targets: []string{"host1", "host2"}},
+		{Hash: solana.Hash{2}.String(), Targets: []string{"host3"}},
+	}, group.Conflicts[0].Hashes)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Hash conflicts")
+	assert.Contains(t, rec.Body.String(), "solana-cluster fetch --tracker http://localhost:8458")
+}
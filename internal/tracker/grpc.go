@@ -0,0 +1,199 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"context"
+	"time"
+
+	"go.blockdaemon.com/solana/cluster-manager/api/trackerpb"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPCServer implements the tracker gRPC API.
+//
+// It serves the same index, cordons and scoring as the HTTP handler it wraps.
+type GRPCServer struct {
+	trackerpb.UnimplementedTrackerServer
+
+	Handler *Handler
+}
+
+// NewGRPCServer creates a gRPC API backed by the given HTTP API handler.
+func NewGRPCServer(handler *Handler) *GRPCServer {
+	return &GRPCServer{Handler: handler}
+}
+
+// ListSnapshots returns known snapshots matching the query, one page at a time.
+func (s *GRPCServer) ListSnapshots(_ context.Context, req *trackerpb.ListSnapshotsRequest) (*trackerpb.ListSnapshotsResponse, error) {
+	params := snapshotQueryFromProto(req.GetQuery())
+	params.Sort = req.GetSort()
+	params.Cursor = req.GetCursor()
+	params.Limit = int(req.GetLimit())
+	query, err := index.NewQuery(params, time.Now())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	entries, next := s.Handler.DB.FindSnapshots(query)
+	res := &trackerpb.ListSnapshotsResponse{
+		Snapshots:  make([]*trackerpb.SnapshotEntry, len(entries)),
+		NextCursor: next,
+	}
+	for i, entry := range entries {
+		res.Snapshots[i] = &trackerpb.SnapshotEntry{
+			Target:     entry.Target,
+			Group:      entry.Group,
+			Info:       snapshotInfoToProto(entry.Info),
+			UpdatedAt:  timestamppb.New(entry.UpdatedAt),
+			Unverified: entry.Unverified,
+			Origin:     entry.Origin,
+		}
+	}
+	return res, nil
+}
+
+// GetBestSnapshots returns the currently available best snapshots matching the query.
+func (s *GRPCServer) GetBestSnapshots(_ context.Context, req *trackerpb.GetBestSnapshotsRequest) (*trackerpb.GetBestSnapshotsResponse, error) {
+	params, err := s.bestSnapshotsParams(req)
+	if err != nil {
+		return nil, err
+	}
+	sources := s.Handler.bestSnapshots(params, time.Now())
+	return &trackerpb.GetBestSnapshotsResponse{Snapshots: snapshotSourcesToProto(sources)}, nil
+}
+
+// WatchBestSnapshots streams changes of the best snapshots ranking.
+func (s *GRPCServer) WatchBestSnapshots(req *trackerpb.GetBestSnapshotsRequest, stream trackerpb.Tracker_WatchBestSnapshotsServer) error {
+	params, err := s.bestSnapshotsParams(req)
+	if err != nil {
+		return err
+	}
+	err = s.Handler.watchBestSnapshots(stream.Context(), params, func(event *types.BestSnapshotsEvent) error {
+		return stream.Send(&trackerpb.BestSnapshotsEvent{
+			Snapshots: snapshotSourcesToProto(event.Snapshots),
+			Added:     snapshotIDsToProto(event.Added),
+			Removed:   snapshotIDsToProto(event.Removed),
+		})
+	}, nil)
+	if err == stream.Context().Err() {
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
+
+func (s *GRPCServer) bestSnapshotsParams(req *trackerpb.GetBestSnapshotsRequest) (bestSnapshotsParams, error) {
+	params := bestSnapshotsParams{
+		Max:           int(req.GetMax()),
+		SnapshotQuery: snapshotQueryFromProto(req.GetQuery()),
+	}
+	if err := s.Handler.checkBestSnapshotsParams(&params); err != nil {
+		return params, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return params, nil
+}
+
+func snapshotQueryFromProto(q *trackerpb.SnapshotQuery) types.SnapshotQuery {
+	if q == nil {
+		return types.SnapshotQuery{}
+	}
+	return types.SnapshotQuery{
+		Group:     q.GetGroup(),
+		Target:    q.GetTarget(),
+		MinSlot:   q.GetMinSlot(),
+		MaxSlot:   q.GetMaxSlot(),
+		Hash:      q.GetHash(),
+		Kind:      q.GetKind(),
+		BaseSlot:  q.BaseSlot,
+		MinSize:   q.GetMinSize(),
+		MaxAge:    q.GetMaxAge().AsDuration(),
+		Verified:  q.GetVerified(),
+		LocalOnly: q.GetLocalOnly(),
+	}
+}
+
+func snapshotSourcesToProto(sources []types.SnapshotSource) []*trackerpb.SnapshotSource {
+	res := make([]*trackerpb.SnapshotSource, len(sources))
+	for i := range sources {
+		source := &sources[i]
+		res[i] = &trackerpb.SnapshotSource{
+			Info:       snapshotInfoToProto(&source.SnapshotInfo),
+			Target:     source.Target,
+			UpdatedAt:  timestamppb.New(source.UpdatedAt),
+			Unverified: source.Unverified,
+			Origin:     source.Origin,
+		}
+		if score := source.Score; score != nil {
+			res[i].Score = &trackerpb.SnapshotScore{
+				Total:     score.Total,
+				Slot:      score.Slot,
+				Freshness: score.Freshness,
+				Latency:   score.Latency,
+				Health:    score.Health,
+				Agreement: score.Agreement,
+				Labels:    score.Labels,
+				Origin:    score.Origin,
+				SlotLag:   score.SlotLag,
+			}
+		}
+	}
+	return res
+}
+
+func snapshotInfoToProto(info *types.SnapshotInfo) *trackerpb.SnapshotInfo {
+	res := &trackerpb.SnapshotInfo{
+		Slot:     info.Slot,
+		Hash:     info.Hash.String(),
+		Files:    make([]*trackerpb.SnapshotFile, len(info.Files)),
+		Size:     info.TotalSize,
+		Draining: info.Draining,
+	}
+	for i, file := range info.Files {
+		res.Files[i] = &trackerpb.SnapshotFile{
+			FileName: file.FileName,
+			Slot:     file.Slot,
+			BaseSlot: file.BaseSlot,
+			Hash:     file.Hash.String(),
+			Ext:      file.Ext,
+			Size:     file.Size,
+		}
+		if file.ModTime != nil {
+			res.Files[i].ModTime = timestamppb.New(*file.ModTime)
+		}
+	}
+	if meta := info.SnapshotMetadata; meta != nil {
+		res.Metadata = &trackerpb.SnapshotMetadata{
+			Version:        meta.Version,
+			Epoch:          meta.Epoch,
+			ParentSlot:     meta.ParentSlot,
+			Capitalization: meta.Capitalization,
+		}
+		if meta.AccountsHash != nil {
+			res.Metadata.AccountsHash = meta.AccountsHash.String()
+		}
+	}
+	return res
+}
+
+func snapshotIDsToProto(ids []types.SnapshotID) []*trackerpb.SnapshotID {
+	res := make([]*trackerpb.SnapshotID, len(ids))
+	for i, id := range ids {
+		res[i] = &trackerpb.SnapshotID{Slot: id.Slot, Hash: id.Hash.String()}
+	}
+	return res
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "solana_cluster",
+		Subsystem: "tracker",
+		Name:      "http_requests_total",
+		Help:      "Number of API requests served",
+	}, []string{"method", "route", "code"})
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "solana_cluster",
+		Subsystem: "tracker",
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of API requests",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	assignmentEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "solana_cluster",
+		Subsystem: "tracker",
+		Name:      "assignments_total",
+		Help:      "Number of snapshot source leases by event (granted, completed, failed, expired)",
+	}, []string{"event"})
+)
+
+// Metrics returns a middleware recording request counts and latencies.
+// Requests are labelled by route pattern to keep cardinality bounded.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
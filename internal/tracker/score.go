@@ -0,0 +1,171 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"sort"
+	"time"
+
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+	"go.uber.org/atomic"
+)
+
+// Scorer rates candidates of the best snapshots ranking.
+type Scorer interface {
+	// Score returns the score of each entry, in the same order.
+	// Returns nil to rank entries by slot only.
+	Score(entries []*index.SnapshotEntry, now time.Time) []*types.SnapshotScore
+}
+
+// WeightedScorer sums weighted inputs from the index, scrape health, slot lag,
+// target labels and the penalty of federated sources.
+//
+// Weights are taken from the scoring section of the config.
+// Without one, it returns no scores.
+type WeightedScorer struct {
+	Targets func() []types.TargetStatus               // scrape health, e.g. from the collector
+	Lag     func(group, target string) (uint64, bool) // slot lag of a node, e.g. from the slot monitor
+
+	config atomic.Pointer[weightedScoring]
+}
+
+type weightedScoring struct {
+	*types.ScoringConfig
+	groups map[string]*types.TargetGroup
+}
+
+// NewWeightedScorer creates a scorer reading scrape health from the given function.
+func NewWeightedScorer(targets func() []types.TargetStatus) *WeightedScorer {
+	return &WeightedScorer{Targets: targets}
+}
+
+// Configure applies the weights and target labels of the given config.
+func (s *WeightedScorer) Configure(conf *types.Config) {
+	if conf.Scoring == nil {
+		s.config.Store(nil)
+		return
+	}
+	groups := make(map[string]*types.TargetGroup, len(conf.TargetGroups))
+	for _, group := range conf.TargetGroups {
+		groups[group.Group] = group
+	}
+	s.config.Store(&weightedScoring{
+		ScoringConfig: conf.Scoring,
+		groups:        groups,
+	})
+}
+
+// Score implements Scorer.
+func (s *WeightedScorer) Score(entries []*index.SnapshotEntry, now time.Time) []*types.SnapshotScore {
+	conf := s.config.Load()
+	if conf == nil {
+		return nil
+	}
+	w := conf.Weights
+
+	var newest uint64
+	sources := make(map[types.SnapshotID]int)
+	for _, entry := range entries {
+		newest = max(newest, entry.Info.Slot)
+		sources[types.SnapshotID{Slot: entry.Info.Slot, Hash: entry.Info.Hash}]++
+	}
+	statuses := make(map[targetKey]*types.TargetStatus)
+	if (w.Latency != 0 || w.Health != 0) && s.Targets != nil {
+		targets := s.Targets()
+		for i := range targets {
+			statuses[targetKey{targets[i].Group, targets[i].Target}] = &targets[i]
+		}
+	}
+
+	scores := make([]*types.SnapshotScore, len(entries))
+	for i, entry := range entries {
+		score := &types.SnapshotScore{
+			Slot:      penalty(w.Slot, float64(newest-entry.Info.Slot)),
+			Freshness: penalty(w.Freshness, max(now.Sub(entry.UpdatedAt), 0).Seconds()),
+			Agreement: w.Agreement * float64(sources[types.SnapshotID{Slot: entry.Info.Slot, Hash: entry.Info.Hash}]-1),
+		}
+		// Sources learned from federated trackers inherit health and labels of the tracker.
+		scraped := entry.Target
+		if entry.Origin != "" {
+			scraped = entry.Origin
+		}
+		if status, ok := statuses[targetKey{entry.Group, scraped}]; ok {
+			score.Latency = penalty(w.Latency, status.LastDuration.Seconds())
+			score.Health = penalty(w.Health, float64(status.ConsecutiveFailures))
+		}
+		if group, ok := conf.groups[entry.Group]; ok {
+			if w.Labels != 0 {
+				labels := group.LabelsOf(scraped)
+				for key, value := range conf.PreferLabels {
+					if labels[key] == value {
+						score.Labels += w.Labels
+					}
+				}
+			}
+			if entry.Origin != "" && group.Federation != nil {
+				score.Origin = penalty(1, group.Federation.Penalty)
+			}
+		}
+		// The slot lag of federated sources is unknown to this tracker.
+		if w.SlotLag != 0 && entry.Origin == "" && s.Lag != nil {
+			if lag, ok := s.Lag(entry.Group, entry.Target); ok {
+				score.SlotLag = penalty(w.SlotLag, float64(lag))
+			}
+		}
+		score.Total = score.Slot + score.Freshness + score.Latency + score.Health + score.Agreement + score.Labels + score.Origin + score.SlotLag
+		scores[i] = score
+	}
+	return scores
+}
+
+type targetKey struct {
+	group, target string
+}
+
+// penalty returns the negative weighted value, avoiding negative zero.
+func penalty(weight, value float64) float64 {
+	if weight == 0 || value == 0 {
+		return 0
+	}
+	return -weight * value
+}
+
+// rankByScore orders entries by descending score and keeps the first limit.
+// Draining sources are ranked after all others.
+// Entries of equal score keep their order.
+func rankByScore(entries []*index.SnapshotEntry, scores []*types.SnapshotScore, limit int) ([]*index.SnapshotEntry, []*types.SnapshotScore) {
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if entries[a].Info.Draining != entries[b].Info.Draining {
+			return entries[b].Info.Draining
+		}
+		return scores[a].Total > scores[b].Total
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+	rankedEntries := make([]*index.SnapshotEntry, len(order))
+	rankedScores := make([]*types.SnapshotScore, len(order))
+	for i, idx := range order {
+		rankedEntries[i] = entries[idx]
+		rankedScores[i] = scores[idx]
+	}
+	return rankedEntries, rankedScores
+}
@@ -0,0 +1,143 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.blockdaemon.com/solana/cluster-manager/internal/index"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+func TestWeightedScorer(t *testing.T) {
+	now := time.Now()
+	db := index.NewDB()
+	newEntry := func(group, target string, slot uint64) *index.SnapshotEntry {
+		return &index.SnapshotEntry{
+			SnapshotKey: index.NewSnapshotKey(target, slot),
+			Group:       group,
+			UpdatedAt:   now,
+			Info: &types.SnapshotInfo{
+				Slot:  slot,
+				Hash:  solana.Hash{byte(slot)},
+				Files: []*types.SnapshotFile{},
+			},
+		}
+	}
+	db.UpsertSnapshots(newEntry("far", "host1", 101))
+	db.UpsertSnapshots(newEntry("near", "host2", 100))
+	db.UpsertSnapshots(newEntry("near", "host3", 100))
+
+	scorer := NewWeightedScorer(func() []types.TargetStatus {
+		return []types.TargetStatus{
+			{Group: "far", Target: "host1", LastDuration: 2 * time.Second},
+			{Group: "near", Target: "host3", ConsecutiveFailures: 2},
+		}
+	})
+	h := NewHandler(db)
+	h.Scorer = scorer
+	targets := func(sources []types.SnapshotSource) (targets []string) {
+		for _, source := range sources {
+			targets = append(targets, source.Target)
+		}
+		return
+	}
+
+	// Without scoring config, the newest slot wins.
+	scorer.Configure(&types.Config{})
+	sources := h.bestSnapshots(bestSnapshotsParams{Max: 2}, now)
+	assert.Equal(t, []string{"host1"}, targets(sources[:1]))
+	assert.Nil(t, sources[0].Score)
+
+	// Nearby sources agreeing on a snapshot beat a slow remote one.
+	scorer.Configure(&types.Config{
+		TargetGroups: []*types.TargetGroup{
+			{Group: "far", Labels: map[string]string{"region": "us"}},
+			{Group: "near", Labels: map[string]string{"region": "eu"}},
+		},
+		Scoring: &types.ScoringConfig{
+			Weights: types.ScoreWeights{
+				Slot:      1,
+				Freshness: 1,
+				Latency:   1,
+				Health:    1,
+				Agreement: 0.5,
+				Labels:    5,
+			},
+			PreferLabels: map[string]string{"region": "eu"},
+		},
+	})
+	sources = h.bestSnapshots(bestSnapshotsParams{Max: 3}, now)
+	assert.Equal(t, []string{"host2", "host3", "host1"}, targets(sources))
+	require.NotNil(t, sources[1].Score)
+	assert.Equal(t, types.SnapshotScore{
+		Total:     2.5,
+		Slot:      -1,
+		Health:    -2,
+		Agreement: 0.5,
+		Labels:    5,
+	}, *sources[1].Score)
+	assert.Equal(t, types.SnapshotScore{Total: -2, Latency: -2}, *sources[2].Score)
+
+	// Exactly max sources are returned.
+	sources = h.bestSnapshots(bestSnapshotsParams{Max: 1}, now)
+	assert.Equal(t, []string{"host2"}, targets(sources))
+
+	// Draining sources come last regardless of score.
+	draining := newEntry("near", "host2", 100)
+	draining.Info.Draining = true
+	db.UpsertSnapshots(draining)
+	sources = h.bestSnapshots(bestSnapshotsParams{Max: 3}, now)
+	assert.Equal(t, []string{"host3", "host1", "host2"}, targets(sources))
+
+	// Federated sources pay the penalty of their group, and inherit the health of their tracker.
+	federated := newEntry("remote", "host4", 101)
+	federated.Origin = "tracker:8458"
+	db.UpsertSnapshots(federated)
+	scorer.Targets = func() []types.TargetStatus {
+		return []types.TargetStatus{{Group: "remote", Target: "tracker:8458", ConsecutiveFailures: 1}}
+	}
+	scorer.Configure(&types.Config{
+		TargetGroups: []*types.TargetGroup{
+			{Group: "remote", Federation: &types.Federation{Penalty: 10}},
+		},
+		Scoring: &types.ScoringConfig{Weights: types.ScoreWeights{Slot: 1, Health: 1}},
+	})
+	sources = h.bestSnapshots(bestSnapshotsParams{Max: 4}, now)
+	assert.Equal(t, []string{"host1", "host3", "host4", "host2"}, targets(sources))
+	assert.Equal(t, types.SnapshotScore{Total: -11, Health: -1, Origin: -10}, *sources[2].Score)
+
+	// Lagging nodes rank behind nodes in sync, federated sources have no known lag.
+	scorer.Lag = func(group, target string) (uint64, bool) {
+		switch target {
+		case "host1":
+			return 5, group == "far"
+		case "host4":
+			return 100, true
+		}
+		return 0, false
+	}
+	scorer.Configure(&types.Config{
+		Scoring: &types.ScoringConfig{Weights: types.ScoreWeights{Slot: 1, SlotLag: 1}},
+	})
+	sources = h.bestSnapshots(bestSnapshotsParams{Max: 4}, now)
+	assert.Equal(t, []string{"host4", "host3", "host1", "host2"}, targets(sources))
+	assert.Equal(t, types.SnapshotScore{}, *sources[0].Score)
+	assert.Equal(t, types.SnapshotScore{Total: -5, SlotLag: -5}, *sources[2].Score)
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.blockdaemon.com/solana/cluster-manager/internal/scraper"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+// TargetsHandler implements the target health API methods.
+type TargetsHandler struct {
+	Collector *scraper.Collector
+}
+
+// NewTargetsHandler creates a new target health API using the provided collector.
+func NewTargetsHandler(collector *scraper.Collector) *TargetsHandler {
+	return &TargetsHandler{Collector: collector}
+}
+
+// RegisterHandlers registers this API with Gin web framework.
+func (h *TargetsHandler) RegisterHandlers(group gin.IRoutes) {
+	group.GET("/targets", h.GetTargets)
+	group.GET("/targets/history", h.GetHistory)
+}
+
+// GetTargets returns the scrape health of all targets.
+// Optionally filters by the "group" and "health" query parameters.
+func (h *TargetsHandler) GetTargets(c *gin.Context) {
+	var query struct {
+		Group  string `form:"group"`
+		Health string `form:"health"`
+	}
+	if err := c.BindQuery(&query); err != nil {
+		return
+	}
+	c.JSON(http.StatusOK, h.filter(query.Group, query.Health))
+}
+
+func (h *TargetsHandler) filter(group, health string) []types.TargetStatus {
+	targets := h.Collector.Targets()
+	filtered := targets[:0]
+	for _, target := range targets {
+		if (group == "" || target.Group == group) && (health == "" || target.Health == health) {
+			filtered = append(filtered, target)
+		}
+	}
+	return filtered
+}
+
+// GetHistory returns the snapshot production history of all scraped targets.
+// Optionally filters by the "group", "target" and "stalled" query parameters.
+func (h *TargetsHandler) GetHistory(c *gin.Context) {
+	var query struct {
+		Group   string `form:"group"`
+		Target  string `form:"target"`
+		Stalled bool   `form:"stalled"`
+	}
+	if err := c.BindQuery(&query); err != nil {
+		return
+	}
+	histories := h.Collector.History(time.Now())
+	filtered := histories[:0]
+	for _, history := range histories {
+		if (query.Group == "" || history.Group == query.Group) &&
+			(query.Target == "" || history.Target == query.Target) &&
+			(!query.Stalled || history.Stalled) {
+			filtered = append(filtered, history)
+		}
+	}
+	c.JSON(http.StatusOK, filtered)
+}
+
+//go:embed targets.html
+var targetsPageSource string
+
+var targetsPage = template.Must(template.New("targets").Funcs(template.FuncMap{
+	"since": func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return time.Since(t).Truncate(time.Second).String() + " ago"
+	},
+	"ms": func(d time.Duration) string {
+		return d.Truncate(time.Millisecond).String()
+	},
+}).Parse(targetsPageSource))
+
+// ServeHTTP renders the target health as an HTML page.
+// Intended for the internal listener.
+func (h *TargetsHandler) ServeHTTP(wr http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	data := struct {
+		Group   string
+		Health  string
+		Targets []types.TargetStatus
+	}{
+		Group:   query.Get("group"),
+		Health:  query.Get("health"),
+		Targets: h.filter(query.Get("group"), query.Get("health")),
+	}
+	wr.Header().Set("content-type", "text/html; charset=utf-8")
+	_ = targetsPage.Execute(wr, &data)
+}
@@ -17,52 +17,156 @@ package tracker
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.blockdaemon.com/solana/cluster-manager/internal/index"
 	"go.blockdaemon.com/solana/cluster-manager/types"
 )
 
+// NextCursorHeader carries the cursor of the next page in paginated responses.
+const NextCursorHeader = "X-Next-Cursor"
+
 // Handler implements the tracker API methods.
 type Handler struct {
-	DB *index.DB
+	DB      *index.DB
+	Cordons *Cordons // targets excluded from best snapshots, optional
+	Scorer  Scorer   // ranks best snapshots by score instead of slot, optional
+
+	MaxBestSnapshots int           // upper bound for the "max" parameter of best_snapshots
+	WatchDebounce    time.Duration // min delay between watch events
+	WatchKeepalive   time.Duration // interval of keepalive comments on idle watch streams
 }
 
 // NewHandler creates a new tracker API using the provided database.
 func NewHandler(db *index.DB) *Handler {
-	return &Handler{DB: db}
+	return &Handler{
+		DB:               db,
+		MaxBestSnapshots: 25,
+		WatchDebounce:    250 * time.Millisecond,
+		WatchKeepalive:   30 * time.Second,
+	}
 }
 
 // RegisterHandlers registers this API with Gin web framework.
 func (h *Handler) RegisterHandlers(group gin.IRoutes) {
 	group.GET("/snapshots", h.GetSnapshots)
 	group.GET("/best_snapshots", h.GetBestSnapshots)
+	group.GET("/best_snapshots/watch", h.WatchBestSnapshots)
 }
 
+// GetSnapshots returns known snapshots matching the query, one page at a time.
+//
+// Without a limit, all matching snapshots are returned.
+// If more snapshots are available, the response carries a cursor to the next page.
 func (h *Handler) GetSnapshots(c *gin.Context) {
-	c.JSON(http.StatusOK, h.DB.GetAllSnapshots())
+	var params types.SnapshotQuery
+	if err := c.BindQuery(&params); err != nil {
+		return
+	}
+	query, err := index.NewQuery(params, time.Now())
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	entries, next := h.DB.FindSnapshots(query)
+	if entries == nil {
+		entries = []*index.SnapshotEntry{}
+	}
+	if next != "" {
+		c.Header(NextCursorHeader, next)
+	}
+	c.JSON(http.StatusOK, entries)
 }
 
-// GetBestSnapshots returns the currently available best snapshots.
-func (h *Handler) GetBestSnapshots(c *gin.Context) {
-	var query struct {
-		Max int `form:"max"`
+// bestSnapshotsParams are the query parameters of best_snapshots endpoints.
+// Sort and pagination parameters are ignored.
+type bestSnapshotsParams struct {
+	Max int `form:"max"`
+	types.SnapshotQuery
+}
+
+// bindBestSnapshotsParams parses and validates the query parameters.
+// Returns false if the request has been aborted.
+func (h *Handler) bindBestSnapshotsParams(c *gin.Context) (params bestSnapshotsParams, ok bool) {
+	if err := c.BindQuery(&params); err != nil {
+		return params, false
 	}
-	if err := c.BindQuery(&query); err != nil {
-		return
+	if err := h.checkBestSnapshotsParams(&params); err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return params, false
 	}
-	const maxItems = 25
-	if query.Max < 0 || query.Max > 25 {
-		query.Max = maxItems
+	return params, true
+}
+
+// checkBestSnapshotsParams clamps max, drops sort and pagination, and validates filters.
+func (h *Handler) checkBestSnapshotsParams(params *bestSnapshotsParams) error {
+	if params.Max < 0 || params.Max > h.MaxBestSnapshots {
+		params.Max = h.MaxBestSnapshots
 	}
-	entries := h.DB.GetBestSnapshots(query.Max)
+	params.Sort, params.Cursor, params.Limit = "", "", 0
+	_, err := index.NewQuery(params.SnapshotQuery, time.Now())
+	return err
+}
+
+// bestMatcher returns a filter selecting candidates for best snapshots.
+func (h *Handler) bestMatcher(params bestSnapshotsParams, now time.Time) func(*index.SnapshotEntry) bool {
+	query, _ := index.NewQuery(params.SnapshotQuery, now)
+	if h.Cordons == nil {
+		return query.Match
+	}
+	return func(entry *index.SnapshotEntry) bool {
+		return query.Match(entry) && !h.Cordons.Cordoned(entry.Target)
+	}
+}
+
+// bestSnapshots returns the ranking for the given validated parameters.
+//
+// If the scorer rates the candidates, exactly max sources are returned in order of score.
+// Otherwise, sources are ordered by slot and all sources of the last slot are included.
+func (h *Handler) bestSnapshots(params bestSnapshotsParams, now time.Time) []types.SnapshotSource {
+	entries, scores := h.bestEntries(params, nil, now)
 	sources := make([]types.SnapshotSource, len(entries))
 	for i, entry := range entries {
 		sources[i] = types.SnapshotSource{
 			SnapshotInfo: *entry.Info,
 			Target:       entry.Target,
 			UpdatedAt:    entry.UpdatedAt,
+			Unverified:   entry.Unverified,
+			Origin:       entry.Origin,
 		}
+		if scores != nil {
+			sources[i].Score = scores[i]
+		}
+	}
+	return sources
+}
+
+// bestEntries returns the index entries behind bestSnapshots, and their scores if any.
+// Entries for which keep returns false are skipped. A nil keep function accepts all entries.
+func (h *Handler) bestEntries(params bestSnapshotsParams, keep func(*index.SnapshotEntry) bool, now time.Time) ([]*index.SnapshotEntry, []*types.SnapshotScore) {
+	match := h.bestMatcher(params, now)
+	if keep != nil {
+		query := match
+		match = func(entry *index.SnapshotEntry) bool {
+			return query(entry) && keep(entry)
+		}
+	}
+	if h.Scorer != nil {
+		entries := h.DB.GetBestSnapshotsFunc(-1, match)
+		if scores := h.Scorer.Score(entries, now); scores != nil {
+			return rankByScore(entries, scores, max(params.Max, 1))
+		}
+	}
+	return h.DB.GetBestSnapshotsFunc(params.Max, match), nil
+}
+
+// GetBestSnapshots returns the currently available best snapshots matching the query.
+// Snapshots of cordoned targets are skipped.
+func (h *Handler) GetBestSnapshots(c *gin.Context) {
+	params, ok := h.bindBestSnapshotsParams(c)
+	if !ok {
+		return
 	}
-	c.JSON(http.StatusOK, sources)
+	c.JSON(http.StatusOK, h.bestSnapshots(params, time.Now()))
 }
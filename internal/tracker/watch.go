@@ -0,0 +1,188 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"go.blockdaemon.com/solana/cluster-manager/types"
+)
+
+// WatchBestSnapshots streams changes of the best snapshots ranking via SSE.
+//
+// Accepts the same parameters as GetBestSnapshots.
+// An event is sent initially, and then whenever the ranking changes,
+// a new snapshot appears, or a snapshot loses all its sources.
+// Scrapes that confirm the existing state do not produce events.
+func (h *Handler) WatchBestSnapshots(c *gin.Context) {
+	params, ok := h.bindBestSnapshotsParams(c)
+	if !ok {
+		return
+	}
+	var eventID uint64
+	c.Header("cache-control", "no-cache")
+	_ = h.watchBestSnapshots(c.Request.Context(), params,
+		func(event *types.BestSnapshotsEvent) error {
+			eventID++
+			c.Render(-1, sse.Event{
+				Event: "best_snapshots",
+				Id:    strconv.FormatUint(eventID, 10),
+				Data:  event,
+			})
+			c.Writer.Flush()
+			return nil
+		},
+		func() error {
+			_, err := io.WriteString(c.Writer, ": keepalive\n\n")
+			c.Writer.Flush()
+			return err
+		})
+}
+
+// watchBestSnapshots calls send with the initial ranking and on every change,
+// until ctx is cancelled or a callback fails.
+// Idle streams call keepalive periodically, if set.
+func (h *Handler) watchBestSnapshots(
+	ctx context.Context,
+	params bestSnapshotsParams,
+	send func(*types.BestSnapshotsEvent) error,
+	keepalive func() error,
+) error {
+	ticker := time.NewTicker(h.WatchKeepalive)
+	defer ticker.Stop()
+
+	var (
+		lastRank []rankKey
+		lastIDs  map[types.SnapshotID]struct{}
+		watch    <-chan struct{}
+		cordons  <-chan struct{}
+	)
+	for {
+		if watch != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				// Also re-evaluate, as max_age filters depend on the current time.
+				if keepalive != nil {
+					if err := keepalive(); err != nil {
+						return err
+					}
+				}
+			case <-cordons:
+			case <-watch:
+				// Coalesce bursts of index updates, e.g. from a scrape round.
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(h.WatchDebounce):
+				}
+			}
+		}
+		// Register the watch before reading, so that no update is missed.
+		watch = h.DB.WatchCh()
+		if h.Cordons != nil {
+			cordons = h.Cordons.WatchCh()
+		}
+
+		now := time.Now()
+		sources := h.bestSnapshots(params, now)
+		rank := rankKeys(sources)
+		ids := h.snapshotIDs(params, now)
+		event := types.BestSnapshotsEvent{Snapshots: sources}
+		if lastIDs != nil {
+			event.Added = diffIDs(ids, lastIDs)
+			event.Removed = diffIDs(lastIDs, ids)
+		}
+		changed := lastIDs == nil || len(event.Added) > 0 || len(event.Removed) > 0 || !equalRank(rank, lastRank)
+		lastRank, lastIDs = rank, ids
+		if !changed {
+			continue
+		}
+		if err := send(&event); err != nil {
+			return err
+		}
+	}
+}
+
+// rankKey is the part of a ranked source that is relevant for change detection.
+// Notably, it excludes the scrape time.
+type rankKey struct {
+	target     string
+	slot       uint64
+	hash       [32]byte
+	draining   bool
+	unverified bool
+}
+
+func rankKeys(sources []types.SnapshotSource) []rankKey {
+	keys := make([]rankKey, len(sources))
+	for i, src := range sources {
+		keys[i] = rankKey{
+			target:     src.Target,
+			slot:       src.Slot,
+			hash:       src.Hash,
+			draining:   src.Draining,
+			unverified: src.Unverified,
+		}
+	}
+	return keys
+}
+
+func equalRank(a, b []rankKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// snapshotIDs returns all distinct snapshots eligible for the ranking, regardless of rank.
+func (h *Handler) snapshotIDs(params bestSnapshotsParams, now time.Time) map[types.SnapshotID]struct{} {
+	match := h.bestMatcher(params, now)
+	ids := make(map[types.SnapshotID]struct{})
+	for _, entry := range h.DB.GetAllSnapshots() {
+		if match(entry) {
+			ids[types.SnapshotID{Slot: entry.Info.Slot, Hash: entry.Info.Hash}] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// diffIDs returns the IDs in a but not in b, newest first.
+func diffIDs(a, b map[types.SnapshotID]struct{}) (diff []types.SnapshotID) {
+	for id := range a {
+		if _, ok := b[id]; !ok {
+			diff = append(diff, id)
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		if diff[i].Slot != diff[j].Slot {
+			return diff[i].Slot > diff[j].Slot
+		}
+		return string(diff[i].Hash[:]) < string(diff[j].Hash[:])
+	})
+	return
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "github.com/gagliardetto/solana-go"
+
+// Advice values of AdviceResponse.
+const (
+	AdviceFetch        = "fetch"         // download the listed files
+	AdviceUpToDate     = "up_to_date"    // local snapshot is recent enough
+	AdviceNothingFound = "nothing_found" // no snapshot available
+)
+
+// AdviceRequest asks the tracker whether and what to download,
+// given the snapshots available locally.
+type AdviceRequest struct {
+	Snapshots []*SnapshotInfo `json:"snapshots,omitempty"` // local snapshots, in any order
+	Group     string          `json:"group,omitempty"`     // only consider sources of this group
+	Cluster   string          `json:"cluster,omitempty"`   // only consider groups labeled cluster=<value>
+	MinSlots  *uint64         `json:"min_slots,omitempty"` // download only snapshots this many slots newer than local, default 500
+	MaxSlots  *uint64         `json:"max_slots,omitempty"` // refuse snapshots this many slots older than the newest, default 10000
+
+	MaxSources int `json:"max_sources,omitempty"` // candidate sources per file, default 3
+}
+
+// AdviceResponse tells a client what to download.
+type AdviceResponse struct {
+	Advice  string   `json:"advice"`
+	Reasons []string `json:"reasons"`
+
+	// Set if the advice is to fetch.
+	Slot    uint64        `json:"slot,omitempty"`
+	Hash    *solana.Hash  `json:"hash,omitempty"`
+	MinSlot uint64        `json:"min_slot,omitempty"` // lowest slot still worth downloading
+	Files   []*AdviceFile `json:"files,omitempty"`    // files missing locally
+}
+
+// AdviceFile is a file to download, with its candidate sources in order of preference.
+type AdviceFile struct {
+	SnapshotFile
+	Sources []AdviceSource `json:"sources"`
+}
+
+// AdviceSource is a sidecar serving a file.
+type AdviceSource struct {
+	Target string `json:"target"`
+	URL    string `json:"url"`
+	Origin string `json:"origin,omitempty"` // federated tracker the source was learned from
+}
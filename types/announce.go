@@ -0,0 +1,55 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// SnapshotAnnouncement is pushed by a sidecar to the tracker when its snapshots change.
+//
+// The target is passed in the query, so the tracker can check the token before reading the body.
+type SnapshotAnnouncement struct {
+	Snapshots []*SnapshotInfo `json:"snapshots"`
+}
+
+// AnnounceAuth authenticates snapshot announcements pushed by sidecars.
+//
+// Each sidecar presents a bearer token derived from the secret and its own target address,
+// so a token only allows announcing snapshots for that one target.
+type AnnounceAuth struct {
+	Secret string `json:"secret" yaml:"secret"`
+}
+
+// AnnounceToken derives the announce token of a target: hex(HMAC-SHA256(secret, target)).
+func AnnounceToken(secret, target string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(target))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Check returns whether the request carries the announce token of the given target.
+func (a *AnnounceAuth) Check(req *http.Request, target string) bool {
+	token, ok := strings.CutPrefix(req.Header.Get("authorization"), "Bearer ")
+	if !ok || a.Secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(AnnounceToken(a.Secret, target))) == 1
+}
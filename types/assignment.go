@@ -0,0 +1,46 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Results of an assignment reported on release.
+const (
+	AssignmentCompleted = "completed"
+	AssignmentFailed    = "failed"
+)
+
+// AssignmentRequest asks the tracker for a source of a snapshot.
+type AssignmentRequest struct {
+	Slot   uint64      `json:"slot"`
+	Hash   solana.Hash `json:"hash"`
+	Group  string      `json:"group,omitempty"`
+	Client string      `json:"client,omitempty"` // name of the fetcher, for logs
+}
+
+// Assignment is a lease on a snapshot source.
+//
+// The tracker counts unexpired leases per target and assigns
+// new downloads to the least loaded source.
+type Assignment struct {
+	ID        string         `json:"id"`
+	Source    SnapshotSource `json:"source"`
+	ExpiresAt time.Time      `json:"expires_at"` // renew before this time
+	Active    int            `json:"active"`     // leases on the source, including this one
+}
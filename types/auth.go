@@ -15,13 +15,19 @@
 package types
 
 import (
+	"crypto/subtle"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 type BasicAuth struct {
@@ -42,6 +48,59 @@ func (b *BearerAuth) Apply(header http.Header) {
 	header.Set("authorization", "Bearer "+b.Token)
 }
 
+// ServerAuth lists credentials accepted by a server.
+// An empty ServerAuth accepts all requests.
+type ServerAuth struct {
+	BasicAuth  []*BasicAuth  `json:"basic_auth" yaml:"basic_auth"`
+	BearerAuth []*BearerAuth `json:"bearer_auth" yaml:"bearer_auth"`
+}
+
+// LoadServerAuth reads server credentials from a YAML file.
+func LoadServerAuth(filePath string) (*ServerAuth, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	auth := new(ServerAuth)
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(auth); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// IsEmpty returns whether no credentials are configured.
+func (s *ServerAuth) IsEmpty() bool {
+	return len(s.BasicAuth) == 0 && len(s.BearerAuth) == 0
+}
+
+// Check returns whether the request carries any of the accepted credentials.
+func (s *ServerAuth) Check(req *http.Request) bool {
+	if s.IsEmpty() {
+		return true
+	}
+	if username, password, ok := req.BasicAuth(); ok {
+		for _, cred := range s.BasicAuth {
+			userOk := subtle.ConstantTimeCompare([]byte(username), []byte(cred.Username)) == 1
+			passOk := subtle.ConstantTimeCompare([]byte(password), []byte(cred.Password)) == 1
+			if userOk && passOk {
+				return true
+			}
+		}
+	}
+	if token, ok := strings.CutPrefix(req.Header.Get("authorization"), "Bearer "); ok {
+		for _, cred := range s.BearerAuth {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(cred.Token)) == 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type TLSConfig struct {
 	CAFile             string `json:"ca_file" yaml:"ca_file"`
 	CertFile           string `json:"cert_file" yaml:"cert_file"`
@@ -89,3 +148,142 @@ func (t *TLSConfig) getClientCertificate(_ *tls.CertificateRequestInfo) (*tls.Ce
 	}
 	return &cert, nil
 }
+
+// Roles of API clients. Admins may also do everything readers can.
+// The peer role is reserved for tracker replicas and only grants access to the replication API.
+const (
+	RoleRead  = "read"
+	RoleAdmin = "admin"
+	RolePeer  = "peer"
+)
+
+// RoleAllows returns whether a client with the given role may access endpoints requiring another role.
+func RoleAllows(role, required string) bool {
+	switch role {
+	case RoleAdmin:
+		return required == RoleAdmin || required == RoleRead
+	case RoleRead:
+		return required == RoleRead
+	case RolePeer:
+		return required == RolePeer
+	default:
+		return false
+	}
+}
+
+// APIAuth lists the clients allowed to access an API and their roles.
+// An empty APIAuth accepts all requests with admin role.
+type APIAuth struct {
+	Tokens  []*APIToken  `json:"tokens" yaml:"tokens"`
+	Clients []*APIClient `json:"clients" yaml:"clients"`
+}
+
+// APIToken is a bearer token identifying an API client.
+type APIToken struct {
+	Name      string `json:"name" yaml:"name"`
+	Role      string `json:"role" yaml:"role"`
+	Token     string `json:"token" yaml:"token"`
+	TokenFile string `json:"token_file" yaml:"token_file"` // read on load, alternative to token
+}
+
+// APIClient is an API client identified by a TLS client certificate.
+type APIClient struct {
+	Name       string `json:"name" yaml:"name"`
+	Role       string `json:"role" yaml:"role"`
+	CommonName string `json:"common_name" yaml:"common_name"` // subject CN of the verified certificate
+}
+
+// LoadAPIAuth reads API client credentials from a YAML file.
+// Token files are read as well.
+func LoadAPIAuth(filePath string) (*APIAuth, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	auth := new(APIAuth)
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(auth); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for i, token := range auth.Tokens {
+		if token == nil {
+			return nil, fmt.Errorf("tokens[%d] is empty", i)
+		}
+		if token.TokenFile != "" {
+			if token.Token != "" {
+				return nil, fmt.Errorf("token %q: token and token_file are mutually exclusive", token.Name)
+			}
+			buf, err := os.ReadFile(token.TokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("token %q: %w", token.Name, err)
+			}
+			token.Token = strings.TrimSpace(string(buf))
+		}
+	}
+	if err := auth.Validate(); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// Validate checks the credentials for semantic errors.
+func (a *APIAuth) Validate() error {
+	checkRole := func(role string) error {
+		if role != RoleRead && role != RoleAdmin {
+			return fmt.Errorf("unknown role %q", role)
+		}
+		return nil
+	}
+	for i, token := range a.Tokens {
+		if token == nil || token.Name == "" {
+			return fmt.Errorf("tokens[%d]: missing name", i)
+		}
+		if token.Token == "" {
+			return fmt.Errorf("token %q: empty token", token.Name)
+		}
+		if err := checkRole(token.Role); err != nil {
+			return fmt.Errorf("token %q: %w", token.Name, err)
+		}
+	}
+	for i, client := range a.Clients {
+		if client == nil || client.Name == "" {
+			return fmt.Errorf("clients[%d]: missing name", i)
+		}
+		if client.CommonName == "" {
+			return fmt.Errorf("client %q: missing common_name", client.Name)
+		}
+		if err := checkRole(client.Role); err != nil {
+			return fmt.Errorf("client %q: %w", client.Name, err)
+		}
+	}
+	return nil
+}
+
+// IsEmpty returns whether no credentials are configured.
+func (a *APIAuth) IsEmpty() bool {
+	return len(a.Tokens) == 0 && len(a.Clients) == 0
+}
+
+// Authenticate returns the name and role of the client sending the request.
+// Bearer tokens take precedence over TLS client certificates.
+func (a *APIAuth) Authenticate(req *http.Request) (name, role string, ok bool) {
+	if token, ok := strings.CutPrefix(req.Header.Get("authorization"), "Bearer "); ok {
+		for _, cred := range a.Tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(cred.Token)) == 1 {
+				return cred.Name, cred.Role, true
+			}
+		}
+	}
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 && len(req.TLS.VerifiedChains[0]) > 0 {
+		commonName := req.TLS.VerifiedChains[0][0].Subject.CommonName
+		for _, client := range a.Clients {
+			if client.CommonName == commonName {
+				return client.Name, client.Role, true
+			}
+		}
+	}
+	return "", "", false
+}
@@ -15,10 +15,16 @@
 package types
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBasicAuth_Apply(t *testing.T) {
@@ -37,3 +43,107 @@ func TestBearerAuth_Apply(t *testing.T) {
 	ba.Apply(header)
 	assert.Equal(t, "Bearer 123", header.Get("Authorization"))
 }
+
+func TestServerAuth_Check(t *testing.T) {
+	newRequest := func(apply func(http.Header)) *http.Request {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		if apply != nil {
+			apply(req.Header)
+		}
+		return req
+	}
+
+	assert.True(t, (&ServerAuth{}).Check(newRequest(nil)))
+
+	auth := &ServerAuth{
+		BasicAuth:  []*BasicAuth{{Username: "Aladdin", Password: "open sesame"}},
+		BearerAuth: []*BearerAuth{{Token: "123"}},
+	}
+	assert.False(t, auth.Check(newRequest(nil)))
+	assert.True(t, auth.Check(newRequest((&BasicAuth{Username: "Aladdin", Password: "open sesame"}).Apply)))
+	assert.False(t, auth.Check(newRequest((&BasicAuth{Username: "Aladdin", Password: "close sesame"}).Apply)))
+	assert.True(t, auth.Check(newRequest((&BearerAuth{Token: "123"}).Apply)))
+	assert.False(t, auth.Check(newRequest((&BearerAuth{Token: "1234"}).Apply)))
+}
+
+func TestLoadAPIAuth(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("from-file\n"), 0o600))
+	authFile := filepath.Join(dir, "auth.yml")
+	require.NoError(t, os.WriteFile(authFile, []byte(`
+tokens:
+  - name: fetchers
+    role: read
+    token: static
+  - name: ops
+    role: admin
+    token_file: `+tokenFile+`
+clients:
+  - name: deploy
+    role: admin
+    common_name: deploy.example.com
+`), 0o600))
+
+	auth, err := LoadAPIAuth(authFile)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", auth.Tokens[1].Token)
+
+	newRequest := func(apply func(http.Header)) *http.Request {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		if apply != nil {
+			apply(req.Header)
+		}
+		return req
+	}
+	name, role, ok := auth.Authenticate(newRequest((&BearerAuth{Token: "static"}).Apply))
+	assert.True(t, ok)
+	assert.Equal(t, "fetchers", name)
+	assert.Equal(t, RoleRead, role)
+	name, role, ok = auth.Authenticate(newRequest((&BearerAuth{Token: "from-file"}).Apply))
+	assert.True(t, ok)
+	assert.Equal(t, "ops", name)
+	assert.Equal(t, RoleAdmin, role)
+	_, _, ok = auth.Authenticate(newRequest((&BearerAuth{Token: "wrong"}).Apply))
+	assert.False(t, ok)
+
+	req := newRequest(nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "deploy.example.com"}}}},
+	}
+	name, role, ok = auth.Authenticate(req)
+	assert.True(t, ok)
+	assert.Equal(t, "deploy", name)
+	assert.Equal(t, RoleAdmin, role)
+	// Unverified certificates are ignored.
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "deploy.example.com"}}},
+	}
+	_, _, ok = auth.Authenticate(req)
+	assert.False(t, ok)
+}
+
+func TestAPIAuth_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		auth APIAuth
+		err  string
+	}{
+		{APIAuth{Tokens: []*APIToken{{Role: RoleRead, Token: "x"}}}, "tokens[0]: missing name"},
+		{APIAuth{Tokens: []*APIToken{{Name: "a", Role: RoleRead}}}, `token "a": empty token`},
+		{APIAuth{Tokens: []*APIToken{{Name: "a", Role: "root", Token: "x"}}}, `token "a": unknown role "root"`},
+		{APIAuth{Clients: []*APIClient{{Name: "b", Role: RoleAdmin}}}, `client "b": missing common_name`},
+	} {
+		assert.EqualError(t, tc.auth.Validate(), tc.err)
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, RoleAllows(RoleAdmin, RoleAdmin))
+	assert.True(t, RoleAllows(RoleAdmin, RoleRead))
+	assert.True(t, RoleAllows(RoleRead, RoleRead))
+	assert.False(t, RoleAllows(RoleRead, RoleAdmin))
+	assert.False(t, RoleAllows("", RoleRead))
+	assert.True(t, RoleAllows(RolePeer, RolePeer))
+	assert.False(t, RoleAllows(RolePeer, RoleRead))
+	assert.False(t, RoleAllows(RoleAdmin, RolePeer))
+}
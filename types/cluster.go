@@ -0,0 +1,48 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "time"
+
+// ClusterStatus describes how caught up the nodes of each target group are.
+type ClusterStatus struct {
+	Groups []*GroupSlotStatus `json:"groups"`
+}
+
+// GroupSlotStatus describes the slot progress of a target group.
+type GroupSlotStatus struct {
+	Group        string            `json:"group"`
+	MaxProcessed uint64            `json:"max_processed"`
+	MaxConfirmed uint64            `json:"max_confirmed"`
+	MaxRooted    uint64            `json:"max_rooted"`
+	Nodes        []*NodeSlotStatus `json:"nodes"`
+}
+
+// NodeSlotStatus describes the latest slots seen by a single node,
+// and its lag versus the highest slots seen in its group.
+type NodeSlotStatus struct {
+	Target    string `json:"target"`
+	Connected bool   `json:"connected"`
+
+	Processed uint64 `json:"processed"`
+	Confirmed uint64 `json:"confirmed"`
+	Rooted    uint64 `json:"rooted"`
+
+	ProcessedLag uint64 `json:"processed_lag"`
+	ConfirmedLag uint64 `json:"confirmed_lag"`
+	RootedLag    uint64 `json:"rooted_lag"`
+
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
@@ -17,10 +17,14 @@ package types
 import (
 	"bufio"
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
 
+	"golang.org/x/net/http/httpguts"
 	"gopkg.in/yaml.v3"
 )
 
@@ -28,6 +32,13 @@ import (
 type Config struct {
 	ScrapeInterval time.Duration  `json:"scrape_interval" yaml:"scrape_interval"`
 	TargetGroups   []*TargetGroup `json:"target_groups" yaml:"target_groups"`
+
+	// Scoring ranks best snapshots by weighted inputs.
+	// If nil, best snapshots are ranked by slot only.
+	Scoring *ScoringConfig `json:"scoring" yaml:"scoring"`
+
+	// Webhooks notify external systems about snapshot and target events.
+	Webhooks []*WebhookRule `json:"webhooks" yaml:"webhooks"`
 }
 
 // LoadConfig reads the config object from the file system.
@@ -41,8 +52,87 @@ func LoadConfig(filePath string) (*Config, error) {
 	conf := new(Config)
 	decoder := yaml.NewDecoder(f)
 	decoder.KnownFields(true)
-	confErr := decoder.Decode(conf)
-	return conf, confErr
+	if err := decoder.Decode(conf); err != nil {
+		return nil, err
+	}
+	conf.SetDefaults()
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return conf, nil
+}
+
+// DefaultScrapeInterval is used if the config does not set scrape_interval.
+const DefaultScrapeInterval = 15 * time.Second
+
+// SetDefaults fills in settings that configs written for older versions may omit.
+func (c *Config) SetDefaults() {
+	if c.ScrapeInterval == 0 {
+		c.ScrapeInterval = DefaultScrapeInterval
+	}
+	for _, group := range c.TargetGroups {
+		if group == nil {
+			continue
+		}
+		group.Scheme = strings.ToLower(group.Scheme)
+		if group.Scheme == "" {
+			group.Scheme = "http"
+		}
+	}
+}
+
+// Validate checks the config for semantic errors.
+func (c *Config) Validate() error {
+	if c.ScrapeInterval <= 0 {
+		return fmt.Errorf("scrape_interval must be positive")
+	}
+	names := make(map[string]struct{}, len(c.TargetGroups))
+	for i, group := range c.TargetGroups {
+		if group == nil {
+			return fmt.Errorf("target_groups[%d] is empty", i)
+		}
+		if group.Group == "" {
+			return fmt.Errorf("target_groups[%d]: missing group name", i)
+		}
+		if _, ok := names[group.Group]; ok {
+			return fmt.Errorf("duplicate target group %q", group.Group)
+		}
+		names[group.Group] = struct{}{}
+		if err := group.Validate(); err != nil {
+			return fmt.Errorf("target group %q: %w", group.Group, err)
+		}
+		if group.ScrapeTimeout > group.Interval(c.ScrapeInterval) {
+			return fmt.Errorf("target group %q: scrape_timeout exceeds scrape interval", group.Group)
+		}
+		if group.Federation != nil && group.Federation.Penalty != 0 && c.Scoring == nil {
+			return fmt.Errorf("target group %q: federation.penalty requires scoring", group.Group)
+		}
+	}
+	if c.Scoring != nil {
+		if err := c.Scoring.Validate(); err != nil {
+			return fmt.Errorf("scoring: %w", err)
+		}
+	}
+	rules := make(map[string]struct{}, len(c.Webhooks))
+	for i, rule := range c.Webhooks {
+		if rule == nil {
+			return fmt.Errorf("webhooks[%d] is empty", i)
+		}
+		if rule.Name == "" {
+			return fmt.Errorf("webhooks[%d]: missing name", i)
+		}
+		if _, ok := rules[rule.Name]; ok {
+			return fmt.Errorf("duplicate webhook %q", rule.Name)
+		}
+		rules[rule.Name] = struct{}{}
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("webhook %q: %w", rule.Name, err)
+		}
+		if _, ok := names[rule.Group]; rule.Group != "" && !ok {
+			return fmt.Errorf("webhook %q: unknown target group %q", rule.Name, rule.Group)
+		}
+	}
+	return nil
 }
 
 // TargetGroup explains how to retrieve snapshots from a group of Solana nodes.
@@ -54,11 +144,295 @@ type TargetGroup struct {
 	BearerAuth *BearerAuth `json:"bearer_auth" yaml:"bearer_auth"`
 	TLSConfig  *TLSConfig  `json:"tls_config" yaml:"tls_config"`
 
+	// Scrape settings of the group. Zero values use the defaults.
+	ScrapeInterval  time.Duration     `json:"scrape_interval" yaml:"scrape_interval"`   // defaults to the global scrape_interval
+	ScrapeTimeout   time.Duration     `json:"scrape_timeout" yaml:"scrape_timeout"`     // defaults to 10s
+	ProxyURL        string            `json:"proxy_url" yaml:"proxy_url"`               // defaults to the proxy environment variables
+	TLSServerName   string            `json:"tls_server_name" yaml:"tls_server_name"`   // defaults to the target host
+	FollowRedirects *bool             `json:"follow_redirects" yaml:"follow_redirects"` // defaults to following one redirect
+	Headers         map[string]string `json:"headers" yaml:"headers"`                   // sent with every scrape
+
+	Expiry  *TargetExpiry `json:"expiry" yaml:"expiry"`
+	Cadence *Cadence      `json:"cadence" yaml:"cadence"`
+
+	// Labels describe the location of all targets in the group, e.g. region.
+	Labels map[string]string `json:"labels" yaml:"labels"`
+	// TargetLabels add to or override the group labels of individual targets, e.g. rack.
+	TargetLabels map[string]map[string]string `json:"target_labels" yaml:"target_labels"`
+
+	// Federation turns the targets of the group into other trackers.
+	// Their local snapshot sources are ingested, tagged with the tracker as origin.
+	Federation *Federation `json:"federation" yaml:"federation"`
+
+	// AnnounceAuth authenticates sidecars pushing their snapshots.
+	// Pushes are disabled if unset.
+	AnnounceAuth *AnnounceAuth `json:"announce_auth" yaml:"announce_auth"`
+
 	StaticTargets  *StaticTargets  `json:"static_targets" yaml:"static_targets"`
 	FileTargets    *FileTargets    `json:"file_targets" yaml:"file_targets"`
 	ConsulSDConfig *ConsulSDConfig `json:"consul_sd_config" yaml:"consul_sd_config"`
 }
 
+// Validate checks the target group config for semantic errors.
+func (t *TargetGroup) Validate() error {
+	switch t.Scheme {
+	case "http", "https":
+	default:
+		return fmt.Errorf("unsupported scheme %q", t.Scheme)
+	}
+	if t.TLSConfig != nil && t.Scheme != "https" {
+		return fmt.Errorf("tls_config requires https scheme")
+	}
+	if t.TLSServerName != "" && t.Scheme != "https" {
+		return fmt.Errorf("tls_server_name requires https scheme")
+	}
+	if t.ScrapeInterval < 0 {
+		return fmt.Errorf("scrape_interval must not be negative")
+	}
+	if t.ScrapeTimeout < 0 {
+		return fmt.Errorf("scrape_timeout must not be negative")
+	}
+	if t.ProxyURL != "" {
+		u, err := url.Parse(t.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy_url: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("unsupported proxy_url scheme %q", u.Scheme)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("proxy_url is missing a host")
+		}
+	}
+	for name, value := range t.Headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("invalid value of header %q", name)
+		}
+		if http.CanonicalHeaderKey(name) == "Authorization" && (t.BasicAuth != nil || t.BearerAuth != nil) {
+			return fmt.Errorf("header %q conflicts with basic_auth or bearer_auth", name)
+		}
+	}
+	var discoverers int
+	for _, present := range []bool{t.StaticTargets != nil, t.FileTargets != nil, t.ConsulSDConfig != nil} {
+		if present {
+			discoverers++
+		}
+	}
+	if discoverers != 1 {
+		return fmt.Errorf("exactly one of static_targets, file_targets, consul_sd_config required")
+	}
+	if t.Cadence != nil {
+		if t.Cadence.StallAfter < 0 {
+			return fmt.Errorf("cadence.stall_after must not be negative")
+		}
+		if t.Cadence.StallFactor < 0 {
+			return fmt.Errorf("cadence.stall_factor must not be negative")
+		}
+	}
+	if t.Federation != nil {
+		if t.Federation.Penalty < 0 {
+			return fmt.Errorf("federation.penalty must not be negative")
+		}
+		if t.AnnounceAuth != nil {
+			return fmt.Errorf("announce_auth is not supported with federation")
+		}
+	}
+	if t.AnnounceAuth != nil && t.AnnounceAuth.Secret == "" {
+		return fmt.Errorf("announce_auth.secret is required")
+	}
+	if t.Expiry != nil {
+		if t.Expiry.MaxFailures < 0 {
+			return fmt.Errorf("expiry.max_failures must not be negative")
+		}
+		if t.Expiry.MaxAge < 0 {
+			return fmt.Errorf("expiry.max_age must not be negative")
+		}
+	}
+	return nil
+}
+
+// Interval returns the scrape interval of the group, given the global default.
+func (t *TargetGroup) Interval(global time.Duration) time.Duration {
+	if t.ScrapeInterval > 0 {
+		return t.ScrapeInterval
+	}
+	return global
+}
+
+// LabelsOf returns the labels of a target in this group.
+func (t *TargetGroup) LabelsOf(target string) map[string]string {
+	labels := make(map[string]string, len(t.Labels))
+	for key, value := range t.Labels {
+		labels[key] = value
+	}
+	for key, value := range t.TargetLabels[target] {
+		labels[key] = value
+	}
+	return labels
+}
+
+// ScoringConfig weighs the inputs of the best snapshots ranking.
+//
+// Each weight scales one score component, and sources are ranked by the sum of all components.
+// Components that only penalize are subtracted, so all weights are non-negative.
+// Zero weights disable the respective component.
+type ScoringConfig struct {
+	Weights ScoreWeights `json:"weights" yaml:"weights"`
+	// PreferLabels are matched against target labels, e.g. the region of the tracker's clients.
+	PreferLabels map[string]string `json:"prefer_labels" yaml:"prefer_labels"`
+}
+
+// ScoreWeights are the weights of score components.
+type ScoreWeights struct {
+	Slot      float64 `json:"slot" yaml:"slot"`           // per slot behind the newest snapshot
+	Freshness float64 `json:"freshness" yaml:"freshness"` // per second since the last scrape
+	Latency   float64 `json:"latency" yaml:"latency"`     // per second of the last scrape duration
+	Health    float64 `json:"health" yaml:"health"`       // per consecutive scrape failure
+	Agreement float64 `json:"agreement" yaml:"agreement"` // per other source serving the same snapshot
+	Labels    float64 `json:"labels" yaml:"labels"`       // per matching preferred label
+	SlotLag   float64 `json:"slot_lag" yaml:"slot_lag"`   // per slot the node is behind its group (requires --slot-monitor)
+}
+
+// Validate checks the scoring config for semantic errors.
+func (s *ScoringConfig) Validate() error {
+	w := s.Weights
+	for _, weight := range []struct {
+		name  string
+		value float64
+	}{
+		{"slot", w.Slot},
+		{"freshness", w.Freshness},
+		{"latency", w.Latency},
+		{"health", w.Health},
+		{"agreement", w.Agreement},
+		{"labels", w.Labels},
+		{"slot_lag", w.SlotLag},
+	} {
+		if weight.value < 0 {
+			return fmt.Errorf("weights.%s must not be negative", weight.name)
+		}
+	}
+	return nil
+}
+
+// Webhook conditions.
+const (
+	ConditionNewBestFull   = "new_best_full"  // a group has a newer full snapshot
+	ConditionSnapshotAge   = "snapshot_age"   // the newest snapshot of a group is older than max_age
+	ConditionHashConflict  = "hash_conflict"  // targets of a group disagree on the hash of a slot
+	ConditionTargetFailing = "target_failing" // a target failed max_failures scrapes in a row
+)
+
+// Webhook payload formats.
+const (
+	WebhookFormatJSON      = "json"      // WebhookEvent
+	WebhookFormatSlack     = "slack"     // Slack incoming webhook message
+	WebhookFormatPagerDuty = "pagerduty" // PagerDuty Events API v2
+)
+
+// PagerDutyEventsURL is the default URL of the pagerduty format.
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// WebhookRule sends a webhook whenever its condition fires or resolves.
+type WebhookRule struct {
+	Name      string `json:"name" yaml:"name"`
+	Condition string `json:"condition" yaml:"condition"`
+	Group     string `json:"group" yaml:"group"` // all groups if empty
+
+	MaxAge      time.Duration `json:"max_age" yaml:"max_age"`           // snapshot_age threshold
+	MaxFailures int           `json:"max_failures" yaml:"max_failures"` // target_failing threshold, defaults to 3
+
+	URL        string            `json:"url" yaml:"url"`                 // defaults to PagerDutyEventsURL for pagerduty
+	Format     string            `json:"format" yaml:"format"`           // defaults to json
+	RoutingKey string            `json:"routing_key" yaml:"routing_key"` // PagerDuty integration key
+	Severity   string            `json:"severity" yaml:"severity"`       // PagerDuty severity, defaults to warning
+	Headers    map[string]string `json:"headers" yaml:"headers"`
+}
+
+// Validate checks the webhook rule for semantic errors.
+func (w *WebhookRule) Validate() error {
+	switch w.Condition {
+	case ConditionNewBestFull, ConditionHashConflict:
+	case ConditionSnapshotAge:
+		if w.MaxAge <= 0 {
+			return fmt.Errorf("max_age must be positive")
+		}
+	case ConditionTargetFailing:
+		if w.MaxFailures < 0 {
+			return fmt.Errorf("max_failures must not be negative")
+		}
+	case "":
+		return fmt.Errorf("missing condition")
+	default:
+		return fmt.Errorf("unknown condition %q", w.Condition)
+	}
+	switch w.Format {
+	case "", WebhookFormatJSON, WebhookFormatSlack:
+		if w.URL == "" {
+			return fmt.Errorf("missing url")
+		}
+	case WebhookFormatPagerDuty:
+		if w.RoutingKey == "" {
+			return fmt.Errorf("pagerduty requires routing_key")
+		}
+		switch w.Severity {
+		case "", "critical", "error", "warning", "info":
+		default:
+			return fmt.Errorf("unknown severity %q", w.Severity)
+		}
+	default:
+		return fmt.Errorf("unknown format %q", w.Format)
+	}
+	if w.URL != "" {
+		u, err := url.Parse(w.URL)
+		if err != nil {
+			return fmt.Errorf("invalid url: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("unsupported url scheme %q", u.Scheme)
+		}
+	}
+	for name, value := range w.Headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("invalid value of header %q", name)
+		}
+	}
+	return nil
+}
+
+// TargetExpiry controls when snapshots of a target get removed from the index.
+// Zero values disable the respective rule.
+type TargetExpiry struct {
+	MaxFailures int           `json:"max_failures" yaml:"max_failures"` // consecutive failed scrapes
+	MaxAge      time.Duration `json:"max_age" yaml:"max_age"`           // time since last successful scrape
+	DropMissing bool          `json:"drop_missing" yaml:"drop_missing"` // target no longer discovered
+}
+
+// Federation configures a group of other trackers, e.g. in other datacenters.
+//
+// Only sources scraped by those trackers themselves are ingested,
+// so federated trackers never re-export each other's sources and loops are impossible.
+type Federation struct {
+	// Penalty is subtracted from the score of each ingested source if scoring is enabled.
+	// Regardless, local sources come before ingested ones of the same slot.
+	Penalty float64 `json:"penalty" yaml:"penalty"`
+}
+
+// Cadence controls when a target counts as a stalled snapshot producer.
+// Zero values use the defaults.
+type Cadence struct {
+	StallAfter  time.Duration `json:"stall_after" yaml:"stall_after"`   // max time without a new snapshot, derived from history by default
+	StallFactor float64       `json:"stall_factor" yaml:"stall_factor"` // multiple of the usual interval tolerated, default 3
+}
+
 // StaticTargets is a hardcoded list of Solana nodes.
 type StaticTargets struct {
 	Targets []string `json:"targets" yaml:"targets"`
@@ -37,3 +37,225 @@ func TestLoadConfig(t *testing.T) {
 
 	assert.Equal(t, expected, actual)
 }
+
+func TestLoadConfig_Legacy(t *testing.T) {
+	// Example config shipped before per-group settings were introduced.
+	legacy, err := LoadConfig("testdata/legacy-config.yml")
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Second, legacy.ScrapeInterval)
+	require.Len(t, legacy.TargetGroups, 1)
+	assert.Equal(t, "http", legacy.TargetGroups[0].Scheme)
+
+	// Omitted settings get defaults.
+	minimal, err := LoadConfig("testdata/minimal-config.yml")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultScrapeInterval, minimal.ScrapeInterval)
+	require.Len(t, minimal.TargetGroups, 2)
+	assert.Equal(t, "http", minimal.TargetGroups[0].Scheme)
+	assert.Equal(t, "https", minimal.TargetGroups[1].Scheme)
+}
+
+func TestTargetGroup_Validate(t *testing.T) {
+	static := &StaticTargets{Targets: []string{"localhost:8899"}}
+	cases := []struct {
+		name  string
+		group TargetGroup
+		err   string
+	}{
+		{
+			name: "Valid",
+			group: TargetGroup{
+				Scheme:        "https",
+				StaticTargets: static,
+				ScrapeTimeout: 5 * time.Second,
+				ProxyURL:      "socks5://proxy.example.org:1080",
+				TLSServerName: "sidecar.example.org",
+				Headers:       map[string]string{"X-Cluster": "mainnet"},
+			},
+		},
+		{
+			name:  "TLSServerNameWithoutHTTPS",
+			group: TargetGroup{Scheme: "http", StaticTargets: static, TLSServerName: "sidecar.example.org"},
+			err:   "tls_server_name requires https scheme",
+		},
+		{
+			name:  "NegativeTimeout",
+			group: TargetGroup{Scheme: "http", StaticTargets: static, ScrapeTimeout: -time.Second},
+			err:   "scrape_timeout must not be negative",
+		},
+		{
+			name:  "ProxyScheme",
+			group: TargetGroup{Scheme: "http", StaticTargets: static, ProxyURL: "ftp://proxy.example.org"},
+			err:   `unsupported proxy_url scheme "ftp"`,
+		},
+		{
+			name:  "ProxyHost",
+			group: TargetGroup{Scheme: "http", StaticTargets: static, ProxyURL: "http://"},
+			err:   "proxy_url is missing a host",
+		},
+		{
+			name:  "HeaderName",
+			group: TargetGroup{Scheme: "http", StaticTargets: static, Headers: map[string]string{"X Cluster": "mainnet"}},
+			err:   `invalid header name "X Cluster"`,
+		},
+		{
+			name:  "HeaderValue",
+			group: TargetGroup{Scheme: "http", StaticTargets: static, Headers: map[string]string{"X-Cluster": "main\nnet"}},
+			err:   `invalid value of header "X-Cluster"`,
+		},
+		{
+			name: "AuthorizationHeader",
+			group: TargetGroup{
+				Scheme:        "http",
+				StaticTargets: static,
+				BearerAuth:    &BearerAuth{Token: "secret"},
+				Headers:       map[string]string{"authorization": "Bearer other"},
+			},
+			err: `header "authorization" conflicts with basic_auth or bearer_auth`,
+		},
+		{
+			name:  "CadenceStallAfter",
+			group: TargetGroup{Scheme: "http", StaticTargets: static, Cadence: &Cadence{StallAfter: -time.Minute}},
+			err:   "cadence.stall_after must not be negative",
+		},
+		{
+			name:  "FederationPenalty",
+			group: TargetGroup{Scheme: "http", StaticTargets: static, Federation: &Federation{Penalty: -1}},
+			err:   "federation.penalty must not be negative",
+		},
+		{
+			name: "FederationAnnounce",
+			group: TargetGroup{
+				Scheme:        "http",
+				StaticTargets: static,
+				Federation:    &Federation{},
+				AnnounceAuth:  &AnnounceAuth{Secret: "secret"},
+			},
+			err: "announce_auth is not supported with federation",
+		},
+		{
+			name:  "AnnounceSecret",
+			group: TargetGroup{Scheme: "http", StaticTargets: static, AnnounceAuth: &AnnounceAuth{}},
+			err:   "announce_auth.secret is required",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.group.Validate()
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_ScrapeTimeout(t *testing.T) {
+	config := &Config{
+		ScrapeInterval: 15 * time.Second,
+		TargetGroups: []*TargetGroup{{
+			Group:         "mainnet",
+			Scheme:        "http",
+			StaticTargets: &StaticTargets{Targets: []string{"localhost:8899"}},
+			ScrapeTimeout: 10 * time.Second,
+		}},
+	}
+	assert.NoError(t, config.Validate())
+
+	config.TargetGroups[0].ScrapeInterval = 5 * time.Second
+	assert.EqualError(t, config.Validate(), `target group "mainnet": scrape_timeout exceeds scrape interval`)
+}
+
+func TestConfig_Validate_FederationPenalty(t *testing.T) {
+	config := &Config{
+		ScrapeInterval: 15 * time.Second,
+		TargetGroups: []*TargetGroup{{
+			Group:         "remote",
+			Scheme:        "http",
+			StaticTargets: &StaticTargets{Targets: []string{"tracker:8458"}},
+			Federation:    &Federation{Penalty: 100},
+		}},
+	}
+	assert.EqualError(t, config.Validate(), `target group "remote": federation.penalty requires scoring`)
+
+	config.Scoring = &ScoringConfig{}
+	assert.NoError(t, config.Validate())
+}
+
+func TestWebhookRule_Validate(t *testing.T) {
+	cases := []struct {
+		name string
+		rule WebhookRule
+		err  string
+	}{
+		{
+			name: "JSON",
+			rule: WebhookRule{Condition: ConditionNewBestFull, URL: "https://hooks.example.org/snapshot"},
+		},
+		{
+			name: "PagerDuty",
+			rule: WebhookRule{Condition: ConditionHashConflict, Format: WebhookFormatPagerDuty, RoutingKey: "key", Severity: "critical"},
+		},
+		{
+			name: "UnknownCondition",
+			rule: WebhookRule{Condition: "slot_lag", URL: "https://hooks.example.org"},
+			err:  `unknown condition "slot_lag"`,
+		},
+		{
+			name: "MissingMaxAge",
+			rule: WebhookRule{Condition: ConditionSnapshotAge, URL: "https://hooks.example.org"},
+			err:  "max_age must be positive",
+		},
+		{
+			name: "MissingURL",
+			rule: WebhookRule{Condition: ConditionTargetFailing, Format: WebhookFormatSlack},
+			err:  "missing url",
+		},
+		{
+			name: "MissingRoutingKey",
+			rule: WebhookRule{Condition: ConditionTargetFailing, Format: WebhookFormatPagerDuty},
+			err:  "pagerduty requires routing_key",
+		},
+		{
+			name: "URLScheme",
+			rule: WebhookRule{Condition: ConditionHashConflict, URL: "ftp://hooks.example.org"},
+			err:  `unsupported url scheme "ftp"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.rule.Validate()
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_Webhooks(t *testing.T) {
+	config := &Config{
+		ScrapeInterval: 15 * time.Second,
+		TargetGroups: []*TargetGroup{{
+			Group:         "mainnet",
+			Scheme:        "http",
+			StaticTargets: &StaticTargets{Targets: []string{"localhost:8899"}},
+		}},
+		Webhooks: []*WebhookRule{{
+			Name:      "failing",
+			Condition: ConditionTargetFailing,
+			Group:     "mainnet",
+			URL:       "https://hooks.example.org",
+		}},
+	}
+	assert.NoError(t, config.Validate())
+
+	config.Webhooks[0].Group = "testnet"
+	assert.EqualError(t, config.Validate(), `webhook "failing": unknown target group "testnet"`)
+
+	config.Webhooks[0].Group = ""
+	config.Webhooks = append(config.Webhooks, config.Webhooks[0])
+	assert.EqualError(t, config.Validate(), `duplicate webhook "failing"`)
+}
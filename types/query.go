@@ -0,0 +1,98 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Snapshot kinds accepted by SnapshotQuery.Kind.
+const (
+	SnapshotKindFull        = "full"
+	SnapshotKindIncremental = "incremental"
+)
+
+// Sort orders accepted by SnapshotQuery.Sort.
+const (
+	SortSlotDesc      = "slot_desc" // default
+	SortSlotAsc       = "slot_asc"
+	SortUpdatedAtDesc = "updated_at_desc"
+	SortUpdatedAtAsc  = "updated_at_asc"
+	SortSizeDesc      = "size_desc"
+	SortSizeAsc       = "size_asc"
+)
+
+// SnapshotQuery filters and pages through snapshots known to the tracker.
+//
+// Zero values do not filter.
+type SnapshotQuery struct {
+	Group     string        `form:"group"`
+	Target    string        `form:"target"`
+	MinSlot   uint64        `form:"min_slot"`
+	MaxSlot   uint64        `form:"max_slot"`
+	Hash      string        `form:"hash"` // base58
+	Kind      string        `form:"kind"` // "full" or "incremental"
+	BaseSlot  *uint64       `form:"base_slot"`
+	MinSize   uint64        `form:"min_size"`
+	MaxAge    time.Duration `form:"max_age"` // time since the snapshot was created
+	Verified  bool          `form:"verified"`
+	LocalOnly bool          `form:"local_only"` // exclude sources learned from federated trackers
+
+	Sort   string `form:"sort"`
+	Cursor string `form:"cursor"` // returned by the previous page
+	Limit  int    `form:"limit"`  // page size
+}
+
+// Values encodes the query as URL query parameters.
+func (q *SnapshotQuery) Values() url.Values {
+	v := make(url.Values)
+	setString := func(key, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	setUint := func(key string, value uint64) {
+		if value != 0 {
+			v.Set(key, strconv.FormatUint(value, 10))
+		}
+	}
+	setString("group", q.Group)
+	setString("target", q.Target)
+	setUint("min_slot", q.MinSlot)
+	setUint("max_slot", q.MaxSlot)
+	setString("hash", q.Hash)
+	setString("kind", q.Kind)
+	if q.BaseSlot != nil {
+		v.Set("base_slot", strconv.FormatUint(*q.BaseSlot, 10))
+	}
+	setUint("min_size", q.MinSize)
+	if q.MaxAge != 0 {
+		v.Set("max_age", q.MaxAge.String())
+	}
+	if q.Verified {
+		v.Set("verified", "true")
+	}
+	if q.LocalOnly {
+		v.Set("local_only", "true")
+	}
+	setString("sort", q.Sort)
+	setString("cursor", q.Cursor)
+	if q.Limit != 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return v
+}
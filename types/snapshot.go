@@ -24,8 +24,26 @@ import (
 // SnapshotSource describes a snapshot, and where to get it from.
 type SnapshotSource struct {
 	SnapshotInfo
-	Target    string    `json:"target"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Target     string    `json:"target"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Unverified bool      `json:"unverified,omitempty"` // restored from disk, not yet re-scraped
+	Origin     string    `json:"origin,omitempty"`     // federated tracker the source was learned from
+
+	Score *SnapshotScore `json:"score,omitempty"` // set if the tracker ranks by score
+}
+
+// SnapshotScore explains the rank of a snapshot source.
+// Each component is the weighted contribution to the total.
+type SnapshotScore struct {
+	Total     float64 `json:"total"`
+	Slot      float64 `json:"slot"`
+	Freshness float64 `json:"freshness"`
+	Latency   float64 `json:"latency"`
+	Health    float64 `json:"health"`
+	Agreement float64 `json:"agreement"`
+	Labels    float64 `json:"labels"`
+	Origin    float64 `json:"origin"`   // federation penalty
+	SlotLag   float64 `json:"slot_lag"` // processed slot lag of the node
 }
 
 // SnapshotInfo describes a snapshot.
@@ -34,6 +52,32 @@ type SnapshotInfo struct {
 	Hash      solana.Hash     `json:"hash"`
 	Files     []*SnapshotFile `json:"files"`
 	TotalSize uint64          `json:"size"`
+	Draining  bool            `json:"draining,omitempty"` // source is shutting down
+
+	*SnapshotMetadata // optional, read from archive contents
+}
+
+// IsFull returns whether the snapshot can be restored without another snapshot.
+func (s *SnapshotInfo) IsFull() bool {
+	return s.BaseSlot() == 0
+}
+
+// BaseSlot returns the slot of the full snapshot an incremental snapshot builds on,
+// or zero for full snapshots.
+func (s *SnapshotInfo) BaseSlot() uint64 {
+	if len(s.Files) == 0 {
+		return 0
+	}
+	return s.Files[0].BaseSlot
+}
+
+// SnapshotMetadata describes the bank state contained in a snapshot archive.
+type SnapshotMetadata struct {
+	Version        string       `json:"version,omitempty"`
+	Epoch          uint64       `json:"epoch"`
+	ParentSlot     uint64       `json:"parent_slot"`
+	Capitalization uint64       `json:"capitalization"`
+	AccountsHash   *solana.Hash `json:"accounts_hash,omitempty"`
 }
 
 // SnapshotFile is a file that makes up a snapshot (either full or incremental).
@@ -0,0 +1,88 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Target health states.
+const (
+	TargetHealthUnknown = "unknown" // discovered, not scraped yet
+	TargetHealthUp      = "up"
+	TargetHealthDown    = "down"
+)
+
+// TargetStatus describes the scrape health of a sidecar.
+type TargetStatus struct {
+	Group               string        `json:"group"`
+	Target              string        `json:"target"`
+	Source              string        `json:"source"` // discovery mechanism
+	Health              string        `json:"health"`
+	LastScrape          time.Time     `json:"last_scrape"`
+	LastDuration        time.Duration `json:"last_duration"`
+	LastError           string        `json:"last_error,omitempty"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	TotalScrapes        uint64        `json:"total_scrapes"`
+	TotalFailures       uint64        `json:"total_failures"`
+	NumSnapshots        int           `json:"num_snapshots"`
+}
+
+// TargetHistory describes when a target produced its recent snapshots.
+//
+// First sightings are only known for snapshots that appeared while the tracker was watching.
+// Snapshots already present on the first scrape are marked initial and do not count towards the cadence.
+type TargetHistory struct {
+	Group        string             `json:"group"`
+	Target       string             `json:"target"`
+	Snapshots    []SnapshotSighting `json:"snapshots"`               // newest first
+	Full         *SnapshotCadence   `json:"full,omitempty"`          // between full snapshots
+	Incremental  *SnapshotCadence   `json:"incremental,omitempty"`   // between incremental snapshots
+	LastProduced *time.Time         `json:"last_produced,omitempty"` // first sighting of the newest snapshot produced
+	StallAfter   time.Duration      `json:"stall_after,omitempty"`   // max time without a new snapshot, zero if unknown
+	Stalled      bool               `json:"stalled"`
+}
+
+// SnapshotSighting records when the tracker first saw a snapshot on a target.
+type SnapshotSighting struct {
+	Slot      uint64      `json:"slot"`
+	BaseSlot  uint64      `json:"base_slot,omitempty"`
+	Hash      solana.Hash `json:"hash"`
+	FirstSeen time.Time   `json:"first_seen"`
+	Initial   bool        `json:"initial,omitempty"` // already present on the first scrape
+}
+
+// IsFull returns whether the snapshot is a full snapshot.
+func (s *SnapshotSighting) IsFull() bool {
+	return s.BaseSlot == 0
+}
+
+// SnapshotCadence summarizes the intervals between consecutive snapshots of a kind.
+type SnapshotCadence struct {
+	Intervals      int           `json:"intervals"`       // number of intervals observed
+	LastInterval   time.Duration `json:"last_interval"`   // between the two newest snapshots
+	MedianInterval time.Duration `json:"median_interval"` // robust to missed snapshots
+	MedianSlots    uint64        `json:"median_slots"`
+}
+
+// Cordon excludes a target from best snapshot results.
+type Cordon struct {
+	Target    string    `json:"target"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedBy string    `json:"created_by"` // name of the admin client
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "github.com/gagliardetto/solana-go"
+
+// SnapshotID identifies a snapshot regardless of which nodes serve it.
+type SnapshotID struct {
+	Slot uint64      `json:"slot"`
+	Hash solana.Hash `json:"hash"`
+}
+
+// BestSnapshotsEvent is sent by the best snapshots watch stream.
+//
+// The first event of a stream carries the initial ranking.
+// Subsequent events are sent when the ranking changed.
+type BestSnapshotsEvent struct {
+	Snapshots []SnapshotSource `json:"snapshots"`         // current ranking
+	Added     []SnapshotID     `json:"added,omitempty"`   // snapshots that gained their first source
+	Removed   []SnapshotID     `json:"removed,omitempty"` // snapshots that lost all sources
+}
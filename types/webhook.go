@@ -0,0 +1,45 @@
+// Copyright 2022 Blockdaemon Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Status values of WebhookEvent.
+const (
+	WebhookFiring   = "firing"
+	WebhookResolved = "resolved"
+)
+
+// WebhookEvent is the payload of webhooks in json format.
+//
+// The ID stays the same when an event is resolved, retried or sent by another tracker replica,
+// so receivers can deduplicate by ID and status.
+type WebhookEvent struct {
+	ID        string        `json:"id"`
+	Rule      string        `json:"rule"`
+	Condition string        `json:"condition"`
+	Status    string        `json:"status"`
+	Group     string        `json:"group"`
+	Target    string        `json:"target,omitempty"`
+	Slot      uint64        `json:"slot,omitempty"`
+	BaseSlot  uint64        `json:"base_slot,omitempty"`
+	Hashes    []solana.Hash `json:"hashes,omitempty"`
+	Summary   string        `json:"summary"`
+	Time      time.Time     `json:"time"`
+}